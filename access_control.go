@@ -0,0 +1,176 @@
+package embedded
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/mysql_db"
+	"github.com/go-sql-driver/mysql"
+)
+
+// Access is the kind of access a Grant gives to its tables.
+type Access int
+
+const (
+	// AccessRead allows reading tables and views
+	AccessRead Access = iota
+	// AccessReadWrite allows reading and modifying the data and schema of tables and views
+	AccessReadWrite
+)
+
+// AllDatabases can be used as Grant.Database to grant access to every database.
+const AllDatabases = "*"
+
+// Grant gives an Account access to a database, or a single table in it.
+type Grant struct {
+	// Database is the name of the database the grant applies to, or AllDatabases
+	Database string
+	// Table is the name of the table the grant applies to. The grant applies to every table in Database if it's empty.
+	Table string
+	// Access is the kind of access granted
+	Access Access
+}
+
+// Account is a user connections can authenticate as when access control is enabled with Config.Users.
+type Account struct {
+	Name     string
+	Password string
+	Grants   []Grant
+}
+
+var (
+	readPrivileges = []sql.PrivilegeType{
+		sql.PrivilegeType_Select,
+		sql.PrivilegeType_ShowView,
+	}
+	readWriteTablePrivileges = []sql.PrivilegeType{
+		sql.PrivilegeType_Select,
+		sql.PrivilegeType_Insert,
+		sql.PrivilegeType_Update,
+		sql.PrivilegeType_Delete,
+		sql.PrivilegeType_Create,
+		sql.PrivilegeType_Drop,
+		sql.PrivilegeType_Alter,
+		sql.PrivilegeType_Index,
+		sql.PrivilegeType_References,
+		sql.PrivilegeType_Trigger,
+		sql.PrivilegeType_CreateView,
+		sql.PrivilegeType_ShowView,
+	}
+	// readWriteDatabasePrivileges are the privileges that only apply to whole databases, in addition to the table ones
+	readWriteDatabasePrivileges = append([]sql.PrivilegeType{
+		sql.PrivilegeType_Execute,
+		sql.PrivilegeType_CreateRoutine,
+		sql.PrivilegeType_AlterRoutine,
+		sql.PrivilegeType_CreateTempTable,
+		sql.PrivilegeType_LockTables,
+		sql.PrivilegeType_Event,
+	}, readWriteTablePrivileges...)
+)
+
+// privileges returns the engine privileges given by |g|.
+func (g Grant) privileges() []sql.PrivilegeType {
+	switch {
+	case g.Access == AccessRead:
+		return readPrivileges
+	case g.Table != "":
+		return readWriteTablePrivileges
+	default:
+		return readWriteDatabasePrivileges
+	}
+}
+
+// addTo adds the privileges given by |g| to |privs|.
+func (g Grant) addTo(privs mysql_db.PrivilegeSet) error {
+	if g.Database == "" {
+		return fmt.Errorf("grant must name a database or use AllDatabases")
+	}
+
+	switch {
+	case g.Database == AllDatabases && g.Table == "":
+		privs.AddGlobalStatic(g.privileges()...)
+	case g.Database == AllDatabases:
+		return fmt.Errorf("grant on table '%s' must name its database", g.Table)
+	case g.Table == "":
+		privs.AddDatabase(g.Database, g.privileges()...)
+	default:
+		privs.AddTable(g.Database, g.Table, g.privileges()...)
+	}
+
+	return nil
+}
+
+// accessControlHost is the host of the accounts created for Config.Users. Connections are local, so their host is
+// always the wildcard.
+const accessControlHost = "%"
+
+// enableAccessControl creates the accounts for |users| in |mysqlDb| and turns on privilege checking. A root account
+// with a random password is also created, so the driver's internal sessions keep full access while no connection can
+// authenticate as root.
+func enableAccessControl(mysqlDb *mysql_db.MySQLDb, users []Account) error {
+	ed := mysqlDb.Editor()
+	defer ed.Close()
+
+	rootPassword := make([]byte, 32)
+	if _, err := rand.Read(rootPassword); err != nil {
+		return err
+	}
+	mysqlDb.AddSuperUser(ed, "root", accessControlHost, hex.EncodeToString(rootPassword))
+
+	for _, user := range users {
+		if user.Name == "" || strings.EqualFold(user.Name, "root") {
+			return fmt.Errorf("invalid user name '%s'", user.Name)
+		}
+
+		privs := mysql_db.NewPrivilegeSet()
+		for _, grant := range user.Grants {
+			if err := grant.addTo(privs); err != nil {
+				return fmt.Errorf("user '%s': %w", user.Name, err)
+			}
+		}
+
+		ed.PutUser(&mysql_db.User{
+			User:                user.Name,
+			Host:                accessControlHost,
+			PrivilegeSet:        privs,
+			Plugin:              "mysql_native_password",
+			Password:            nativePasswordHash(user.Password),
+			PasswordLastChanged: time.Now().UTC(),
+		})
+	}
+
+	mysqlDb.SetEnabled(true)
+	return nil
+}
+
+// authenticate returns the configured user named |name| if |password| is its password, and an access denied error
+// otherwise.
+func authenticate(users []Account, name, password string) (*Account, error) {
+	for i := range users {
+		if users[i].Name == name && subtle.ConstantTimeCompare([]byte(users[i].Password), []byte(password)) == 1 {
+			return &users[i], nil
+		}
+	}
+
+	return nil, &mysql.MySQLError{
+		Number:  1045,
+		Message: fmt.Sprintf("Access denied for user '%s'", name),
+	}
+}
+
+// nativePasswordHash returns |password| hashed like MySQL's mysql_native_password plugin stores it.
+func nativePasswordHash(password string) string {
+	if password == "" {
+		return ""
+	}
+
+	s1 := sha1.Sum([]byte(password))
+	s2 := sha1.Sum(s1[:])
+	return "*" + strings.ToUpper(hex.EncodeToString(s2[:]))
+}
@@ -0,0 +1,89 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/dolthub/go-mysql-server/sql/mysql_db"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessControl(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	cfg := Config{
+		Directory:       dir,
+		CommitName:      "Billy Batson",
+		CommitEmail:     "shazam@gmail.com",
+		MultiStatements: true,
+	}
+
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	_, err = db.ExecContext(ctx, `create database db1; create database db2;
+		create table db1.t1 (id int primary key); create table db1.t2 (id int primary key);
+		create table db2.t (id int primary key); insert into db1.t1 values (1)`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	cfg.Users = []Account{
+		{
+			Name:     "reader",
+			Password: "r34d",
+			Grants:   []Grant{{Database: "db1", Table: "t1", Access: AccessRead}},
+		},
+		{
+			Name:     "writer",
+			Password: "wr1t3",
+			Grants:   []Grant{{Database: "db1", Access: AccessReadWrite}},
+		},
+	}
+
+	cfg.User, cfg.Password = "reader", "wrong"
+	_, err = NewConnector(cfg)
+	var mysqlErr *mysql.MySQLError
+	require.True(t, errors.As(err, &mysqlErr))
+	require.Equal(t, uint16(1045), mysqlErr.Number)
+
+	cfg.User, cfg.Password = "reader", "r34d"
+	connector, err = NewConnector(cfg)
+	require.NoError(t, err)
+	db = sql.OpenDB(connector)
+
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from db1.t1").Scan(&count))
+	require.Equal(t, 1, count)
+	require.Error(t, db.QueryRowContext(ctx, "select count(*) from db1.t2").Scan(&count))
+	_, err = db.ExecContext(ctx, "insert into db1.t1 values (2)")
+	require.Error(t, err)
+	require.NoError(t, db.Close())
+
+	cfg.User, cfg.Password = "writer", "wr1t3"
+	connector, err = NewConnector(cfg)
+	require.NoError(t, err)
+	db = sql.OpenDB(connector)
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, "insert into db1.t1 values (2); insert into db1.t2 values (1)")
+	require.NoError(t, err)
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from db1.t1").Scan(&count))
+	require.Equal(t, 2, count)
+	require.Error(t, db.QueryRowContext(ctx, "select count(*) from db2.t").Scan(&count))
+	_, err = db.ExecContext(ctx, "drop database db2")
+	require.Error(t, err)
+}
+
+func TestGrantValidation(t *testing.T) {
+	require.Error(t, Grant{}.addTo(mysql_db.NewPrivilegeSet()))
+	require.Error(t, Grant{Database: AllDatabases, Table: "t"}.addTo(mysql_db.NewPrivilegeSet()))
+	require.Equal(t, "", nativePasswordHash(""))
+	require.Equal(t, "*2470C0C06DEE42FD1618BB99005ADCA2EC9D1E19", nativePasswordHash("password"))
+}
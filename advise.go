@@ -0,0 +1,290 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/expression"
+	"github.com/dolthub/go-mysql-server/sql/plan"
+	"github.com/dolthub/go-mysql-server/sql/transform"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// adviseMinRows is the number of rows from which Connector.Advise considers a table large, and flags the statements
+// reading it in full.
+const adviseMinRows = 1000
+
+// IndexAdvice flags a statement that reads a large table in full, as returned by Connector.Advise, with the index that
+// would let it read only the rows it needs, if there is one.
+type IndexAdvice struct {
+	// Digest, Tag and Database identify the statement, as in its StatementStats
+	Digest   string
+	Tag      string
+	Database string
+	// Count and TotalLatency are the number of executions and the total latency of the statement
+	Count        int64
+	TotalLatency time.Duration
+	// Table is the table the statement reads in full, and Rows the number of rows it has
+	Table string
+	Rows  uint64
+	// Columns are the columns of the suggested index: those the statement compares to a value first, then one it
+	// compares to a range. It is empty if no index would help, for instance because the statement doesn't filter the
+	// table's rows, or if the table already has an index starting with the first of them that the engine didn't use.
+	Columns []string
+	// CreateIndex is the statement creating the suggested index, or empty if Columns is
+	CreateIndex string
+}
+
+// Advise returns advice about the indexes of the tables read by the |window| statements with the highest total
+// latency in the connector's statement statistics, or by all of them if |window| isn't positive. Each statement is
+// planned by the engine, in the database it last ran in, with its literals replaced by placeholder values, and each
+// table of at least 1000 rows that the plan reads in full is reported, with an index built from the columns the
+// statement filters or joins the table on. Only SELECT, UPDATE and DELETE statements are planned, and statements that
+// can't be planned anymore, for instance because their table was dropped, are skipped. The advice is ordered like
+// Connector.Stats, and nothing is executed.
+func (c *Connector) Advise(ctx context.Context, window int) ([]IndexAdvice, error) {
+	stats := c.Stats()
+	if window > 0 && len(stats) > window {
+		stats = stats[:window]
+	}
+
+	var advice []IndexAdvice
+	for _, stmt := range stats {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		scans, err := c.planScans(ctx, stmt)
+		if err != nil {
+			return nil, err
+		}
+		for _, scan := range scans {
+			advice = append(advice, IndexAdvice{
+				Digest:       stmt.Digest,
+				Tag:          stmt.Tag,
+				Database:     stmt.Database,
+				Count:        stmt.Count,
+				TotalLatency: stmt.TotalLatency,
+				Table:        scan.table,
+				Rows:         scan.rows,
+				Columns:      scan.columns,
+				CreateIndex:  createIndexStatement(scan.database, scan.table, scan.columns),
+			})
+		}
+	}
+
+	return advice, nil
+}
+
+// tableScan is a table of at least adviseMinRows rows that a statement reads in full, with the columns of the index
+// suggested for it.
+type tableScan struct {
+	database string
+	table    string
+	rows     uint64
+	columns  []string
+}
+
+// planScans plans the statement of |stmt| in a new session, and returns the large tables the plan reads in full. It
+// returns no scans if the statement isn't a SELECT, UPDATE or DELETE, or can't be planned.
+func (c *Connector) planScans(ctx context.Context, stmt StatementStats) ([]tableScan, error) {
+	parsed, err := sqlparser.Parse(stmt.Digest)
+	if err != nil {
+		return nil, nil
+	}
+	switch parsed.(type) {
+	case *sqlparser.Select, *sqlparser.SetOp, *sqlparser.Update, *sqlparser.Delete:
+	default:
+		return nil, nil
+	}
+
+	// The literals of the digest were replaced by bind variables, which are bound to a value any column can be
+	// compared to, so that the engine chooses the indexes it would for actual values
+	bindings := make(map[string]sqlparser.Expr)
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if val, ok := node.(*sqlparser.SQLVal); ok && val.Type == sqlparser.ValArg {
+			bindings[strings.TrimPrefix(string(val.Val), ":")] = sqlparser.NewStrVal([]byte("0"))
+		}
+		return true, nil
+	}, parsed)
+
+	var eng *sharedEngine
+	var gmsCtx *gms.Context
+	if stmt.Database != "" {
+		eng, gmsCtx, err = c.newDatabaseSession(ctx, stmt.Database)
+	} else {
+		eng, gmsCtx, err = c.newSession()
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer eng.release()
+	gmsCtx.SetCurrentDatabase(stmt.Database)
+	defer func() {
+		if tx := gmsCtx.GetTransaction(); tx != nil {
+			gmsCtx.Session.(gms.TransactionSession).Rollback(gmsCtx, tx)
+		}
+	}()
+
+	node, err := eng.se.GetUnderlyingEngine().BoundQueryPlan(gmsCtx, stmt.Digest, parsed, bindings)
+	if err != nil {
+		return nil, nil
+	}
+	return largeTableScans(gmsCtx, node)
+}
+
+// largeTableScans returns the tables of at least adviseMinRows rows that |node| reads in full, in the order of the
+// plan, with the columns of the index suggested for each.
+func largeTableScans(gmsCtx *gms.Context, node gms.Node) ([]tableScan, error) {
+	// Tables are referred to by their alias in the plan, if they have one
+	var tables []*plan.ResolvedTable
+	names := make(map[*plan.ResolvedTable]string)
+	var conditions []gms.Expression
+	transform.Inspect(node, func(n gms.Node) bool {
+		switch n := n.(type) {
+		case *plan.TableAlias:
+			if rt, ok := n.Child.(*plan.ResolvedTable); ok {
+				names[rt] = n.Name()
+			}
+		case *plan.ResolvedTable:
+			if _, ok := names[n]; !ok {
+				names[n] = n.Name()
+			}
+			tables = append(tables, n)
+		case *plan.Filter:
+			conditions = append(conditions, expression.SplitConjunction(n.Expression)...)
+		case *plan.JoinNode:
+			if n.Filter != nil {
+				conditions = append(conditions, expression.SplitConjunction(n.Filter)...)
+			}
+		}
+		return true
+	})
+
+	var scans []tableScan
+	for _, rt := range tables {
+		// The engine wraps the tables it reads, to track its progress
+		table := rt.Table
+		for wrapper, ok := table.(gms.TableWrapper); ok; wrapper, ok = table.(gms.TableWrapper) {
+			table = wrapper.Underlying()
+		}
+		statsTable, ok := table.(gms.StatisticsTable)
+		if !ok {
+			continue
+		}
+		rows, _, err := statsTable.RowCount(gmsCtx)
+		if err != nil {
+			return nil, translateError(err)
+		}
+		if rows < adviseMinRows {
+			continue
+		}
+
+		columns := indexColumnsFor(names[rt], conditions)
+		if len(columns) > 0 {
+			if indexed, err := hasIndexStartingWith(gmsCtx, table, columns[0]); err != nil {
+				return nil, translateError(err)
+			} else if indexed {
+				columns = nil
+			}
+		}
+		scans = append(scans, tableScan{database: rt.Database().Name(), table: rt.Name(), rows: rows, columns: columns})
+	}
+
+	return scans, nil
+}
+
+// indexColumnsFor returns the columns of an index for the table named |table| in |conditions|: the columns compared
+// to a value, or to a column of another table, followed by the first column compared to a range.
+func indexColumnsFor(table string, conditions []gms.Expression) []string {
+	var equal, ranges []string
+	add := func(columns *[]string, e gms.Expression, other ...gms.Expression) {
+		field, ok := e.(*expression.GetField)
+		if !ok || !strings.EqualFold(field.Table(), table) {
+			return
+		}
+		for _, o := range other {
+			if otherField, ok := o.(*expression.GetField); ok && strings.EqualFold(otherField.Table(), table) {
+				return
+			}
+		}
+		for _, column := range append(equal, ranges...) {
+			if strings.EqualFold(column, field.Name()) {
+				return
+			}
+		}
+		*columns = append(*columns, field.Name())
+	}
+
+	for _, cond := range conditions {
+		switch cond := cond.(type) {
+		case *expression.Equals:
+			add(&equal, cond.Left(), cond.Right())
+			add(&equal, cond.Right(), cond.Left())
+		case *expression.NullSafeEquals:
+			add(&equal, cond.Left(), cond.Right())
+			add(&equal, cond.Right(), cond.Left())
+		case *expression.InTuple:
+			add(&equal, cond.Left(), cond.Right())
+		case *expression.GreaterThan, *expression.GreaterThanOrEqual, *expression.LessThan,
+			*expression.LessThanOrEqual:
+			comparison := cond.(expression.Comparer)
+			add(&ranges, comparison.Left(), comparison.Right())
+			add(&ranges, comparison.Right(), comparison.Left())
+		case *expression.Between:
+			add(&ranges, cond.Val, cond.Lower, cond.Upper)
+		case *expression.Like:
+			add(&ranges, cond.LeftChild, cond.RightChild)
+		}
+	}
+
+	if len(ranges) > 0 {
+		return append(equal, ranges[0])
+	}
+	return equal
+}
+
+// hasIndexStartingWith returns whether |table| has an index whose first column is |column|.
+func hasIndexStartingWith(gmsCtx *gms.Context, table gms.Table, column string) (bool, error) {
+	indexed, ok := table.(gms.IndexAddressable)
+	if !ok {
+		return false, nil
+	}
+	indexes, err := indexed.GetIndexes(gmsCtx)
+	if err != nil {
+		return false, err
+	}
+	for _, idx := range indexes {
+		expressions := idx.Expressions()
+		if len(expressions) == 0 {
+			continue
+		}
+		// Index expressions are qualified with the table name
+		first := expressions[0]
+		if i := strings.LastIndexByte(first, '.'); i >= 0 {
+			first = first[i+1:]
+		}
+		if strings.EqualFold(first, column) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// createIndexStatement returns the statement creating an index on |columns| of |table| in |database|, or the empty
+// string if there are no columns.
+func createIndexStatement(database, table string, columns []string) string {
+	if len(columns) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+	}
+	name := "idx_" + table + "_" + strings.Join(columns, "_")
+	return fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s)", quoteIdentifier(name), quoteIdentifier(database),
+		quoteIdentifier(table), strings.Join(quoted, ", "))
+}
@@ -0,0 +1,90 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvise(t *testing.T) {
+	connector, db, cleanup := initializeTestConnector(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	values := make([]string, adviseMinRows)
+	for i := range values {
+		values[i] = fmt.Sprintf("(%d, %d, 'v%d', %d)", i, i%10, i, i%100)
+	}
+	for _, query := range []string{
+		"create table big (id int primary key, v int, s varchar(10), w int, key (s))",
+		"create table small (id int primary key, v int)",
+		"insert into big values " + strings.Join(values, ", "),
+		"insert into small values (1, 1), (2, 2)",
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	connector.ResetStats()
+
+	advised := make(map[string]IndexAdvice)
+	for _, query := range []string{
+		"select * from big where v = 5",
+		"select count(*) from big b where b.w > 3 and b.v = 1",
+		"select * from big where s = 'v1'",
+		"select * from big where id = 3",
+		"select * from big",
+		"select * from small where v = 1",
+		"select * from small join big on big.w = small.v where small.id = 1",
+		"update big set w = 1 where w = 2",
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	for _, stats := range connector.Stats() {
+		require.Equal(t, "testdb", stats.Database)
+	}
+
+	advice, err := connector.Advise(ctx, 0)
+	require.NoError(t, err)
+	for _, a := range advice {
+		require.Equal(t, "testdb", a.Database)
+		require.Equal(t, "big", a.Table)
+		require.EqualValues(t, adviseMinRows, a.Rows)
+		require.EqualValues(t, 1, a.Count)
+		advised[a.Digest] = a
+	}
+
+	// Large tables read in full are flagged, with an index on the columns they are filtered on
+	require.Len(t, advised, 5)
+	require.Equal(t, []string{"v"}, advised["select * from big where v = :v1"].Columns)
+	require.Equal(t, "CREATE INDEX `idx_big_v` ON `testdb`.`big` (`v`)",
+		advised["select * from big where v = :v1"].CreateIndex)
+	require.Equal(t, []string{"v", "w"}, advised["select count(*) from big as b where b.w > :v1 and b.v = :v2"].Columns)
+	require.Equal(t, []string{"w"}, advised["update big set w = :v1 where w = :v2"].Columns)
+	require.Equal(t, []string{"w"},
+		advised["select * from small join big on big.w = small.v where small.id = :v1"].Columns)
+
+	// even when no index would help
+	require.Contains(t, advised, "select * from big")
+	require.Empty(t, advised["select * from big"].Columns)
+	require.Empty(t, advised["select * from big"].CreateIndex)
+
+	// Only the statements with the highest total latency are considered
+	advice, err = connector.Advise(ctx, 1)
+	require.NoError(t, err)
+	for _, a := range advice {
+		require.Equal(t, connector.Stats()[0].Digest, a.Digest)
+	}
+
+	// Once the index exists, the statement isn't flagged anymore
+	_, err = db.ExecContext(ctx, advised["select * from big where v = :v1"].CreateIndex)
+	require.NoError(t, err)
+	advice, err = connector.Advise(ctx, 0)
+	require.NoError(t, err)
+	for _, a := range advice {
+		require.NotEqual(t, "select * from big where v = :v1", a.Digest)
+	}
+}
@@ -0,0 +1,105 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env/actions/commitwalk"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/store/hash"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+type asOfKey struct{}
+
+// AsOf returns a copy of |ctx| with which statements read the connection's current database as of |revision|, a
+// commit spec such as a commit hash, a tag, a branch or HEAD~3, like SELECT ... AS OF does. The revision is resolved
+// relative to the connection's branch, or to the branch set with WithBranch, when each statement starts, and the
+// statement runs against the read-only database of the commit it resolves to, so writes fail.
+func AsOf(ctx context.Context, revision string) context.Context {
+	return context.WithValue(ctx, asOfKey{}, revision)
+}
+
+// AsOfTime returns a copy of |ctx| with which statements read the connection's current database as of the last
+// commit made at or before |t|, like SELECT ... AS OF does with a timestamp. See AsOf.
+func AsOfTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, asOfKey{}, t)
+}
+
+// resolveAsOf returns the hash of the commit of database |dbName| that |asOf|, a commit spec or a time.Time, refers
+// to.
+func resolveAsOf(gmsCtx *gms.Context, dbName string, asOf any) (string, error) {
+	spec := "HEAD"
+	if s, ok := asOf.(string); ok {
+		spec = s
+	}
+	ddb, commit, err := resolveCommit(gmsCtx, dbName, spec)
+	if err != nil {
+		return "", err
+	}
+	h, err := commit.HashOf()
+	if err != nil {
+		return "", err
+	}
+
+	t, ok := asOf.(time.Time)
+	if !ok {
+		return h.String(), nil
+	}
+
+	// Find the last commit made at or before |t|, in the order of dolt log
+	itr, err := commitwalk.GetTopologicalOrderIterator(gmsCtx, ddb, []hash.Hash{h}, nil)
+	if err != nil {
+		return "", err
+	}
+	for {
+		h, optCmt, err := itr.Next(gmsCtx)
+		if err == io.EOF {
+			return "", fmt.Errorf("no commit of database '%s' at or before %s", dbName, t.Format(time.RFC3339))
+		} else if err != nil {
+			return "", err
+		}
+		commit, ok := optCmt.ToCommit()
+		if !ok {
+			return "", doltdb.ErrGhostCommitEncountered
+		}
+		meta, err := commit.GetCommitMeta(gmsCtx)
+		if err != nil {
+			return "", err
+		}
+		if !meta.Time().After(t) {
+			return h.String(), nil
+		}
+	}
+}
+
+// resolveCommit returns the commit of database |dbName| that the commit spec |spec| refers to, resolving HEAD to the
+// session's branch of the database, and the database it belongs to.
+func resolveCommit(gmsCtx *gms.Context, dbName, spec string) (*doltdb.DoltDB, *doltdb.Commit, error) {
+	sess := dsess.DSessFromSess(gmsCtx.Session)
+	dbData, ok := sess.GetDbData(gmsCtx, dbName)
+	if !ok {
+		return nil, nil, translateError(gms.ErrDatabaseNotFound.New(dbName))
+	}
+	headRef, err := sess.CWBHeadRef(gmsCtx, dbName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cs, err := doltdb.NewCommitSpec(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	optCmt, err := dbData.Ddb.Resolve(gmsCtx, cs, headRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	commit, ok := optCmt.ToCommit()
+	if !ok {
+		return nil, nil, doltdb.ErrGhostCommitEncountered
+	}
+	return dbData.Ddb, commit, nil
+}
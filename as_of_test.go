@@ -0,0 +1,71 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsOf(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com", Database: "test"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, query := range []string{
+		"create database test",
+		"use test",
+		"create table t (v int primary key)",
+		"call dolt_add('.')",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	for i := 1; i <= 4; i++ {
+		_, err = conn.ExecContext(ctx, "insert into t values (?)", i)
+		require.NoError(t, err)
+		date := time.Date(2024, 1, i, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+		_, err = conn.ExecContext(ctx, "call dolt_commit('-am', 'commit', '--date', ?)", date)
+		require.NoError(t, err)
+	}
+	_, err = conn.ExecContext(ctx, "call dolt_tag('v2', 'HEAD~2')")
+	require.NoError(t, err)
+
+	count := func(ctx context.Context) int {
+		var n int
+		require.NoError(t, conn.QueryRowContext(ctx, "select count(*) from t").Scan(&n))
+		return n
+	}
+	require.Equal(t, 4, count(ctx))
+	require.Equal(t, 1, count(AsOf(ctx, "HEAD~3")))
+	require.Equal(t, 2, count(AsOf(ctx, "v2")))
+	require.Equal(t, 3, count(AsOfTime(ctx, time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC))))
+	require.Equal(t, 3, count(AsOfTime(ctx, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))))
+
+	_, err = conn.QueryContext(AsOfTime(ctx, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)), "select count(*) from t")
+	require.ErrorContains(t, err, "no commit")
+	_, err = conn.ExecContext(AsOf(ctx, "HEAD~1"), "insert into t values (5)")
+	require.Error(t, err)
+	_, err = conn.QueryContext(AsOf(ctx, "nonexistent"), "select count(*) from t")
+	require.Error(t, err)
+
+	// AsOf resolves relative to the branch of WithBranch
+	_, err = conn.ExecContext(ctx, "call dolt_branch('old', 'HEAD~2')")
+	require.NoError(t, err)
+	require.Equal(t, 1, count(AsOf(WithBranch(ctx, "old"), "HEAD~1")))
+
+	// The connection is back on its database afterwards
+	requireResults(t, conn, "select database(), count(*) from t", [][]any{{"test", int64(4)}})
+}
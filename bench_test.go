@@ -0,0 +1,235 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkSelectOne(b *testing.B) {
+	_, db, cleanupFunc := initializeTestConnector(b)
+	defer cleanupFunc()
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var one int
+		require.NoError(b, db.QueryRowContext(ctx, "select 1").Scan(&one))
+	}
+}
+
+func BenchmarkSelectOneWithArg(b *testing.B) {
+	_, db, cleanupFunc := initializeTestConnector(b)
+	defer cleanupFunc()
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var one int
+		require.NoError(b, db.QueryRowContext(ctx, "select ?", 1).Scan(&one))
+	}
+}
+
+func BenchmarkExecNoArgs(b *testing.B) {
+	_, db, cleanupFunc := initializeTestConnector(b)
+	defer cleanupFunc()
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := db.ExecContext(ctx, "set @a = 1")
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkSelectParallel(b *testing.B) {
+	_, db, cleanupFunc := initializeTestConnector(b)
+	defer cleanupFunc()
+
+	ctx := context.Background()
+	_, err := db.ExecContext(ctx, "create table t (id int primary key, v varchar(20))")
+	require.NoError(b, err)
+	_, err = db.ExecContext(ctx, "insert into t values (1, 'one'), (2, 'two'), (3, 'three')")
+	require.NoError(b, err)
+
+	// 8 goroutines per CPU, each on its own pooled connection
+	b.SetParallelism(8)
+	db.SetMaxIdleConns(8 * runtime.GOMAXPROCS(0))
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var v string
+			require.NoError(b, db.QueryRowContext(ctx, "select v from t where id = ?", 2).Scan(&v))
+		}
+	})
+}
+
+// benchRows is the number of rows of the table benchmarks read from.
+const benchRows = 10_000
+
+// createBenchTable creates the table t with |rows| rows in the database of |db|, inserting them in batches.
+func createBenchTable(b *testing.B, db *sql.DB, rows int) {
+	ctx := context.Background()
+	_, err := db.ExecContext(ctx, "create table t (id int primary key, v varchar(20), n int)")
+	require.NoError(b, err)
+	for start := 0; start < rows; start += 1000 {
+		_, err = db.ExecContext(ctx, insertValues("t", start, min(start+1000, rows)))
+		require.NoError(b, err)
+	}
+}
+
+// insertValues returns a single INSERT statement of the rows of |table| with the ids from |start| to |end|.
+func insertValues(table string, start, end int) string {
+	var query strings.Builder
+	fmt.Fprintf(&query, "insert into %s values ", table)
+	for id := start; id < end; id++ {
+		if id > start {
+			query.WriteString(", ")
+		}
+		fmt.Fprintf(&query, "(%d, 'value %d', %d)", id, id, id%100)
+	}
+	return query.String()
+}
+
+func BenchmarkPointRead(b *testing.B) {
+	_, db, cleanupFunc := initializeTestConnector(b)
+	defer cleanupFunc()
+	createBenchTable(b, db, benchRows)
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var v string
+		require.NoError(b, db.QueryRowContext(ctx, "select v from t where id = ?", i%benchRows).Scan(&v))
+	}
+}
+
+func BenchmarkPreparedPointRead(b *testing.B) {
+	_, db, cleanupFunc := initializeTestConnector(b)
+	defer cleanupFunc()
+	createBenchTable(b, db, benchRows)
+
+	ctx := context.Background()
+	stmt, err := db.PrepareContext(ctx, "select v from t where id = ?")
+	require.NoError(b, err)
+	defer stmt.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var v string
+		require.NoError(b, stmt.QueryRowContext(ctx, i%benchRows).Scan(&v))
+	}
+}
+
+func BenchmarkCountStar(b *testing.B) {
+	_, db, cleanupFunc := initializeTestConnector(b)
+	defer cleanupFunc()
+	createBenchTable(b, db, benchRows)
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var count int
+		require.NoError(b, db.QueryRowContext(ctx, "select count(*) from t").Scan(&count))
+	}
+}
+
+func BenchmarkBulkInsert(b *testing.B) {
+	_, db, cleanupFunc := initializeTestConnector(b)
+	defer cleanupFunc()
+	createBenchTable(b, db, 0)
+
+	// Each operation inserts 100 rows in one statement
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := db.ExecContext(ctx, insertValues("t", i*100, (i+1)*100))
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkRangeScan(b *testing.B) {
+	_, db, cleanupFunc := initializeTestConnector(b)
+	defer cleanupFunc()
+	createBenchTable(b, db, benchRows)
+
+	// Each operation reads every row of the table
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows, err := db.QueryContext(ctx, "select id, v, n from t")
+		require.NoError(b, err)
+		count := 0
+		for rows.Next() {
+			var id, n int
+			var v string
+			require.NoError(b, rows.Scan(&id, &v, &n))
+			count++
+		}
+		require.NoError(b, rows.Err())
+		require.NoError(b, rows.Close())
+		require.Equal(b, benchRows, count)
+	}
+}
+
+func BenchmarkMultiStatementScript(b *testing.B) {
+	for _, parallel := range []int64{0, 4} {
+		b.Run(fmt.Sprintf("parallelscript=%d", parallel), func(b *testing.B) {
+			dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+			require.NoError(b, err)
+			defer os.RemoveAll(dir)
+
+			connector, err := NewConnector(Config{
+				Directory:       dir,
+				CommitName:      "Billy Batson",
+				CommitEmail:     "shazam@gmail.com",
+				Database:        "testdb",
+				MultiStatements: true,
+				ParallelScript:  parallel,
+			})
+			require.NoError(b, err)
+			db := sql.OpenDB(connector)
+			defer db.Close()
+
+			ctx := context.Background()
+			_, err = db.ExecContext(ctx, "create database testdb")
+			require.NoError(b, err)
+			tables := []string{"a", "b", "c", "d"}
+			for _, table := range tables {
+				_, err = db.ExecContext(ctx, "create table "+table+" (id int primary key, v varchar(20), n int)")
+				require.NoError(b, err)
+			}
+
+			// Each operation runs a fixture script of 10 statements of 10 rows per table
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var script strings.Builder
+				for j := 0; j < 10; j++ {
+					for _, table := range tables {
+						start := (i*10 + j) * 10
+						script.WriteString(insertValues(table, start, start+10))
+						script.WriteString(";\n")
+					}
+				}
+				_, err = db.ExecContext(ctx, script.String())
+				require.NoError(b, err)
+			}
+		})
+	}
+}
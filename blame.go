@@ -0,0 +1,107 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// RowBlame attributes the values of a row to the commits that last changed them, as listed by Connector.Blame.
+type RowBlame struct {
+	// Key are the primary key values of the row, in the order of the primary key's columns
+	Key []any
+	// Values are the row's values by column name, as the engine returns them
+	Values map[string]any
+	// Columns holds the commit that last changed the value of each column, by column name. The commits don't have
+	// their parents.
+	Columns map[string]Commit
+}
+
+// Blame returns, for each row of |table| in |database| at the head of the branch new connections use, the commit
+// that last changed the value of each of its columns, like dolt_blame_<table> does for whole rows. A column whose
+// value changed and was later changed back is attributed to the commit changing it back. It reads the whole history of
+// the table from its dolt_history_<table> system table, so it is meant for auditing rather than frequent use: Blame
+// of a single row with BlameRow reads much less.
+func (c *Connector) Blame(ctx context.Context, database, table string) ([]RowBlame, error) {
+	return c.blame(ctx, database, table, nil)
+}
+
+// BlameRow returns the blame of the row of |table| in |database| whose primary key is |pk|, given in the order of the
+// primary key's columns, like Blame. It returns an error if the row doesn't exist at the head of the branch.
+func (c *Connector) BlameRow(ctx context.Context, database, table string, pk ...any) (RowBlame, error) {
+	if len(pk) == 0 {
+		return RowBlame{}, fmt.Errorf("no primary key values given for table '%s'", table)
+	}
+	blames, err := c.blame(ctx, database, table, pk)
+	if err != nil {
+		return RowBlame{}, err
+	} else if len(blames) == 0 {
+		return RowBlame{}, fmt.Errorf("no row of table '%s' has the primary key %v", table, pk)
+	}
+	return blames[0], nil
+}
+
+func (c *Connector) blame(ctx context.Context, database, table string, pk []any) ([]RowBlame, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	eng, gmsCtx, err := c.newDatabaseSession(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	defer eng.release()
+	gmsCtx.SetCurrentDatabase(database)
+
+	h, err := readTableHistory(gmsCtx, eng, database, table, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk the log from the oldest commit, attributing the values of each row to the commits changing them. Rows
+	// missing from a commit were deleted, so their values are attributed again if they reappear.
+	type rowState struct {
+		values  gms.Row
+		commits []Commit
+	}
+	states := make(map[string]*rowState)
+	for i := len(h.commits) - 1; i >= 0; i-- {
+		commit := h.commits[i]
+		rows := h.rows[commit.Hash]
+		for key := range states {
+			if _, ok := rows[key]; !ok {
+				delete(states, key)
+			}
+		}
+		for key, values := range rows {
+			state, ok := states[key]
+			if !ok {
+				state = &rowState{commits: make([]Commit, len(h.sch))}
+				states[key] = state
+			}
+			for col := range h.sch {
+				if !ok || !reflect.DeepEqual(values[col], state.values[col]) {
+					state.commits[col] = commit
+				}
+			}
+			state.values = values
+		}
+	}
+
+	blames := make([]RowBlame, 0, len(h.order))
+	for _, key := range h.order {
+		state := states[key]
+		blame := RowBlame{
+			Key:     h.primaryKey(state.values),
+			Values:  h.values(state.values),
+			Columns: make(map[string]Commit, len(h.sch)),
+		}
+		for i, col := range h.sch {
+			blame.Columns[col.Name] = state.commits[i]
+		}
+		blames = append(blames, blame)
+	}
+	return blames, nil
+}
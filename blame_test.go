@@ -0,0 +1,69 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlame(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, query := range []string{
+		"create database test",
+		"use test",
+		"create table people (id int primary key, name varchar(20), city varchar(20))",
+		"insert into people values (1, 'billy', 'fawcett'), (2, 'mary', 'fawcett'), (3, 'freddy', 'fawcett')",
+		"call dolt_commit('-Am', 'create')",
+		"update people set city = 'gotham' where id = 1",
+		"call dolt_commit('-am', 'move', '--author', 'Mary Batson <mary@gmail.com>')",
+		"update people set name = 'captain' where id = 1",
+		"delete from people where id = 3",
+		"call dolt_commit('-am', 'rename')",
+		"insert into people values (3, 'freddy', 'fawcett')",
+		"call dolt_commit('-am', 'return')",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	blames, err := connector.Blame(ctx, "test", "people")
+	require.NoError(t, err)
+	require.Len(t, blames, 3)
+	messages := func(b RowBlame) map[string]string {
+		m := make(map[string]string)
+		for col, commit := range b.Columns {
+			m[col] = commit.Message
+		}
+		return m
+	}
+
+	require.Equal(t, []any{int32(1)}, blames[0].Key)
+	require.Equal(t, map[string]any{"id": int32(1), "name": "captain", "city": "gotham"}, blames[0].Values)
+	require.Equal(t, map[string]string{"id": "create", "name": "rename", "city": "move"}, messages(blames[0]))
+	require.Equal(t, "Mary Batson", blames[0].Columns["city"].Author)
+	require.Equal(t, "mary@gmail.com", blames[0].Columns["city"].Email)
+	require.Equal(t, map[string]string{"id": "create", "name": "create", "city": "create"}, messages(blames[1]))
+	require.Equal(t, map[string]string{"id": "return", "name": "return", "city": "return"}, messages(blames[2]))
+
+	blame, err := connector.BlameRow(ctx, "test", "people", 1)
+	require.NoError(t, err)
+	require.Equal(t, blames[0], blame)
+
+	_, err = connector.BlameRow(ctx, "test", "people", 4)
+	require.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+type branchKey struct{}
+
+// WithBranch returns a copy of |ctx| with which statements run against |branch| of the connection's current database,
+// as if it was used as `db/branch`, without checking the branch out. The current database is switched for the duration
+// of each statement and restored after it, so a pool's connections can serve reads and writes of several branches
+// concurrently. Tables qualified with another database name aren't affected.
+func WithBranch(ctx context.Context, branch string) context.Context {
+	return context.WithValue(ctx, branchKey{}, branch)
+}
+
+// ContextBranch returns the branch set in |ctx| with WithBranch, or the empty string.
+func ContextBranch(ctx context.Context) string {
+	branch, _ := ctx.Value(branchKey{}).(string)
+	return branch
+}
+
+// useContextRevision switches the current database of |gmsCtx| to the database set in |ctx| with WithDatabase, or to
+// the revision set with WithBranch, AsOf or AsOfTime, if it has one, and returns |release| extended to switch it back.
+// |release| is called if the switch fails.
+func useContextRevision(ctx context.Context, gmsCtx *gms.Context, release context.CancelFunc) (context.CancelFunc, error) {
+	database := ContextDatabase(ctx)
+	branch := ContextBranch(ctx)
+	asOf := ctx.Value(asOfKey{})
+	if database == "" && branch == "" && asOf == nil {
+		return release, nil
+	}
+
+	current := gmsCtx.GetCurrentDatabase()
+	revision := current
+	if database != "" {
+		revision = database
+	}
+	if revision == "" {
+		release()
+		return nil, fmt.Errorf("no database selected to use a revision of")
+	}
+
+	base, _ := dsess.SplitRevisionDbName(revision)
+	if branch != "" {
+		revision = base + dsess.DbRevisionDelimiter + branch
+	}
+	if asOf != nil {
+		commit, err := resolveAsOf(gmsCtx, revision, asOf)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		revision = base + dsess.DbRevisionDelimiter + commit
+	}
+
+	gmsCtx.SetCurrentDatabase(revision)
+	return func() {
+		gmsCtx.SetCurrentDatabase(current)
+		release()
+	}, nil
+}
@@ -0,0 +1,81 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBranch(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com", Database: "test"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	for _, query := range []string{
+		"create database test",
+		"use test",
+		"create table t (v varchar(10) primary key)",
+		"insert into t values ('main')",
+		"call dolt_commit('-Am', 'main')",
+		"call dolt_branch('feature-x')",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	require.NoError(t, conn.Close())
+
+	feature := WithBranch(ctx, "feature-x")
+	require.Equal(t, "feature-x", ContextBranch(feature))
+	require.Empty(t, ContextBranch(ctx))
+	_, err = db.ExecContext(feature, "update t set v = 'feature'")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			queryCtx, want := ctx, "main"
+			if i%2 == 0 {
+				queryCtx, want = feature, "feature"
+			}
+			for j := 0; j < 10; j++ {
+				var v string
+				require.NoError(t, db.QueryRowContext(queryCtx, "select v from t").Scan(&v))
+				require.Equal(t, want, v)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// The connection's current database is restored after each statement
+	conn, err = db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	requireResults(t, conn, "select database(), active_branch()", [][]any{{"test", "main"}})
+	rows, err := conn.QueryContext(feature, "select database(), v from t")
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	var database, v string
+	require.NoError(t, rows.Scan(&database, &v))
+	require.Equal(t, "test/feature-x", database)
+	require.Equal(t, "feature", v)
+	require.NoError(t, rows.Close())
+	requireResults(t, conn, "select database(), v from t", [][]any{{"test", "main"}})
+
+	_, err = conn.QueryContext(WithBranch(ctx, "missing"), "select v from t")
+	require.Error(t, err)
+	requireResults(t, conn, "select database()", [][]any{{"test"}})
+}
@@ -0,0 +1,51 @@
+package embedded
+
+import (
+	"strings"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+)
+
+// restoreBranches undoes the branch checkouts of the connection, such as CALL DOLT_CHECKOUT('feature'), so that the
+// next borrower of a pooled connection starts on the default branch of each database, like a new connection. The
+// session state of a database whose checked out branch isn't its default one is dropped, and is loaded again, on the
+// default branch, when the database is next used. Changes are committed to the branch's working set by each
+// statement, so nothing is lost, but a connection with an open transaction is left as it is.
+func (d *DoltConn) restoreBranches() error {
+	if d.gmsCtx.GetTransaction() != nil {
+		return nil
+	}
+
+	sess := dsess.DSessFromSess(d.gmsCtx.Session)
+	pro := sess.Provider()
+	restored := make(map[string]bool)
+	for _, db := range pro.AllDatabases(d.gmsCtx) {
+		name, _ := dsess.SplitRevisionDbName(db.Name())
+		name = strings.ToLower(name)
+		if restored[name] {
+			continue
+		}
+		restored[name] = true
+
+		head, ok, err := sess.CurrentHead(d.gmsCtx, name)
+		if err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		baseDb, ok := pro.BaseDatabase(d.gmsCtx, name)
+		if !ok {
+			continue
+		}
+		defaultHead, err := dsess.DefaultHead(name, baseDb)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(head, defaultHead) {
+			if err := sess.RemoveDbState(d.gmsCtx, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckoutIsScopedToBorrower(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com", Database: "test"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	for _, query := range []string{
+		"create database test",
+		"use test",
+		"create table t (v varchar(10) primary key)",
+		"call dolt_commit('-Am', 'main')",
+		"call dolt_checkout('-b', 'feature')",
+		"insert into t values ('feature')",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	requireResults(t, conn, "select active_branch()", [][]any{{"feature"}})
+	require.NoError(t, conn.Close())
+
+	// The next borrower of the same connection is back on main, and the checkout's changes were kept on its branch
+	conn, err = db.Conn(ctx)
+	require.NoError(t, err)
+	requireResults(t, conn, "select active_branch()", [][]any{{"main"}})
+	requireResults(t, conn, "select count(*) from t", [][]any{{int64(0)}})
+	requireResults(t, conn, "select v from `test/feature`.t", [][]any{{"feature"}})
+
+	// Checking out a branch holds for the rest of the borrow
+	_, err = conn.ExecContext(ctx, "call dolt_checkout('feature')")
+	require.NoError(t, err)
+	requireResults(t, conn, "select v from t", [][]any{{"feature"}})
+	require.NoError(t, conn.Close())
+
+	var branch string
+	require.NoError(t, db.QueryRowContext(ctx, "select active_branch()").Scan(&branch))
+	require.Equal(t, "main", branch)
+}
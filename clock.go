@@ -0,0 +1,27 @@
+package embedded
+
+import "time"
+
+// now returns the current time of the connector's clock, Config.Now, or the system's if it isn't set.
+func (cfg Config) now() time.Time {
+	if cfg.Now != nil {
+		return cfg.Now()
+	}
+	return time.Now()
+}
+
+// clock returns the time of a connector's clock, for the connections and statements of the connector. A nil clock
+// returns the system's time, for statements created without a connector.
+type clock func() time.Time
+
+func (c clock) now() time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c()
+}
+
+// since returns the time elapsed on |c| since |t|.
+func (c clock) since(t time.Time) time.Duration {
+	return c.now().Sub(t)
+}
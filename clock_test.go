@@ -0,0 +1,87 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testClock is a clock that only moves when the test advances it.
+type testClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *testClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestClock(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	clock := &testClock{t: time.Date(2021, 6, 1, 12, 0, 0, 0, time.Local)}
+	cfg := Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		Now:         clock.now,
+	}
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, "create database testdb")
+	require.NoError(t, err)
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, "use testdb")
+	require.NoError(t, err)
+
+	// NOW() returns the wall clock time of the session's time zone, the system's
+	var now time.Time
+	require.NoError(t, conn.QueryRowContext(ctx, "select now()").Scan(&now))
+	require.Equal(t, "2021-06-01 12:00:00", now.Format(time.DateTime))
+
+	// Each statement takes the clock's time, without sleeping between them
+	clock.advance(time.Second)
+	require.NoError(t, conn.QueryRowContext(ctx, "select now()").Scan(&now))
+	require.Equal(t, "2021-06-01 12:00:01", now.Format(time.DateTime))
+
+	// Commits are dated with the clock's time
+	_, err = conn.ExecContext(ctx, "create table t (id int primary key)")
+	require.NoError(t, err)
+	clock.advance(time.Hour)
+	_, err = conn.ExecContext(ctx, "call dolt_commit('-Am', 'create t')")
+	require.NoError(t, err)
+	var date time.Time
+	require.NoError(t, conn.QueryRowContext(ctx, "select date from dolt_log limit 1").Scan(&date))
+	require.True(t, date.Equal(clock.now()), "commit date %s", date)
+
+	// Health measures the age of the last commit with the clock
+	clock.advance(time.Minute)
+	report := Health(ctx, db)
+	require.True(t, report.Healthy(), "%+v", report)
+	for _, database := range report.Databases {
+		if database.Name == "testdb" {
+			require.Equal(t, time.Minute, database.LastCommitAge)
+		}
+	}
+}
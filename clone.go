@@ -0,0 +1,118 @@
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrCloneDiverged is returned by NewConnector when the database cloned from Config.Clone has commits on its current
+// branch that the remote doesn't have, so it can't be fast-forwarded to the remote's branch.
+var ErrCloneDiverged = errors.New("the cloned database has diverged from its remote")
+
+// cloneDatabase returns the name of the database cloned from Config.Clone: Config.Database, or else the last element
+// of the remote's URL, like DOLT_CLONE names it.
+func (cfg Config) cloneDatabase() string {
+	if cfg.Database != "" {
+		return cfg.Database
+	}
+	return path.Base(strings.TrimRight(cfg.Clone, "/"))
+}
+
+// syncClone clones the database of Config.Clone into the connector's directory if the directory doesn't have it yet,
+// or else fetches the remote and fast-forwards the database's current branch to the remote's.
+func (c *Connector) syncClone(ctx context.Context) error {
+	if c.cfg.Clone == "" {
+		return nil
+	}
+	name := c.cfg.cloneDatabase()
+
+	all, err := databaseNames(c.cfg)
+	if err != nil {
+		return err
+	}
+	for _, db := range all {
+		if strings.EqualFold(db, name) {
+			return c.fastForwardClone(ctx, db)
+		}
+	}
+
+	eng, gmsCtx, err := c.newSession()
+	if err != nil {
+		return err
+	}
+	defer eng.release()
+	gmsCtx.SetCurrentDatabase("")
+
+	bindings, err := argsToBindings([]driver.Value{c.cfg.Clone, name})
+	if err != nil {
+		return err
+	}
+	if _, err := queryRows(gmsCtx, eng.se, "CALL dolt_clone(:v1, :v2)", bindings); err != nil {
+		return fmt.Errorf("cloning '%s' into database '%s': %w", c.cfg.Clone, name, translateError(err))
+	}
+	return nil
+}
+
+// fastForwardClone fetches the origin remote of the cloned database |name| and fast-forwards its current branch to
+// the remote's. The branch is left as it is if the remote has no new commits.
+func (c *Connector) fastForwardClone(ctx context.Context, name string) error {
+	eng, gmsCtx, err := c.newDatabaseSession(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer eng.release()
+	gmsCtx.SetCurrentDatabase(name)
+
+	if _, err := queryRows(gmsCtx, eng.se, "CALL dolt_fetch('origin')", nil); err != nil {
+		return fmt.Errorf("fetching the remote of database '%s': %w", name, translateError(err))
+	}
+
+	branch, err := queryString(gmsCtx, eng.se, "SELECT active_branch()", nil)
+	if err != nil {
+		return translateError(err)
+	}
+	bindings, err := argsToBindings([]driver.Value{"remotes/origin/" + branch})
+	if err != nil {
+		return err
+	}
+	rows, err := queryRows(gmsCtx, eng.se, "SELECT hash FROM dolt_remote_branches WHERE name = :v1", bindings)
+	if err != nil {
+		return translateError(err)
+	} else if len(rows) == 0 {
+		// The branch was created locally, so there's nothing to fast-forward it to
+		return nil
+	}
+	remote := fmt.Sprint(rows[0][0])
+
+	bindings, err = argsToBindings([]driver.Value{remote})
+	if err != nil {
+		return err
+	}
+	head, err := queryString(gmsCtx, eng.se, "SELECT hashof('HEAD')", nil)
+	if err != nil {
+		return translateError(err)
+	}
+	base, err := queryString(gmsCtx, eng.se, "SELECT dolt_merge_base('HEAD', :v1)", bindings)
+	if err != nil {
+		return translateError(err)
+	}
+	switch {
+	case base == remote:
+		// The branch already has the remote's commits
+		return nil
+	case base != head:
+		return fmt.Errorf("%w: database '%s', branch '%s'", ErrCloneDiverged, name, branch)
+	}
+
+	if bindings, err = argsToBindings([]driver.Value{"origin/" + branch}); err != nil {
+		return err
+	}
+	if _, err := queryRows(gmsCtx, eng.se, "CALL dolt_merge(:v1)", bindings); err != nil {
+		return fmt.Errorf("fast-forwarding database '%s' to its remote: %w", name, translateError(err))
+	}
+	return nil
+}
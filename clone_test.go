@@ -0,0 +1,99 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone(t *testing.T) {
+	ctx := context.Background()
+	tempDir := func() string {
+		dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		return dir
+	}
+	open := func(cfg Config) (*Connector, *sql.DB) {
+		cfg.CommitName = "Billy Batson"
+		cfg.CommitEmail = "shazam@gmail.com"
+		connector, err := NewConnector(cfg)
+		require.NoError(t, err)
+		return connector, sql.OpenDB(connectorOnly{connector})
+	}
+
+	// A source database pushes its commits to a file remote
+	remoteDir := tempDir()
+	remote := "file://" + filepath.ToSlash(filepath.Join(remoteDir, "jails"))
+	source, sourceDB := open(Config{Directory: tempDir()})
+	defer source.Close()
+	defer sourceDB.Close()
+	for _, query := range []string{
+		"create database src",
+		"use src",
+		"create table inmates (id int primary key, name varchar(20))",
+		"insert into inmates values (1, 'Al')",
+		"call dolt_commit('-Am', 'first inmate')",
+		"call dolt_remote('add', 'origin', '" + remote + "')",
+		"call dolt_push('origin', 'main')",
+	} {
+		_, err := sourceDB.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	push := func(query string) {
+		for _, query := range []string{query, "call dolt_commit('-Am', 'change')", "call dolt_push('origin', 'main')"} {
+			_, err := sourceDB.ExecContext(ctx, query)
+			require.NoError(t, err, query)
+		}
+	}
+
+	// The first open clones the remote, and connections use the clone
+	cacheDir := tempDir()
+	names := func(cfg Config) []string {
+		connector, db := open(cfg)
+		defer connector.Close()
+		defer db.Close()
+		rows, err := db.QueryContext(ctx, "select name from inmates order by id")
+		require.NoError(t, err)
+		var names []string
+		for rows.Next() {
+			var name string
+			require.NoError(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		require.NoError(t, rows.Err())
+		return names
+	}
+	cfg := Config{Directory: cacheDir, Clone: remote}
+	require.Equal(t, []string{"Al"}, names(cfg))
+	_, err := os.Stat(filepath.Join(cacheDir, "jails", ".dolt"))
+	require.NoError(t, err)
+
+	// Later opens fast-forward to the remote's new commits
+	require.Equal(t, []string{"Al"}, names(cfg))
+	push("insert into inmates values (2, 'Bonnie')")
+	require.Equal(t, []string{"Al", "Bonnie"}, names(cfg))
+
+	// A data source name works as well
+	dsnCfg, err := ParseDSN("file://" + filepath.ToSlash(cacheDir) + "?commitname=Billy&commitemail=b@b.com&clone=" + remote)
+	require.NoError(t, err)
+	require.Equal(t, remote, dsnCfg.Clone)
+	require.Equal(t, []string{"Al", "Bonnie"}, names(dsnCfg))
+
+	// A clone with commits of its own can't be fast-forwarded
+	connector, db := open(cfg)
+	for _, query := range []string{"insert into inmates values (3, 'Clyde')", "call dolt_commit('-Am', 'local change')"} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	require.NoError(t, db.Close())
+	require.NoError(t, connector.Close())
+	push("insert into inmates values (4, 'Dillinger')")
+	cfg.CommitName, cfg.CommitEmail = "Billy Batson", "shazam@gmail.com"
+	_, err = NewConnector(cfg)
+	require.ErrorIs(t, err, ErrCloneDiverged)
+}
@@ -0,0 +1,73 @@
+package embedded
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+)
+
+// CloseTimeoutError is returned when closing an engine takes longer than the deadline of the context it was closed
+// with. The engine keeps closing in the background.
+type CloseTimeoutError struct {
+	// Err is the error of the context, context.DeadlineExceeded or context.Canceled
+	Err error
+	// Stack is the stack trace of the goroutine closing the engine at the time the context was done, showing what the
+	// close was blocked on, such as flushing the journal or waiting for a lock
+	Stack string
+}
+
+func (e *CloseTimeoutError) Error() string {
+	return fmt.Sprintf("closing the engine did not finish: %v; blocked in:\n%s", e.Err, e.Stack)
+}
+
+func (e *CloseTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// closeEngineMarker is the function that closes engines in closeEngine. Its name identifies the closing goroutine in
+// stack traces.
+func closeEngineMarker(se *engine.SqlEngine, closed chan<- error) {
+	closed <- se.Close()
+}
+
+// closeEngine closes |se|, returning a *CloseTimeoutError if |ctx| is done first. The context.Canceled error engines
+// return when closing normally is ignored.
+func closeEngine(ctx context.Context, se *engine.SqlEngine) error {
+	closed := make(chan error, 1)
+	go closeEngineMarker(se, closed)
+
+	select {
+	case err := <-closed:
+		if err != context.Canceled {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+		return &CloseTimeoutError{Err: ctx.Err(), Stack: goroutineStack("embedded.closeEngineMarker")}
+	}
+}
+
+// goroutineStack returns the stack trace of the first goroutine with a frame in a function whose name contains |fn|,
+// or the stack traces of all goroutines if there is none.
+func goroutineStack(fn string) string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.Contains(stack, []byte(fn)) {
+			return string(stack)
+		}
+	}
+
+	return string(buf)
+}
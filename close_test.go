@@ -0,0 +1,36 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseContext(t *testing.T) {
+	connector, db, cleanUpFunc := initializeTestConnector(t)
+	defer cleanUpFunc()
+
+	require.NoError(t, db.PingContext(context.Background()))
+	require.NoError(t, connector.CloseContext(context.Background()))
+}
+
+func blockedCloseForTest(release <-chan struct{}, started chan<- struct{}) {
+	close(started)
+	<-release
+}
+
+func TestGoroutineStack(t *testing.T) {
+	release, started := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	go blockedCloseForTest(release, started)
+	<-started
+
+	stack := goroutineStack("embedded.blockedCloseForTest")
+	require.Contains(t, stack, "blockedCloseForTest")
+	require.Contains(t, stack, "chan receive")
+
+	err := &CloseTimeoutError{Err: context.DeadlineExceeded, Stack: stack}
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Contains(t, err.Error(), "blockedCloseForTest")
+}
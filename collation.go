@@ -0,0 +1,44 @@
+package embedded
+
+import (
+	"fmt"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// collationVars are the session variables Config.Collation sets, each with the character set variable it goes with.
+// The connection's collation applies to literals and arguments, and the server's to the databases the session creates,
+// and so to their tables.
+var collationVars = [][2]string{
+	{"character_set_connection", "collation_connection"},
+	{"character_set_server", "collation_server"},
+}
+
+// collation returns the collation of Config.Collation, or gms.Collation_Unspecified if it isn't set.
+func (cfg Config) collation() (gms.CollationID, error) {
+	if cfg.Collation == "" {
+		return gms.Collation_Unspecified, nil
+	}
+	collation, err := gms.ParseCollation("", cfg.Collation, false)
+	if err != nil {
+		return gms.Collation_Unspecified, fmt.Errorf("unknown collation '%s'", cfg.Collation)
+	}
+	return collation, nil
+}
+
+// setCollation makes |collation| the collation of the session of |gmsCtx|, like SET NAMES ... COLLATE does for the
+// connection, and the default collation of the databases it creates. Unspecified collations are ignored.
+func setCollation(gmsCtx *gms.Context, collation gms.CollationID) error {
+	if collation == gms.Collation_Unspecified {
+		return nil
+	}
+	for _, vars := range collationVars {
+		if err := gmsCtx.SetSessionVariable(gmsCtx, vars[0], collation.CharacterSet().Name()); err != nil {
+			return err
+		}
+		if err := gmsCtx.SetSessionVariable(gmsCtx, vars[1], collation.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
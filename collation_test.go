@@ -0,0 +1,74 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollation(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	query := url.Values{
+		CommitNameParam:  []string{"Billy Batson"},
+		CommitEmailParam: []string{"shazam@gmail.com"},
+		CollationParam:   []string{"utf8mb4_0900_ai_ci"},
+	}
+	dsn := url.URL{Scheme: "file", Path: encodeDir(dir), RawQuery: query.Encode()}
+	db, err := sql.Open(DoltDriverName, dsn.String())
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, query := range []string{
+		"create database testdb",
+		"use testdb",
+		"create table t (id int primary key, v varchar(10), b varbinary(10), s varchar(10) collate utf8mb4_bin)",
+		"insert into t values (1, 'a', 'a', 'a'), (2, 'A', 'A', 'A'), (3, 'é', 'é', 'é')",
+	} {
+		_, err := conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	// Literals and arguments compare like in MySQL
+	var equal, equalArg bool
+	require.NoError(t, conn.QueryRowContext(ctx, "select 'a' = 'A', ? = 'É'", "e").Scan(&equal, &equalArg))
+	require.True(t, equal)
+	require.True(t, equalArg)
+
+	// and so do the columns of the tables created without a collation
+	var count int
+	require.NoError(t, conn.QueryRowContext(ctx, "select count(*) from t where v = 'A'").Scan(&count))
+	require.Equal(t, 2, count)
+	require.NoError(t, conn.QueryRowContext(ctx, "select count(*) from t where s = 'A'").Scan(&count))
+	require.Equal(t, 1, count)
+
+	// The collations of the columns are available to code using the driver directly
+	var collations []string
+	require.NoError(t, conn.Raw(func(driverConn any) error {
+		stmt, err := driverConn.(*DoltConn).Prepare("select id, v, b, s from t")
+		require.NoError(t, err)
+		rows, err := stmt.(driver.StmtQueryContext).QueryContext(ctx, nil)
+		require.NoError(t, err)
+		defer rows.Close()
+		typed := rows.(RowsColumnTypeCollation)
+		for i := range rows.Columns() {
+			collations = append(collations, typed.ColumnTypeCollation(i))
+		}
+		return nil
+	}))
+	require.Equal(t, []string{"", "utf8mb4_0900_ai_ci", "binary", "utf8mb4_bin"}, collations)
+
+	_, err = NewConnector(Config{Directory: dir, CommitName: "Billy", CommitEmail: "b@b.com", Collation: "klingon_ci"})
+	require.ErrorContains(t, err, "unknown collation 'klingon_ci'")
+}
@@ -0,0 +1,104 @@
+package embedded
+
+import (
+	"database/sql/driver"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	querypb "github.com/dolthub/vitess/go/vt/proto/query"
+)
+
+var _ driver.RowsColumnTypeDatabaseTypeName = (*doltRows)(nil)
+var _ driver.RowsColumnTypeDatabaseTypeName = (*doltMultiRows)(nil)
+
+// RowsColumnTypeCollation is implemented by the driver.Rows returned by the driver's statements, for code using the
+// driver directly, e.g. through sql.Conn.Raw, since sql.ColumnType doesn't report collations. ColumnTypeCollation
+// returns the collation of the column at |index|, such as "utf8mb4_0900_ai_ci", which determines how its values
+// compare and sort, "binary" for binary strings, or "" for columns that aren't strings.
+type RowsColumnTypeCollation interface {
+	ColumnTypeCollation(index int) string
+}
+
+var _ RowsColumnTypeCollation = (*doltRows)(nil)
+var _ RowsColumnTypeCollation = (*doltMultiRows)(nil)
+
+// databaseTypeNames are the database type names of the column types, as the MySQL driver names them. TEXT and BLOB
+// columns of every size are named TEXT and BLOB, since MySQL sends them all as BLOB fields.
+var databaseTypeNames = map[querypb.Type]string{
+	sqltypes.Null:      "NULL",
+	sqltypes.Int8:      "TINYINT",
+	sqltypes.Uint8:     "UNSIGNED TINYINT",
+	sqltypes.Int16:     "SMALLINT",
+	sqltypes.Uint16:    "UNSIGNED SMALLINT",
+	sqltypes.Int24:     "MEDIUMINT",
+	sqltypes.Uint24:    "UNSIGNED MEDIUMINT",
+	sqltypes.Int32:     "INT",
+	sqltypes.Uint32:    "UNSIGNED INT",
+	sqltypes.Int64:     "BIGINT",
+	sqltypes.Uint64:    "UNSIGNED BIGINT",
+	sqltypes.Float32:   "FLOAT",
+	sqltypes.Float64:   "DOUBLE",
+	sqltypes.Timestamp: "TIMESTAMP",
+	sqltypes.Date:      "DATE",
+	sqltypes.Time:      "TIME",
+	sqltypes.Datetime:  "DATETIME",
+	sqltypes.Year:      "YEAR",
+	sqltypes.Decimal:   "DECIMAL",
+	sqltypes.Text:      "TEXT",
+	sqltypes.Blob:      "BLOB",
+	sqltypes.VarChar:   "VARCHAR",
+	sqltypes.VarBinary: "VARBINARY",
+	sqltypes.Char:      "CHAR",
+	sqltypes.Binary:    "BINARY",
+	sqltypes.Bit:       "BIT",
+	sqltypes.Enum:      "ENUM",
+	sqltypes.Set:       "SET",
+	sqltypes.Geometry:  "GEOMETRY",
+	sqltypes.TypeJSON:  "JSON",
+}
+
+// ColumnTypeDatabaseTypeName returns the database type name of the column at |index|, such as "VARCHAR" or
+// "UNSIGNED BIGINT", as the MySQL driver returns it.
+func (rows *doltRows) ColumnTypeDatabaseTypeName(index int) string {
+	if rows.intercepted != nil {
+		if typed, ok := rows.intercepted.(driver.RowsColumnTypeDatabaseTypeName); ok {
+			return typed.ColumnTypeDatabaseTypeName(index)
+		}
+		return ""
+	}
+
+	return databaseTypeNames[rows.sch[index].Type.Type()]
+}
+
+// ColumnTypeDatabaseTypeName returns the database type name of the column at |index| of the current result set.
+func (d *doltMultiRows) ColumnTypeDatabaseTypeName(index int) string {
+	if d.currentRowSet >= len(d.rowSets) {
+		return ""
+	}
+
+	return d.rowSets[d.currentRowSet].ColumnTypeDatabaseTypeName(index)
+}
+
+// ColumnTypeCollation returns the collation of the column at |index|.
+func (rows *doltRows) ColumnTypeCollation(index int) string {
+	if rows.intercepted != nil {
+		if typed, ok := rows.intercepted.(RowsColumnTypeCollation); ok {
+			return typed.ColumnTypeCollation(index)
+		}
+		return ""
+	}
+
+	if typed, ok := rows.sch[index].Type.(gms.TypeWithCollation); ok {
+		return typed.Collation().Name()
+	}
+	return ""
+}
+
+// ColumnTypeCollation returns the collation of the column at |index| of the current result set.
+func (d *doltMultiRows) ColumnTypeCollation(index int) string {
+	if d.currentRowSet >= len(d.rowSets) {
+		return ""
+	}
+
+	return d.rowSets[d.currentRowSet].ColumnTypeCollation(index)
+}
@@ -0,0 +1,71 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestColumns asserts that result sets keep the exact case of the SELECT list's column names and aliases, including
+// duplicate names and expressions, and report the database type names of their columns like the MySQL driver does.
+func TestColumns(t *testing.T) {
+	conn, cleanupFunc := initializeTestDatabaseConnection(t, false)
+	defer cleanupFunc()
+
+	ctx := context.Background()
+	_, err := conn.ExecContext(ctx, "create table Users (Id int primary key, UserName varchar(10), `MiXed` bigint unsigned, Bits bit(4), Kind enum('a', 'b'))")
+	require.NoError(t, err)
+
+	tests := []struct {
+		query     string
+		columns   []string
+		typeNames []string
+	}{
+		{
+			query:     "select * from Users",
+			columns:   []string{"Id", "UserName", "MiXed", "Bits", "Kind"},
+			typeNames: []string{"INT", "VARCHAR", "UNSIGNED BIGINT", "BIT", "ENUM"},
+		},
+		{
+			query:     "select Id, USERNAME, mixed from Users",
+			columns:   []string{"Id", "USERNAME", "mixed"},
+			typeNames: []string{"INT", "VARCHAR", "UNSIGNED BIGINT"},
+		},
+		{
+			query:     "select u.ID, U.userName from Users as u",
+			columns:   []string{"ID", "userName"},
+			typeNames: []string{"INT", "VARCHAR"},
+		},
+		{
+			query:     "select Id as UserID, UserName as `Full Name`, Id, id, count(*) as Total, 1+1, concat(UserName, 'x') from Users group by Id, UserName",
+			columns:   []string{"UserID", "Full Name", "Id", "id", "Total", "1+1", "concat(UserName, 'x')"},
+			typeNames: []string{"INT", "VARCHAR", "INT", "INT", "BIGINT", "BIGINT", "TEXT"},
+		},
+		{
+			query:     "select 1 as A, 2 as a",
+			columns:   []string{"A", "a"},
+			typeNames: []string{"TINYINT", "TINYINT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rows, err := conn.QueryContext(ctx, tt.query)
+			require.NoError(t, err)
+			defer rows.Close()
+
+			columns, err := rows.Columns()
+			require.NoError(t, err)
+			require.Equal(t, tt.columns, columns)
+
+			columnTypes, err := rows.ColumnTypes()
+			require.NoError(t, err)
+			typeNames := make([]string, len(columnTypes))
+			for i, columnType := range columnTypes {
+				typeNames[i] = columnType.DatabaseTypeName()
+			}
+			require.Equal(t, tt.typeNames, typeNames)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package embedded
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+type commitMessageKey struct{}
+
+// commitMessageComment matches the comments that set the commit message of a statement's transaction, as in
+// /* dolt:msg: fixing prices */
+var commitMessageComment = regexp.MustCompile(`(?s)/\*\s*dolt:msg:(.*?)\*/`)
+
+// WithCommitMessage returns a copy of |ctx| carrying |message|, which becomes the message of the Dolt commit made for
+// the transaction of the statements executed with the returned context, or of the transaction begun with it, with
+// Config.TransactionCommits. It takes precedence over the messages of /* dolt:msg: ... */ comments.
+func WithCommitMessage(ctx context.Context, message string) context.Context {
+	return context.WithValue(ctx, commitMessageKey{}, message)
+}
+
+// commitMessage returns the commit message of a statement executing |query| with |ctx|: the one set with
+// WithCommitMessage, or else the one of the first /* dolt:msg: ... */ comment in |query|, or the empty string.
+func commitMessage(ctx context.Context, query string) string {
+	if message, _ := ctx.Value(commitMessageKey{}).(string); strings.TrimSpace(message) != "" {
+		return strings.TrimSpace(message)
+	}
+	if match := commitMessageComment.FindStringSubmatch(query); match != nil {
+		return strings.TrimSpace(match[1])
+	}
+	return ""
+}
+
+// commitMessages sets the message of the Dolt commits a connection's transactions make with Config.TransactionCommits,
+// from the messages of the statements of each transaction. The engine reads the message from a session variable when
+// the transaction commits, so the message of a statement is set there, where it stays until the next transaction
+// starts, and the last message set in a transaction wins. The value the variable had before is then restored, so that
+// messages set with SET @@dolt_transaction_commit_message still apply to the transactions without one.
+type commitMessages struct {
+	// set is true while the variable holds a message set for the current transaction, and previous is the value it had
+	// before
+	set      bool
+	previous any
+}
+
+// newCommitMessages makes the transactions of the connection session |gmsCtx| make Dolt commits, and returns the
+// connection's commitMessages, if Config.TransactionCommits is set. It returns nil otherwise.
+func newCommitMessages(cfg Config, gmsCtx *gms.Context) (*commitMessages, error) {
+	if !cfg.TransactionCommits {
+		return nil, nil
+	}
+	if err := gmsCtx.SetSessionVariable(gmsCtx, dsess.DoltCommitOnTransactionCommit, true); err != nil {
+		return nil, err
+	}
+	return &commitMessages{}, nil
+}
+
+// apply is called before a statement executes |query| with |ctx| in |gmsCtx|, and sets the commit message of the
+// transaction the statement runs in, which the statement starts if the session has none. A nil *commitMessages does
+// nothing.
+func (m *commitMessages) apply(ctx context.Context, gmsCtx *gms.Context, query string) error {
+	if m == nil {
+		return nil
+	}
+	if gmsCtx.GetTransaction() == nil {
+		if err := m.reset(gmsCtx); err != nil {
+			return err
+		}
+	}
+	return m.setMessage(gmsCtx, commitMessage(ctx, query))
+}
+
+// begin is called once a transaction was begun with |ctx| in |gmsCtx|, and sets its commit message from |ctx|. A nil
+// *commitMessages does nothing.
+func (m *commitMessages) begin(ctx context.Context, gmsCtx *gms.Context) error {
+	if m == nil {
+		return nil
+	}
+	if err := m.reset(gmsCtx); err != nil {
+		return err
+	}
+	return m.setMessage(gmsCtx, commitMessage(ctx, ""))
+}
+
+// setMessage makes |message| the commit message of the current transaction of |gmsCtx|, unless it is empty.
+func (m *commitMessages) setMessage(gmsCtx *gms.Context, message string) error {
+	if message == "" {
+		return nil
+	}
+	if !m.set {
+		previous, err := gmsCtx.GetSessionVariable(gmsCtx, dsess.DoltCommitOnTransactionCommitMessage)
+		if err != nil {
+			return err
+		}
+		m.previous = previous
+	}
+	if err := gmsCtx.SetSessionVariable(gmsCtx, dsess.DoltCommitOnTransactionCommitMessage, message); err != nil {
+		return err
+	}
+	m.set = true
+	return nil
+}
+
+// reset restores the commit message the session had before a message was set for its last transaction.
+func (m *commitMessages) reset(gmsCtx *gms.Context) error {
+	if !m.set {
+		return nil
+	}
+	if err := gmsCtx.SetSessionVariable(gmsCtx, dsess.DoltCommitOnTransactionCommitMessage, m.previous); err != nil {
+		return err
+	}
+	m.set = false
+	m.previous = nil
+	return nil
+}
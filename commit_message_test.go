@@ -0,0 +1,88 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionCommits(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{
+		Directory:          dir,
+		CommitName:         "Billy Batson",
+		CommitEmail:        "shazam@gmail.com",
+		Database:           "testdb",
+		TransactionCommits: true,
+	})
+	require.NoError(t, err)
+	defer connector.Close()
+	db := sql.OpenDB(connectorOnly{connector})
+	defer db.Close()
+
+	ctx := context.Background()
+	messages := func() []string {
+		it, err := connector.Log(ctx, "testdb", LogOptions{})
+		require.NoError(t, err)
+		defer it.Close()
+		commits, err := it.All()
+		require.NoError(t, err)
+		var messages []string
+		for _, commit := range commits {
+			messages = append(messages, commit.Message)
+		}
+		return messages
+	}
+	exec := func(ctx context.Context, conn *sql.Conn, query string) {
+		_, err := conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	exec(ctx, conn, "create database testdb")
+	exec(ctx, conn, "use testdb")
+	exec(ctx, conn, "create table t (id int primary key, price int)")
+
+	// Statements executed with autocommit commit with the message of their comment, or of their context
+	exec(ctx, conn, "insert into t values (1, 10), (2, 20) /* dolt:msg: add products */")
+	exec(WithCommitMessage(ctx, "from the context"), conn, "/* dolt:msg: from the comment */ delete from t where id = 2")
+	exec(ctx, conn, "/* dolt:msg: reads don't commit */ select * from t")
+	require.Equal(t, []string{"from the context", "add products", "Transaction commit", "Initialize data repository"},
+		messages()[:4])
+
+	// The last message set in a transaction wins
+	exec(ctx, conn, "begin")
+	exec(ctx, conn, "/* dolt:msg: first */ update t set price = 11")
+	exec(ctx, conn, "/* dolt:msg:\n\tfixing prices\n*/ update t set price = 12")
+	exec(ctx, conn, "commit")
+	require.Equal(t, "fixing prices", messages()[0])
+
+	// and the context of BeginTx sets the message of the transaction
+	tx, err := conn.BeginTx(WithCommitMessage(ctx, "import products"), nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "insert into t values (3, 30)")
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "insert into t values (4, 40)")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	require.Equal(t, []string{"import products", "fixing prices"}, messages()[:2])
+
+	// Transactions without a message use the session's
+	exec(ctx, conn, "set @@dolt_transaction_commit_message = 'session message'")
+	exec(ctx, conn, "/* dolt:msg: remove product 3 */ delete from t where id = 3")
+	exec(ctx, conn, "delete from t where id = 4")
+	require.Equal(t, []string{"session message", "remove product 3"}, messages()[:2])
+
+	// There is nothing left to commit
+	var count int
+	require.NoError(t, conn.QueryRowContext(ctx, "select count(*) from dolt_status").Scan(&count))
+	require.Zero(t, count)
+}
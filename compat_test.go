@@ -0,0 +1,595 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+// The compatibility matrix runs each of compatCases with the Dolt driver, and with the MySQL driver against a MySQL
+// server when the DOLT_DRIVER_COMPAT_MYSQL_DSN environment variable is set to its DSN, or runTestsAgainstMySQL is. Each
+// case records what the application observes: the result sets with their column types and values, the rows affected
+// and last insert id, or the error number and SQLSTATE. The observations must match the golden observation of the
+// case, want, which is the same for both drivers unless the case records MySQL's observation and why it deviates.
+// With DOLT_DRIVER_COMPAT_REPORT set to a path, the matrix of the behaviors that passed and failed with each driver is
+// written to it as a markdown table.
+
+// compatCase is a behavior of the compatibility matrix.
+type compatCase struct {
+	category string
+	name     string
+	// setup are the statements creating the tables of the case, in an empty database
+	setup []string
+	// query is executed with QueryContext, or ExecContext if exec is true, with args
+	query string
+	args  []any
+	exec  bool
+	// want is the golden observation of query
+	want string
+	// mysql is MySQL's observation, if it deviates from want, and deviation explains why
+	mysql     string
+	deviation string
+}
+
+var compatCases = []compatCase{
+	{
+		category: "types",
+		name:     "integers",
+		setup: []string{
+			"create table t (a tinyint, b smallint, c int, d bigint, e bigint unsigned)",
+			"insert into t values (-1, -2, -3, -4, 18446744073709551615)",
+		},
+		query: "select * from t",
+		want:  "[TINYINT SMALLINT INT BIGINT UNSIGNED BIGINT] (-1, -2, -3, -4, 18446744073709551615)",
+	},
+	{
+		category: "types",
+		name:     "decimals and floats",
+		setup: []string{
+			"create table t (a decimal(10,2), b float, c double)",
+			"insert into t values (1.5, 2.5, 3.25)",
+		},
+		query:     "select * from t",
+		want:      "[DECIMAL FLOAT DOUBLE] (1.5, 2.5, 3.25)",
+		mysql:     "[DECIMAL FLOAT DOUBLE] (1.50, 2.5, 3.25)",
+		deviation: "decimals are returned without their trailing zeros",
+	},
+	{
+		category: "types",
+		name:     "strings",
+		setup: []string{
+			"create table t (a varchar(10), b char(3), c text, d blob)",
+			"insert into t values ('abc', 'de', 'long text', 'bytes')",
+		},
+		query: "select * from t",
+		want:  "[VARCHAR CHAR TEXT BLOB] (abc, de, long text, bytes)",
+	},
+	{
+		category: "types",
+		name:     "temporal",
+		setup: []string{
+			"create table t (a date, b datetime, c timestamp, d time, e year)",
+			"insert into t values ('2021-06-01', '2021-06-01 12:30:45', '2021-06-01 12:30:45', '12:30:45', 2021)",
+		},
+		query: "select * from t",
+		want:  "[DATE DATETIME TIMESTAMP TIME YEAR] (2021-06-01 00:00:00, 2021-06-01 12:30:45, 2021-06-01 12:30:45, 12:30:45, 2021)",
+	},
+	{
+		category: "types",
+		name:     "enum, set and json",
+		setup: []string{
+			"create table t (a enum('x', 'y'), b set('x', 'y'), c json)",
+			`insert into t values ('y', 'x,y', '{"k": [1, 2]}')`,
+		},
+		query: "select * from t",
+		want:  `[ENUM SET JSON] (y, x,y, {"k": [1, 2]})`,
+	},
+	{
+		category:  "types",
+		name:      "literals",
+		query:     "select 1, 'two'",
+		want:      "[TINYINT TEXT] (1, two)",
+		mysql:     "[BIGINT VARCHAR] (1, two)",
+		deviation: "the engine types integer literals by their value, and string literals as TEXT",
+	},
+	{
+		category: "types",
+		name:     "nulls",
+		setup: []string{
+			"create table t (a int, b varchar(10), c datetime)",
+			"insert into t values (null, null, null)",
+		},
+		query: "select * from t",
+		want:  "[INT VARCHAR DATETIME] (NULL, NULL, NULL)",
+	},
+	{
+		category: "types",
+		name:     "placeholder arguments",
+		setup: []string{
+			"create table t (a int, b varchar(10), c double)",
+		},
+		query: "insert into t values (?, ?, ?)",
+		args:  []any{7, "seven", 7.5},
+		exec:  true,
+		want:  "affected 1, id 0",
+	},
+	{
+		category:  "errors",
+		name:      "table not found",
+		query:     "select * from nosuchtable",
+		want:      "error 1146",
+		mysql:     "error 1146 (42S02)",
+		deviation: "the Dolt driver doesn't report SQLSTATE codes",
+	},
+	{
+		category:  "errors",
+		name:      "syntax error",
+		query:     "selec 1",
+		want:      "error 1105",
+		mysql:     "error 1064 (42000)",
+		deviation: "the engine reports syntax errors as generic errors",
+	},
+	{
+		category: "errors",
+		name:     "duplicate key",
+		setup: []string{
+			"create table t (id int primary key)",
+			"insert into t values (1)",
+		},
+		query:     "insert into t values (1)",
+		exec:      true,
+		want:      "error 1062",
+		mysql:     "error 1062 (23000)",
+		deviation: "the Dolt driver doesn't report SQLSTATE codes",
+	},
+	{
+		category: "errors",
+		name:     "column count mismatch",
+		setup: []string{
+			"create table t (id int, v varchar(10))",
+		},
+		query:     "insert into t values (1, 2, 'too many')",
+		exec:      true,
+		want:      "error 1105",
+		mysql:     "error 1136 (21S01)",
+		deviation: "the engine reports a generic error for a mismatched number of values",
+	},
+	{
+		category: "multi-results",
+		name:     "two result sets",
+		setup: []string{
+			"create table t (id int primary key, v varchar(10))",
+			"insert into t values (1, 'one')",
+		},
+		query: "select id from t; select v from t",
+		want:  "[INT] (1) | [VARCHAR] (one)",
+	},
+	{
+		category: "multi-results",
+		name:     "statements without result sets are skipped",
+		setup: []string{
+			"create table t (id int primary key)",
+		},
+		query: "insert into t values (1), (2); insert into t values (3); select * from t",
+		want:  "[INT] (1); (2); (3)",
+	},
+	{
+		category: "multi-results",
+		name:     "error in a later statement",
+		setup: []string{
+			"create table t (id int primary key)",
+			"insert into t values (1)",
+		},
+		query:     "select * from t; select * from nosuchtable; select * from t",
+		want:      "[INT] (1) | error 1146",
+		mysql:     "[INT] (1) | error 1146 (42S02)",
+		deviation: "the Dolt driver doesn't report SQLSTATE codes",
+	},
+	{
+		category: "multi-results",
+		name:     "empty statements",
+		setup: []string{
+			"create table t (id int primary key, v varchar(10))",
+			"insert into t values (1, 'one')",
+		},
+		query:     "select id from t; ; ; select v from t",
+		want:      "[INT] (1) | [VARCHAR] (one)",
+		mysql:     "[INT] (1)",
+		deviation: "the MySQL driver doesn't move past empty statements to the next result set",
+	},
+	{
+		category: "affected-rows",
+		name:     "update matching unchanged rows",
+		setup: []string{
+			"create table t (id int primary key, v int)",
+			"insert into t values (1, 1), (2, 2)",
+		},
+		query: "update t set v = 1",
+		exec:  true,
+		want:  "affected 1, id 0",
+	},
+	{
+		category: "affected-rows",
+		name:     "insert on duplicate key update",
+		setup: []string{
+			"create table t (id int primary key, v int)",
+			"insert into t values (1, 1)",
+		},
+		query: "insert into t values (1, 2), (2, 2) on duplicate key update v = values(v)",
+		exec:  true,
+		want:  "affected 3, id 0",
+	},
+	{
+		category: "affected-rows",
+		name:     "replace",
+		setup: []string{
+			"create table t (id int primary key, v int)",
+			"insert into t values (1, 1)",
+		},
+		query: "replace into t values (1, 2)",
+		exec:  true,
+		want:  "affected 2, id 0",
+	},
+	{
+		category: "affected-rows",
+		name:     "auto increment",
+		setup: []string{
+			"create table t (id int primary key auto_increment, v int)",
+			"insert into t (v) values (1)",
+		},
+		query: "insert into t (v) values (2), (3)",
+		exec:  true,
+		want:  "affected 2, id 2",
+	},
+	{
+		category: "affected-rows",
+		name:     "insert without auto increment after one with",
+		setup: []string{
+			"create table a (id int primary key auto_increment)",
+			"create table t (id int primary key)",
+			"insert into a values (null)",
+		},
+		query:     "insert into t values (1)",
+		exec:      true,
+		want:      "affected 1, id 1",
+		mysql:     "affected 1, id 0",
+		deviation: "the insert id of a statement that doesn't generate one is the session's LAST_INSERT_ID()",
+	},
+	{
+		category: "affected-rows",
+		name:     "multi-statement exec returns the last result",
+		setup: []string{
+			"create table t (id int primary key, v int)",
+		},
+		query: "insert into t values (1, 1); insert into t values (2, 2), (3, 3)",
+		exec:  true,
+		want:  "affected 2, id 0",
+	},
+	{
+		category: "collations",
+		name:     "case-insensitive lookup",
+		setup:    collationSetup,
+		query:    "select id from t where ci = 'A' order by id",
+		want:     "[INT] (2); (3)",
+	},
+	{
+		category: "collations",
+		name:     "case-insensitive lookup with an argument",
+		setup:    collationSetup,
+		query:    "select id from t where ci = ? order by id",
+		args:     []any{"A"},
+		want:     "[INT] (2); (3)",
+	},
+	{
+		category: "collations",
+		name:     "accent-insensitive lookup",
+		setup:    collationSetup,
+		query:    "select id from t where ci = 'E' order by id",
+		want:     "[INT] (4); (5)",
+	},
+	{
+		category: "collations",
+		name:     "accent- and case-sensitive lookup",
+		setup:    collationSetup,
+		query:    "select id from t where cs = 'A' or cs = 'e' order by id",
+		want:     "[INT] (2); (5)",
+	},
+	{
+		category: "collations",
+		name:     "binary lookup",
+		setup:    collationSetup,
+		query:    "select id from t where bin = 'a' order by id",
+		want:     "[INT] (3)",
+	},
+	{
+		category: "collations",
+		name:     "case-insensitive like",
+		setup:    collationSetup,
+		query:    "select id from t where ci like ? order by id",
+		args:     []any{"a%"},
+		want:     "[INT] (2); (3)",
+	},
+	{
+		category: "collations",
+		name:     "case-insensitive order",
+		setup:    collationSetup,
+		query:    "select ci from t order by ci, id",
+		want:     "[VARCHAR] (A); (a); (b); (B); (é); (e)",
+	},
+	{
+		category: "collations",
+		name:     "case-sensitive order",
+		setup:    collationSetup,
+		query:    "select cs from t order by cs, id",
+		want:     "[VARCHAR] (a); (A); (b); (B); (e); (é)",
+	},
+	{
+		category: "collations",
+		name:     "binary order",
+		setup:    collationSetup,
+		query:    "select bin from t order by bin, id",
+		want:     "[VARCHAR] (A); (B); (a); (b); (e); (é)",
+	},
+	{
+		category: "collations",
+		name:     "order with a collate clause",
+		setup:    collationSetup,
+		query:    "select ci from t order by ci collate utf8mb4_bin, id",
+		want:     "[VARCHAR] (A); (B); (a); (b); (e); (é)",
+	},
+	{
+		category: "collations",
+		name:     "case-insensitive group by",
+		setup:    collationSetup,
+		query:    "select min(id), count(*) from t group by ci order by 1",
+		want:     "[INT BIGINT] (1, 2); (2, 2); (4, 2)",
+	},
+	{
+		category:  "collations",
+		name:      "case-insensitive count distinct",
+		setup:     collationSetup,
+		query:     "select count(distinct ci) from t",
+		want:      "[BIGINT] (6)",
+		mysql:     "[BIGINT] (3)",
+		deviation: "the engine counts distinct strings by their bytes, ignoring the column's collation",
+	},
+	{
+		category: "collations",
+		name:     "default collation",
+		setup: []string{
+			"create table d (v varchar(10))",
+			"insert into d values ('a'), ('A')",
+		},
+		query:     "select count(*) from d where v = 'a'",
+		want:      "[BIGINT] (1)",
+		mysql:     "[BIGINT] (2)",
+		deviation: "Dolt's default collation is utf8mb4_0900_bin rather than utf8mb4_0900_ai_ci; set Config.Collation to change it",
+	},
+}
+
+// collationSetup creates the table of the collation cases, with a column of each kind of collation holding the same
+// strings, differing in case and accents.
+var collationSetup = []string{
+	"create table t (id int primary key, ci varchar(10) collate utf8mb4_0900_ai_ci, cs varchar(10) collate utf8mb4_0900_as_cs, bin varchar(10) collate utf8mb4_bin)",
+	"insert into t values (1, 'b', 'b', 'b'), (2, 'A', 'A', 'A'), (3, 'a', 'a', 'a'), (4, 'é', 'é', 'é'), (5, 'e', 'e', 'e'), (6, 'B', 'B', 'B')",
+}
+
+// compatDriver opens the connections of a driver of the compatibility matrix.
+type compatDriver struct {
+	name string
+	db   *sql.DB
+}
+
+// compatDrivers returns the Dolt driver, and the MySQL driver if a MySQL server is configured.
+func compatDrivers(t *testing.T) []compatDriver {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	connector, err := NewConnector(Config{
+		Directory:       dir,
+		CommitName:      "Billy Batson",
+		CommitEmail:     "shazam@gmail.com",
+		MultiStatements: true,
+	})
+	require.NoError(t, err)
+	dolt := sql.OpenDB(connector)
+	t.Cleanup(func() { dolt.Close() })
+	drivers := []compatDriver{{name: "dolt", db: dolt}}
+
+	dsn := os.Getenv("DOLT_DRIVER_COMPAT_MYSQL_DSN")
+	if dsn == "" && runTestsAgainstMySQL {
+		dsn = mysqlDsn
+	}
+	if dsn != "" {
+		db, err := sql.Open("mysql", dsn)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		drivers = append(drivers, compatDriver{name: "mysql", db: db})
+	}
+	return drivers
+}
+
+func TestCompatibilityMatrix(t *testing.T) {
+	ctx := context.Background()
+	drivers := compatDrivers(t)
+
+	// passed records whether each case passed with each driver
+	passed := make(map[string]map[string]bool)
+	for _, drv := range drivers {
+		passed[drv.name] = make(map[string]bool)
+		for _, c := range compatCases {
+			name := c.category + "/" + c.name
+			passed[drv.name][name] = t.Run(drv.name+"/"+name, func(t *testing.T) {
+				// Each case has a session of its own, discarded afterwards, so that session state such as
+				// LAST_INSERT_ID() doesn't leak from one case to the next
+				conn, err := drv.db.Conn(ctx)
+				require.NoError(t, err)
+				defer func() {
+					conn.Raw(func(any) error { return driver.ErrBadConn })
+					conn.Close()
+				}()
+				for _, query := range []string{"drop database if exists compatdb", "create database compatdb", "use compatdb"} {
+					_, err = conn.ExecContext(ctx, query)
+					require.NoError(t, err)
+				}
+				for _, query := range c.setup {
+					_, err = conn.ExecContext(ctx, query)
+					require.NoError(t, err)
+				}
+
+				want := c.want
+				if drv.name == "mysql" && c.mysql != "" {
+					want = c.mysql
+				}
+				require.Equal(t, want, observe(ctx, conn, c))
+			})
+		}
+	}
+
+	if path := os.Getenv("DOLT_DRIVER_COMPAT_REPORT"); path != "" {
+		require.NoError(t, os.WriteFile(path, []byte(compatReport(drivers, passed)), 0644))
+	}
+}
+
+// compatReport returns the markdown table of the cases that passed and failed with each of |drivers|.
+func compatReport(drivers []compatDriver, passed map[string]map[string]bool) string {
+	var report strings.Builder
+	report.WriteString("| Behavior |")
+	for _, drv := range drivers {
+		fmt.Fprintf(&report, " %s |", drv.name)
+	}
+	report.WriteString(" Deviation |\n|---|")
+	for range drivers {
+		report.WriteString("---|")
+	}
+	report.WriteString("---|\n")
+
+	cases := append([]compatCase(nil), compatCases...)
+	sort.SliceStable(cases, func(i, j int) bool { return cases[i].category < cases[j].category })
+	for _, c := range cases {
+		name := c.category + "/" + c.name
+		fmt.Fprintf(&report, "| %s |", name)
+		for _, drv := range drivers {
+			result := "fail"
+			if passed[drv.name][name] {
+				result = "pass"
+			}
+			fmt.Fprintf(&report, " %s |", result)
+		}
+		fmt.Fprintf(&report, " %s |\n", c.deviation)
+	}
+	return report.String()
+}
+
+// observe executes the query of |c| on |conn| and describes what the application observes.
+func observe(ctx context.Context, conn *sql.Conn, c compatCase) string {
+	if c.exec {
+		res, err := conn.ExecContext(ctx, c.query, c.args...)
+		if err != nil {
+			return observeError(err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return observeError(err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return observeError(err)
+		}
+		return fmt.Sprintf("affected %d, id %d", affected, id)
+	}
+
+	rows, err := conn.QueryContext(ctx, c.query, c.args...)
+	if err != nil {
+		return observeError(err)
+	}
+	defer rows.Close()
+
+	var sets []string
+	for {
+		set, err := observeResultSet(rows)
+		if err != nil {
+			sets = append(sets, observeError(err))
+			break
+		}
+		sets = append(sets, set)
+		if !rows.NextResultSet() {
+			if err := rows.Err(); err != nil {
+				sets = append(sets, observeError(err))
+			}
+			break
+		}
+	}
+	return strings.Join(sets, " | ")
+}
+
+// observeResultSet describes the column types and rows of the current result set of |rows|.
+func observeResultSet(rows *sql.Rows) (string, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, len(types))
+	for i, typ := range types {
+		names[i] = typ.DatabaseTypeName()
+	}
+
+	var values []string
+	for rows.Next() {
+		row := make([]any, len(types))
+		ptrs := make([]any, len(types))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return "", err
+		}
+		strs := make([]string, len(row))
+		for i, v := range row {
+			strs[i] = observeValue(v)
+		}
+		values = append(values, "("+strings.Join(strs, ", ")+")")
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return "[" + strings.Join(names, " ") + "] " + strings.Join(values, "; "), nil
+}
+
+// observeValue formats |v| independently of the Go type the driver returns it as, since the MySQL driver returns
+// most values of queries without arguments as []byte.
+func observeValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.DateTime)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// observeError describes the error number and SQLSTATE of |err|.
+func observeError(err error) string {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return "error: " + err.Error()
+	}
+	if mysqlErr.SQLState != [5]byte{} {
+		return fmt.Sprintf("error %d (%s)", mysqlErr.Number, mysqlErr.SQLState[:])
+	}
+	return fmt.Sprintf("error %d", mysqlErr.Number)
+}
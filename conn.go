@@ -5,7 +5,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
-	"time"
+	"sync/atomic"
 
 	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
 	gms "github.com/dolthub/go-mysql-server/sql"
@@ -13,21 +13,78 @@ import (
 )
 
 var _ driver.Conn = (*DoltConn)(nil)
+var _ driver.ConnPrepareContext = (*DoltConn)(nil)
+var _ driver.Validator = (*DoltConn)(nil)
+var _ driver.SessionResetter = (*DoltConn)(nil)
 
 // DoltConn is a driver.Conn implementation that represents a connection to a dolt database located on the filesystem
 type DoltConn struct {
-	se         *engine.SqlEngine
-	gmsCtx     *gms.Context
-	DataSource *DoltDataSource
+	se           *engine.SqlEngine
+	gmsCtx       *gms.Context
+	stats        *statsRegistry
+	sessionStats *sessionStats
+	interceptors []Interceptor
+	slowLog      *slowQueryLog
+	DataSource   *DoltDataSource
+
+	// denyWrites rejects statements that could modify data or schema
+	denyWrites bool
+	// diskGuard rejects statements that could modify data or schema while the disk is low on space
+	diskGuard *diskGuard
+	// stmtCache bounds the engine's cache of the statements the connection executed with arguments
+	stmtCache *stmtCache
+	// commitMessages sets the messages of the Dolt commits of the connection's transactions, with
+	// Config.TransactionCommits
+	commitMessages *commitMessages
+
+	// geometryFormat is the format geometry values are returned in
+	geometryFormat GeometryFormat
+
+	// rowLimit limits the number of rows returned by queries
+	rowLimit rowLimit
+
+	// shadow mirrors writes to the shadow server in dual-write mode
+	shadow *shadowConn
+
+	// recorder records statements in recording mode
+	recorder *connRecorder
+
+	// connector is set when this connection owns the connector it was created by, as connections opened with
+	// doltDriver.Open do, and closes it when the connection is closed. Connections of a shared Connector leave it open.
+	connector *Connector
+	// engine is the connector engine |se| belongs to, for connections created by a Connector
+	engine *sharedEngine
+	// refresher replaces the connector's engine when other processes change the databases it opened read-only
+	refresher *rootRefresher
+	// databases opens the databases statements use that the engine doesn't load, with Config.MaxOpenDatabases
+	databases *openDatabases
+	// parallel executes the independent INSERT statements of multi-statement Exec calls concurrently
+	parallel *parallelScript
+	// clock is the connector's clock, which sets the time of the connection's statements
+	clock clock
+
+	// guard recovers the engine's panics in the connection's statements and rows
+	guard *panicGuard
+
+	// sessions is the registry of the connector's connections, in which KILL CONNECTION finds this one
+	sessions *sessionRegistry
+	// killed is set once the connection was killed with KILL CONNECTION
+	killed atomic.Bool
 }
 
 // Prepare packages up |query| as a *doltStmt so it can be executed. If multistatements mode
 // has been enabled, then a *doltMultiStmt will be returned, capable of executing multiple statements.
-func (d *DoltConn) Prepare(query string) (driver.Stmt, error) {
+func (d *DoltConn) Prepare(query string) (_ driver.Stmt, err error) {
+	defer d.guard.recover(&err)
+
+	if d.killed.Load() {
+		return nil, driver.ErrBadConn
+	}
+
 	// Reuse the same ctx instance, but update the QueryTime to the current time.
 	// Statements are executed serially on a connection, so it's safe to reuse
 	// the same ctx instance and update the time.
-	d.gmsCtx.SetQueryTime(time.Now())
+	d.gmsCtx.SetQueryTime(d.clock.now())
 
 	if d.DataSource.ParamIsTrue(MultiStatementsParam) {
 		return d.prepareMultiStatement(query)
@@ -36,18 +93,50 @@ func (d *DoltConn) Prepare(query string) (driver.Stmt, error) {
 	}
 }
 
+// PrepareContext runs |query| through the connection's interceptors, and then prepares the query they return as
+// Prepare does.
+func (d *DoltConn) PrepareContext(ctx context.Context, query string) (_ driver.Stmt, err error) {
+	defer d.guard.recover(&err)
+
+	if len(d.interceptors) > 0 {
+		call := &InterceptedCall{Operation: OperationPrepare, Query: query}
+		if err := intercept(ctx, d.interceptors, call); err != nil {
+			return nil, err
+		}
+		query = call.Query
+	}
+
+	return d.Prepare(query)
+}
+
 // prepareSingleStatement creates a doltStmt from |query|.
 func (d *DoltConn) prepareSingleStatement(query string) (*doltStmt, error) {
 	return &doltStmt{
-		query:  query,
-		se:     d.se,
-		gmsCtx: d.gmsCtx,
+		query:          query,
+		se:             d.se,
+		gmsCtx:         d.gmsCtx,
+		stats:          d.stats,
+		sessionStats:   d.sessionStats,
+		interceptors:   d.interceptors,
+		slowLog:        d.slowLog,
+		denyWrites:     d.denyWrites,
+		diskGuard:      d.diskGuard,
+		stmtCache:      d.stmtCache,
+		commitMessages: d.commitMessages,
+		geometryFormat: d.geometryFormat,
+		rowLimit:       d.rowLimit,
+		shadow:         d.shadow,
+		recorder:       d.recorder,
+		guard:          d.guard,
+		engine:         d.engine,
+		databases:      d.databases,
+		clock:          d.clock,
 	}, nil
 }
 
 // prepareMultiStatement creates a doltStmt from each individual statement in |query|.
 func (d *DoltConn) prepareMultiStatement(query string) (*doltMultiStmt, error) {
-	var doltMultiStmt doltMultiStmt
+	doltMultiStmt := doltMultiStmt{query: query, parallel: d.parallel}
 	scanner := gms.NewMysqlParser()
 
 	remainder := query
@@ -73,11 +162,45 @@ func (d *DoltConn) prepareMultiStatement(query string) (*doltMultiStmt, error) {
 
 // Close releases the resources held by the DoltConn instance
 func (d *DoltConn) Close() error {
-	err := d.se.Close()
-	if err != context.Canceled {
-		return err
+	d.unregister()
+	d.stmtCache.close()
+	d.shadow.Close()
+	d.engine.release()
+
+	if d.connector == nil {
+		return nil
+	}
+
+	return d.connector.Close()
+}
+
+// IsValid returns false once the connector has replaced the connection's engine, the engine has panicked on the
+// connection, or the connection was killed, so that the connection pool discards it rather than reusing it.
+func (d *DoltConn) IsValid() bool {
+	return !d.engine.isRetired() && !d.guard.hasPanicked() && !d.killed.Load()
+}
+
+// ResetSession is called by the connection pool before reusing the connection. It returns driver.ErrBadConn in the
+// same cases as IsValid returns false, so that idle connections are discarded too. It restores the default branch of
+// the databases the connection checked out another branch of, so that checkouts don't leak to the next borrower, and
+// discards the connection if that fails. With Config.RefreshInterval, it first checks whether other processes changed
+// the databases, discarding the connection if they did, so that the next borrower sees their changes.
+func (d *DoltConn) ResetSession(ctx context.Context) (err error) {
+	defer d.guard.recover(&err)
+
+	if err := d.refresher.refresh(ctx); err != nil {
+		// database/sql ignores the other errors, and would keep using the connection on the engine it failed to replace
+		d.refresher.report(err)
+		return driver.ErrBadConn
 	}
 
+	if !d.IsValid() {
+		return driver.ErrBadConn
+	}
+
+	if err := d.restoreBranches(); err != nil {
+		return driver.ErrBadConn
+	}
 	return nil
 }
 
@@ -98,13 +221,26 @@ func (d *DoltConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.T
 		return nil, fmt.Errorf("isolation level not supported '%d'", opts.Isolation)
 	}
 
+	head := contextHead(ctx)
+	if err := checkHead(d.gmsCtx, head); err != nil {
+		return nil, err
+	}
+
 	_, _, _, err := d.se.Query(d.gmsCtx, "BEGIN;")
+	d.shadow.mirror(ctx, "BEGIN", nil, 0, err)
+	d.recorder.recordExec("BEGIN", nil, 0, err)
 	if err != nil {
 		return nil, translateError(err)
 	}
+	if err := d.commitMessages.begin(ctx, d.gmsCtx); err != nil {
+		return nil, err
+	}
 
 	return &doltTx{
-		se:     d.se,
-		gmsCtx: d.gmsCtx,
+		se:       d.se,
+		gmsCtx:   d.gmsCtx,
+		shadow:   d.shadow,
+		recorder: d.recorder,
+		head:     head,
 	}, nil
 }
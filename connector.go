@@ -0,0 +1,729 @@
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	"github.com/dolthub/dolt/go/libraries/utils/config"
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/mysql"
+)
+
+// Config configures a Connector. The fields mirror the parameters accepted in a dolt data source name.
+type Config struct {
+	// Directory is the directory whose subdirectories are dolt databases
+	Directory string
+	// Directories are other directories whose subdirectories are dolt databases, served by the same engine as those of
+	// Directory, e.g. to keep the databases of different tenants on different volumes. Databases created with CREATE
+	// DATABASE are created in Directory. The databases of all the directories must have distinct names.
+	Directories []string
+	// Databases holds settings for some of the databases of the directories, by database name, so that a connector
+	// serving many databases can treat them differently. See DatabaseConfig.
+	Databases map[string]DatabaseConfig
+	// CommitName is the name of the committer seen in the dolt commit log
+	CommitName string
+	// CommitEmail is the email of the committer seen in the dolt commit log
+	CommitEmail string
+	// Database is the initial database each connection uses
+	Database string
+	// MultiStatements allows multiple statements in one query
+	MultiStatements bool
+	// ClientFoundRows returns the number of matching rows instead of the number of changed rows in UPDATE queries
+	ClientFoundRows bool
+	// TransactionCommits makes each transaction of the connections that changes data make a Dolt commit of all its
+	// changes when it commits, as with @@dolt_transaction_commit, including the statements executed with autocommit.
+	// The message of the commit is set with WithCommitMessage or a /* dolt:msg: ... */ comment in one of the
+	// transaction's statements, and defaults to "Transaction commit".
+	TransactionCommits bool
+	// DenyWrites rejects any statement that could modify data or schema, independent of engine read-only mode, and any
+	// statement that doesn't parse with the session's sql_mode
+	DenyWrites bool
+	// MinFreeDisk is the number of bytes of free space below which statements that could modify data or schema fail
+	// with ErrLowDiskSpace, before the storage layer runs out of space in the middle of a write. The file systems of all
+	// the directories are checked before each such statement. Writes aren't checked if it is zero, or on platforms
+	// that can't report free space.
+	MinFreeDisk int64
+	// Version is the dolt version the engine reports itself as. It defaults to 0.40.17.
+	Version string
+	// MaxExecutionTime sets the max_execution_time session variable of connections, which interrupts SELECT
+	// statements running for longer than it with ErrQueryTimeout. Connections can change it with SET, like in MySQL.
+	// SELECT statements aren't limited if it is zero.
+	MaxExecutionTime time.Duration
+	// GeometryFormat is the format geometry values are returned in. It defaults to GeometryMySQL.
+	GeometryFormat GeometryFormat
+	// Collation is the collation of the connections, such as utf8mb4_0900_ai_ci, like the collation parameter of the
+	// MySQL driver. It applies to the literals and arguments of statements, and is the default collation of the
+	// databases they create. Dolt's default collation, utf8mb4_0900_bin, compares strings by their bytes, while
+	// MySQL 8's, utf8mb4_0900_ai_ci, ignores case and accents, so applications moving from MySQL can set it to keep
+	// their comparisons and sort orders. The columns of existing tables keep their collation.
+	Collation string
+	// MaxRows limits the number of rows returned by a query, so that an unexpectedly large result can't be
+	// materialized by the application. Result sets with more rows are truncated, with a warning listed by SHOW
+	// WARNINGS, or fail with ErrMaxRowsExceeded if MaxRowsError is set. Queries aren't limited if it is zero.
+	MaxRows int64
+	// MaxRowsError fails queries returning more than MaxRows rows with ErrMaxRowsExceeded instead of truncating them
+	MaxRowsError bool
+	// EventScheduler is the state of the engine's event scheduler. It defaults to EventSchedulerOff, so the events
+	// created with CREATE EVENT only run on their schedule if it is EventSchedulerOn, in the background while the
+	// connector is open.
+	EventScheduler EventScheduler
+
+	// TempDir is the directory the engine writes scratch files to, such as the sorted runs of building an index over a
+	// large table, instead of the system's temp directory, e.g. to keep them on a volume with more space. The files
+	// the storage layer moves into a database once written are still written to the database's directory. The engine's
+	// temp directory is shared by the whole process, so it also applies to the other connectors of the process: the
+	// last connector opened with TempDir that is still open sets it, and the previous one is restored once they're all
+	// closed.
+	TempDir string
+
+	// EngineFlags sets the global values of engine system variables, such as dolt_show_branch_databases, when the
+	// engine is opened, like the system_variables section of a dolt sql-server configuration. Since the engine's
+	// settings are system variables, new engine settings can be used without a driver release. Unknown variables fail
+	// the open. The engine's global system variables are shared by the whole process, so they also apply to the other
+	// engines it opens.
+	EngineFlags map[string]any
+
+	// Logger receives the messages the engine logs, which otherwise go to stderr, with their fields, such as the
+	// connection id and current database of a session, as attributes. The messages the storage layer logs with
+	// logrus's process-wide standard logger are routed to the loggers of all the open connectors that have one, told
+	// apart from the application's own messages by the function that logged them, which the standard logger still
+	// writes. The levels its handler is enabled for when the connector is created control which messages the engine
+	// logs at all.
+	Logger *slog.Logger
+	// Quiet keeps the driver and the engine from writing to the process's stdout and stderr, for command line tools
+	// that own their terminal's output. The engine's messages logged with logrus's standard logger that aren't routed
+	// to a Logger, the output of Dolt's command line package, such as progress output and warnings, and the driver's own
+	// messages are discarded. The application's own messages logged with logrus's standard logger are still written.
+	// The engine's writers are process-wide, so they are silenced while any connector with Quiet is open.
+	Quiet bool
+
+	// Users enables access control when it isn't empty. Connections authenticate as User with Password, and can only
+	// access the databases and tables granted to that user, as enforced by the engine's privilege system. Without
+	// Users, connections have full access.
+	Users []Account
+	// User is the name of the user connections authenticate as when Users is set
+	User string
+	// Password is the password of User
+	Password string
+
+	// QueryInterceptors are invoked, in order, before each statement is prepared and executed. See Interceptor.
+	QueryInterceptors []Interceptor
+
+	// SlowQueryThreshold is the latency above which statements are reported to OnSlowQuery. Slow queries aren't
+	// reported if it is zero.
+	SlowQueryThreshold time.Duration
+	// OnSlowQuery is called with every statement taking longer than SlowQueryThreshold to execute. It is called
+	// synchronously on the connection that executed the statement, so it should return quickly.
+	OnSlowQuery func(SlowQuery)
+	// ExplainSlowQueries captures the EXPLAIN output of slow queries in SlowQuery.Explain
+	ExplainSlowQueries bool
+	// SlowQueryExplainInterval is the minimum time between two EXPLAIN captures, bounding their cost when many
+	// queries are slow. It defaults to one minute.
+	SlowQueryExplainInterval time.Duration
+
+	// ShadowDSN enables dual-write mode when it isn't empty. Every statement that could modify data or session state
+	// is mirrored, after it executes in Dolt, to the MySQL server with this go-sql-driver/mysql data source name, and
+	// the errors and affected row counts of the two are compared. This is a migration safety tool: the shadow server
+	// never changes the outcome of a statement, and it adds its latency to every write.
+	ShadowDSN string
+	// OnShadowDivergence is called with every mirrored statement whose outcome differed between Dolt and the shadow
+	// server. Divergences are logged with the standard logger if it is nil, unless Quiet is set.
+	OnShadowDivergence func(ShadowDivergence)
+
+	// RecordTo enables recording mode when it isn't nil. Every statement executed by the connector's connections is
+	// written to it with its arguments and a digest of its result, so that it can be re-executed against a fresh
+	// database with Replay. Writes to it are serialized by the driver.
+	RecordTo io.Writer
+
+	// WatchInterval is the interval at which Watch polls for changes. It defaults to one second.
+	WatchInterval time.Duration
+	// RefreshInterval is the minimum interval between two checks for commits other processes, such as the dolt CLI,
+	// made to the databases the connector opened read-only, because those processes held their locks. When connections
+	// are returned to the pool, the connector checks the databases' files if RefreshInterval has passed since the last
+	// check, and opens a new engine if they changed, whose connections see the new commits. Connections using the
+	// previous engine are replaced by the pool, like after RefreshDatabases. Databases aren't checked if it is zero.
+	RefreshInterval time.Duration
+	// MaxOpenDatabases limits the number of databases the engine loads, bounding the file descriptors and memory used
+	// by a connector serving many databases. The databases used least recently are closed when a statement uses a
+	// database that isn't loaded, which is then opened by a new engine; see openDatabases. All the databases are
+	// loaded if it is zero.
+	MaxOpenDatabases int64
+	// MaxQueryMemory is the memory, in bytes, above which statements buffering rows in memory, such as joins, DISTINCT
+	// and GROUP BY, fail with the engine's "no memory available" error once freeing cached rows didn't bring the memory
+	// back under it. The engine measures the heap and stack memory of the whole process, application included. It
+	// defaults to the MAX_MEMORY environment variable, in megabytes, and statements aren't limited if neither is set.
+	MaxQueryMemory int64
+	// StatementCacheSize is the number of statements executed with arguments whose parsed form each connection caches,
+	// so that executing them again doesn't parse them again. The least recently used statement is evicted once there
+	// are more, so that applications building queries with literals don't grow the cache without limit. It defaults to
+	// 256. Connector.StatementCacheStats returns the caches' counters.
+	StatementCacheSize int64
+	// ParallelScript is the number of sessions with which a connection executes the statements of a multi-statement
+	// Exec concurrently, when they are all INSERT statements with literal VALUES, such as fixture scripts. Statements
+	// inserting into the same table, or into tables related by foreign keys, still execute in order. If a statement
+	// fails, statements inserting into other tables after it may have executed. Statements execute in order if it is
+	// 0 or 1, in a transaction, with arguments, or when the database has triggers.
+	ParallelScript int64
+	// Clone is the URL of a remote database, such as a DoltHub database like dolthub/us-jails or a file:// URL, that
+	// NewConnector clones into Directory the first time it opens it, so that an application can embed a dataset with a
+	// data source name. Later, NewConnector fetches the remote and fast-forwards the current branch of the clone to the
+	// remote's, and fails with ErrCloneDiverged if the clone has commits the remote doesn't. The clone is named after
+	// Database, or else after the last element of the URL, and is the initial database of connections.
+	Clone string
+	// Standby is the URL of a remote that the commits made to each database are pushed to as they are made, such as the
+	// remotesapi of a running dolt sql-server, which serves a hot standby that other services can read. Each database
+	// is pushed to the URL followed by its name, e.g. http://standby:50051/mydb. Only the databases open when the engine
+	// opens are replicated, and a database whose standby can't be reached then isn't, with an error reported to
+	// OnStandbyError.
+	Standby string
+	// StandbyAsync pushes commits to Standby in the background, instead of before the statement committing returns
+	StandbyAsync bool
+	// OnStandbyError is called with the errors pushing commits to Standby, since they don't fail the commits. It can be
+	// called from background goroutines.
+	OnStandbyError func(*StandbyError)
+	// SyncInterval is the interval at which the connector syncs the databases that have the remote SyncRemote with it in
+	// the background, so that edge deployments converge with a central remote. Databases aren't synced in the
+	// background if it is zero. See Connector.SyncStatus.
+	SyncInterval time.Duration
+	// SyncRemote is the name of the remote databases are synced with. It defaults to origin.
+	SyncRemote string
+	// SyncMode is the direction of syncs: SyncPull, SyncPush or SyncBoth. It defaults to SyncPull. Syncs pull and push
+	// the current branch of new connections.
+	SyncMode SyncMode
+	// SyncUpstream is the branch of the remote that databases are synced with. It defaults to the local branch. For
+	// devices that are only connected intermittently, connections can commit to a device branch, set with
+	// DatabaseConfig.Branch, which syncs push to a branch of the same name, then merge with SyncUpstream and push to it,
+	// so that the commits made offline queue up locally and converge with the remote once it can be reached.
+	SyncUpstream string
+	// SyncConflicts is the policy for the conflicts a pull runs into. It defaults to SyncConflictsFail.
+	SyncConflicts SyncConflicts
+	// ResolveSyncConflict, if set, chooses the policy for the conflicts in each table a pull runs into, in place of
+	// SyncConflicts. It is called from the goroutine syncing.
+	ResolveSyncConflict func(SyncConflict) SyncConflicts
+	// OnSync is called with the status of each database after it is synced. It is called from a background goroutine.
+	OnSync func(SyncStatus)
+	// Now returns the current time, in place of time.Now, for the connector's clock. The clock sets the time of each
+	// statement, returned by NOW() and used as the date of the commits made with DOLT_COMMIT, and measures statement
+	// latencies, Config.RefreshInterval and the age of commits reported by Health. Tests can set it to make those
+	// deterministic rather than sleeping. Dolt stamps commits with its own committer date, which it doesn't take.
+	Now func() time.Time
+
+	// OnOpenProgress is called with the progress of opening the engine, which can take a while for large databases:
+	// once the databases in the directory have been discovered, as each starts loading, once they are all loaded, and
+	// once the engine is open. It is called synchronously by NewConnector, and by RefreshDatabases when it opens a new
+	// engine.
+	OnOpenProgress func(OpenProgress)
+
+	// BreakStaleLocks records the process as the owner of the database locks it holds, and breaks the locks still held
+	// on behalf of a process that is gone when the engine opens, rather than opening those databases read only. Dolt
+	// locks a database with an OS file lock, which is released when the process exits, unless the process started
+	// another process that inherited the lock file and outlived it. A lock is only broken if the OS reports that the
+	// process that took it is no longer running, and that process recorded itself as its owner, which is only known on
+	// Linux. Locks taken by processes that don't set BreakStaleLocks, such as a dolt sql-server, and the locks of other
+	// hosts sharing the directory, are never broken.
+	BreakStaleLocks bool
+	// OnStaleLock is called with each stale lock broken when the engine opens.
+	OnStaleLock func(StaleLock)
+
+	// Cooperative lets several processes use Directory at once. The first connector to open the directory becomes its
+	// owner: it opens the engine and serves it to the other processes over a unix socket in the directory. Connectors
+	// opened while another process owns the directory don't open the engine, and proxy their connections' queries to
+	// the owner instead. Proxied connections are go-sql-driver/mysql connections, so the features of the driver's own
+	// connections, such as statistics and interceptors, don't apply to them, and the guards DenyWrites, MaxRows,
+	// TransactionCommits and MinFreeDisk can't be set with it. When the owner exits, one of the proxying connectors
+	// takes over the directory, and the others proxy to it.
+	Cooperative bool
+	// OnOwnershipChange is called when a connector in cooperative mode takes over its directory from a process that
+	// exited, or fails to. It is called from a background goroutine.
+	OnOwnershipChange func(OwnershipChange)
+}
+
+// ParseDSN parses |dsn| into a Config. See doltDriver.Open for the format of the data source name.
+func ParseDSN(dsn string) (Config, error) {
+	ds, err := ParseDataSource(dsn)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Config{Directory: ds.Directory}
+	for _, p := range params {
+		values := ds.Params[p.Name]
+		if p.Required && (len(values) == 0 || values[0] == "") {
+			// An empty value, as in ?commitname&..., doesn't set a required parameter either
+			return Config{}, fmt.Errorf("datasource '%s' must include the parameter '%s'", dsn, p.Name)
+		} else if len(values) == 0 {
+			continue
+		}
+		if p.Type != ParamList {
+			values = values[:1]
+		}
+		for _, value := range values {
+			if err := p.set(&cfg, value); err != nil {
+				return Config{}, fmt.Errorf("invalid value for parameter '%s': %w", p.Name, err)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// dataSource returns a DoltDataSource equivalent to this Config.
+func (cfg Config) dataSource() *DoltDataSource {
+	return &DoltDataSource{
+		Directory: cfg.Directory,
+		Params:    cfg.paramValues(false),
+	}
+}
+
+// FormatDSN returns the data source name of the configuration, the inverse of ParseDSN. Like ParseDSN, it only covers
+// the fields that have a data source name parameter: ParseDSN(cfg.FormatDSN()) returns cfg without its callbacks,
+// writers, Users, Databases and other fields that can't be written in a data source name. Values are percent-encoded,
+// so they can contain any character. The data source name is canonical: parameters are in the order of Params(), and
+// parameters left to their zero value are omitted, so equivalent configurations have the same data source name.
+func (cfg Config) FormatDSN() string {
+	values := cfg.paramValues(true)
+	var query []string
+	for _, p := range params {
+		for _, v := range values[p.Name] {
+			query = append(query, url.QueryEscape(p.Name)+"="+url.QueryEscape(v))
+		}
+	}
+
+	dsn := fileUrlPrefix + escapeDirectory(cfg.Directory)
+	if len(query) > 0 {
+		dsn += "?" + strings.Join(query, "&")
+	}
+	return dsn
+}
+
+// paramValues returns the values of the parameters set in the configuration, including secret parameters if
+// |secrets| is true.
+func (cfg Config) paramValues(secrets bool) map[string][]string {
+	values := make(map[string][]string)
+	for _, p := range params {
+		if p.Secret && !secrets {
+			continue
+		} else if p.list != nil {
+			if list := p.list(&cfg); len(list) > 0 {
+				values[p.Name] = append([]string(nil), list...)
+			}
+		} else if v := p.get(&cfg); v != "" {
+			values[p.Name] = []string{v}
+		}
+	}
+	return values
+}
+
+var _ driver.Connector = (*Connector)(nil)
+
+// Connector is a driver.Connector which opens a single dolt engine and shares it between all the connections it
+// creates. Use it with sql.OpenDB:
+//
+//	connector, err := embedded.NewConnector(cfg)
+//	db := sql.OpenDB(connector)
+//
+// Closing the sql.DB closes the connector and its engine.
+type Connector struct {
+	cfg      Config
+	ds       *DoltDataSource
+	stats    *statsRegistry
+	shadow   *shadowDB
+	recorder *recorder
+	sessions *sessionRegistry
+	// memory is the memory manager of the sessions, which limits their in-memory caches to Config.MaxQueryMemory
+	memory *gms.MemoryManager
+
+	// mu guards engine, which RefreshDatabases replaces
+	mu     sync.Mutex
+	engine *sharedEngine
+	// refreshMu serializes calls to RefreshDatabases and refresher
+	refreshMu sync.Mutex
+	// refresher replaces the engine when other processes change the databases it opened read-only
+	refresher *rootRefresher
+	// databases tracks the databases the engine loads with Config.MaxOpenDatabases
+	databases *openDatabases
+	// tenantMu serializes calls to EnsureTenantDB
+	tenantMu sync.Mutex
+	// diskGuard rejects writes while the disk is low on space
+	diskGuard *diskGuard
+	// collation is the collation of Config.Collation, set on each session
+	collation gms.CollationID
+	// stmtCacheCounters are the counters of the statement caches of the connector's connections
+	stmtCacheCounters stmtCacheCounters
+	// syncer syncs the databases with their remote, or is nil for a proxy in cooperative mode
+	syncer *syncer
+	// locks renews the records of the database locks the connector holds with Config.BreakStaleLocks, or is nil
+	locks *lockRenewer
+	// log routes the engine's messages to Config.Logger, or is nil
+	log *engineLog
+	// quiet silences the engine's output with Config.Quiet, or is nil
+	quiet *quietOutput
+	// tempDir sets the engine's temp directory to Config.TempDir while the connector is open, or is nil
+	tempDir *tempDir
+
+	// owner is set when the connector owns its directory in cooperative mode
+	owner *owner
+	// proxy is set instead of engine when another process owns the directory in cooperative mode
+	proxy driver.Connector
+	// election waits to take over the directory when another process owns it in cooperative mode
+	election *election
+}
+
+// NewConnector opens the dolt engine for the databases in |cfg.Directory| and |cfg.Directories| and returns a Connector
+// for it.
+func NewConnector(cfg Config) (*Connector, error) {
+	ctx := context.Background()
+	if err := validateDirectories(cfg.directories()); err != nil {
+		return nil, err
+	}
+
+	fs, err := filesys.LocalFS.WithWorkingDir(cfg.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.CommitName == "" {
+		return nil, fmt.Errorf("config must include a commit name")
+	} else if cfg.CommitEmail == "" {
+		return nil, fmt.Errorf("config must include a commit email")
+	}
+
+	if err := breakStaleLocks(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.GeometryFormat.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.EventScheduler.validate(); err != nil {
+		return nil, err
+	}
+
+	collation, err := cfg.collation()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.SyncMode.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.SyncConflicts.validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.SyncInterval > 0 && cfg.Cooperative {
+		return nil, fmt.Errorf("config can't sync databases in the background in cooperative mode")
+	}
+
+	// Proxied connections are plain MySQL connections, so the guards of the driver's own connections wouldn't apply
+	if cfg.Cooperative && (cfg.DenyWrites || cfg.MaxRows > 0 || cfg.TransactionCommits || cfg.MinFreeDisk > 0) {
+		return nil, fmt.Errorf("config can't set DenyWrites, MaxRows, TransactionCommits or MinFreeDisk in cooperative mode")
+	}
+
+	if cfg.Clone != "" {
+		if cfg.Cooperative {
+			return nil, fmt.Errorf("config can't clone a database in cooperative mode")
+		}
+		cfg.Database = cfg.cloneDatabase()
+	}
+
+	if len(cfg.Users) > 0 {
+		if _, err := authenticate(cfg.Users, cfg.User, cfg.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkTempDir(cfg.TempDir); err != nil {
+		return nil, err
+	}
+
+	shadow, err := openShadowDB(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Connector{
+		cfg:       cfg,
+		ds:        cfg.dataSource(),
+		stats:     newStatsRegistry(),
+		shadow:    shadow,
+		recorder:  newRecorder(cfg.RecordTo),
+		sessions:  newSessionRegistry(),
+		memory:    newMemoryManager(cfg),
+		diskGuard: newDiskGuard(cfg),
+		collation: collation,
+		log:       newEngineLog(cfg),
+		quiet:     newQuietOutput(cfg),
+	}
+
+	if c.databases, err = newOpenDatabases(c); err != nil {
+		shadow.Close()
+		c.log.close()
+		c.quiet.close()
+		return nil, err
+	}
+
+	if cfg.Cooperative {
+		err = c.openCooperative(ctx)
+	} else {
+		var engineCfg Config
+		if engineCfg, err = c.engineConfig(); err == nil {
+			if c.engine, err = openSharedEngine(ctx, fs, engineCfg, c.stats); err == nil {
+				if c.refresher, err = newRootRefresher(ctx, c); err != nil {
+					c.engine.close()
+				}
+			}
+		}
+	}
+	if err != nil {
+		shadow.Close()
+		c.log.close()
+		c.quiet.close()
+		return nil, err
+	}
+	c.tempDir = useTempDir(cfg.TempDir)
+
+	if err := c.syncClone(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if c.proxied() == nil {
+		c.syncer = newSyncer(c)
+	}
+	c.locks = newLockRenewer(c)
+
+	return c, nil
+}
+
+// openEngine opens an engine for the databases in |fs|, configured by |cfg|.
+func openEngine(ctx context.Context, fs filesys.Filesys, cfg Config) (*engine.SqlEngine, error) {
+	doltCfg := config.NewMapConfig(map[string]string{
+		config.UserNameKey:  cfg.CommitName,
+		config.UserEmailKey: cfg.CommitEmail,
+	})
+
+	progress, err := newOpenProgress(cfg.directories(), cfg.Databases, cfg.OnOpenProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	version := cfg.Version
+	if version == "" {
+		version = defaultDoltVersion
+	}
+
+	mrEnv, err := loadMultiEnv(ctx, doltCfg, fs, cfg.Directory, cfg.Directories, cfg.Databases, version, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	seCfg := &engine.SqlEngineConfig{
+		IsReadOnly:      false,
+		ServerUser:      "root",
+		Autocommit:      true,
+		SystemVariables: engine.SystemVariables(cfg.EngineFlags),
+
+		EventSchedulerStatus: cfg.EventScheduler.status(),
+	}
+
+	se, err := engine.NewSqlEngine(ctx, mrEnv, seCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = applyDatabaseConfigs(ctx, se, cfg.Databases); err != nil {
+		se.Close()
+		return nil, err
+	}
+
+	if err = addStandbyHooks(ctx, se, mrEnv, cfg); err != nil {
+		se.Close()
+		return nil, err
+	}
+
+	if len(cfg.Users) > 0 {
+		if err = enableAccessControl(se.GetUnderlyingEngine().Analyzer.Catalog.MySQLDb, cfg.Users); err != nil {
+			se.Close()
+			return nil, err
+		}
+	}
+	progress.done()
+
+	return se, nil
+}
+
+// Connect returns a new connection with its own session on the connector's engine.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if proxy := c.proxied(); proxy != nil {
+		return proxy.Connect(ctx)
+	}
+
+	eng, gmsCtx, err := c.newSession()
+	if err != nil {
+		return nil, err
+	}
+
+	commitMessages, err := newCommitMessages(c.cfg, gmsCtx)
+	if err != nil {
+		eng.release()
+		return nil, err
+	}
+
+	c.sessions.register(eng, gmsCtx)
+	conn := &DoltConn{
+		DataSource:     c.ds,
+		se:             eng.se,
+		engine:         eng,
+		gmsCtx:         gmsCtx,
+		stats:          c.stats,
+		sessionStats:   &sessionStats{},
+		interceptors:   c.cfg.QueryInterceptors,
+		slowLog:        eng.slowLog,
+		refresher:      c.refresher,
+		databases:      c.databases,
+		parallel:       newParallelScript(c, eng),
+		clock:          c.cfg.now,
+		denyWrites:     c.cfg.DenyWrites,
+		diskGuard:      c.diskGuard,
+		stmtCache:      newStmtCache(eng.se, gmsCtx.Session.ID(), c.cfg, &c.stmtCacheCounters),
+		commitMessages: commitMessages,
+		geometryFormat: c.cfg.GeometryFormat,
+		rowLimit:       rowLimit{max: c.cfg.MaxRows, error: c.cfg.MaxRowsError},
+		shadow:         c.shadow.newConn(),
+		recorder:       c.recorder.newConn(),
+		guard:          &panicGuard{},
+		sessions:       c.sessions,
+	}
+	c.sessions.add(conn)
+
+	return conn, nil
+}
+
+// newSession creates a session on the connector's current engine, configured like the sessions of its connections. The
+// engine is acquired for the session, and must be released when the session is no longer used.
+func (c *Connector) newSession() (*sharedEngine, *gms.Context, error) {
+	c.mu.Lock()
+	if c.proxy != nil {
+		c.mu.Unlock()
+		return nil, nil, ErrProxied
+	}
+	eng := c.engine
+	eng.acquire()
+	c.mu.Unlock()
+
+	gmsCtx, err := c.newEngineSession(eng)
+	if err != nil {
+		eng.release()
+		return nil, nil, err
+	}
+	return eng, gmsCtx, nil
+}
+
+// newEngineSession creates a session on |eng|, configured like the sessions of the connector's connections. The caller
+// must have acquired the engine.
+func (c *Connector) newEngineSession(eng *sharedEngine) (*gms.Context, error) {
+	// The session context outlives the context passed to Connect, so it must not be derived from it.
+	gmsCtx, err := eng.se.NewLocalContext(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	gmsCtx.ApplyOpts(gms.WithMemoryManager(c.memory))
+	if logger := c.log.newSessionLogger(gmsCtx.Session.ID()); logger != nil {
+		gmsCtx.Session.SetLogger(logger)
+	}
+	gmsCtx.SetQueryTime(c.cfg.now())
+	if c.cfg.Database != "" {
+		gmsCtx.SetCurrentDatabase(c.cfg.Database)
+	}
+	if err := setCollation(gmsCtx, c.collation); err != nil {
+		return nil, err
+	}
+	if c.cfg.MaxExecutionTime > 0 {
+		if err = gmsCtx.SetSessionVariable(gmsCtx, maxExecutionTimeVar, c.cfg.MaxExecutionTime.Milliseconds()); err != nil {
+			return nil, err
+		}
+	}
+
+	client := gmsCtx.Client()
+	if len(c.cfg.Users) > 0 {
+		client.User = c.cfg.User
+		client.Address = accessControlHost
+	}
+	if c.cfg.ClientFoundRows {
+		client.Capabilities |= mysql.CapabilityClientFoundRows
+	}
+	gmsCtx.SetClient(client)
+
+	return gmsCtx, nil
+}
+
+// newDatabaseSession is like newSession, for a session using |database|, which is first opened if the engine doesn't
+// load it because of Config.MaxOpenDatabases.
+func (c *Connector) newDatabaseSession(ctx context.Context, database string) (*sharedEngine, *gms.Context, error) {
+	if _, err := c.databases.open(ctx, database); err != nil {
+		return nil, nil, err
+	}
+	return c.newSession()
+}
+
+// Driver returns the dolt driver.
+func (c *Connector) Driver() driver.Driver {
+	return &doltDriver{}
+}
+
+// Stats returns a snapshot of the statement statistics recorded for all connections created by this connector,
+// ordered by total latency, highest first. Its connections can also query them in the StatementsTableName table of
+// information_schema.
+func (c *Connector) Stats() []StatementStats {
+	return c.stats.snapshot()
+}
+
+// ResetStats discards all statement statistics recorded so far.
+func (c *Connector) ResetStats() {
+	c.stats.reset()
+}
+
+// Close closes the engine shared by the connector's connections, and the shadow server's connections in dual-write
+// mode. sql.DB calls Close when it is closed.
+func (c *Connector) Close() error {
+	return c.CloseContext(context.Background())
+}
+
+// CloseContext is like Close, but stops waiting for the engine to close when |ctx| is done, returning a
+// *CloseTimeoutError that shows what closing was blocked on, such as flushing the journal. The engine keeps closing in
+// the background, so the database files may still be locked.
+func (c *Connector) CloseContext(ctx context.Context) error {
+	c.syncer.close()
+	c.locks.close()
+	c.election.close()
+	c.owner.close()
+	c.shadow.Close()
+	defer c.log.close()
+	defer c.quiet.close()
+	defer c.tempDir.close()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.proxy != nil {
+		return nil
+	}
+	if err := closeEngine(ctx, c.engine.se); err != nil {
+		return err
+	}
+	releaseStores(c.engine.stores)
+
+	// Another process can only take over the directory once the engine has released its files
+	c.owner.release()
+	return nil
+}
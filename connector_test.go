@@ -0,0 +1,233 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDSN(t *testing.T) {
+	cfg, err := ParseDSN("file:///Users/brian/datasets/test?commitname=Billy%20Batson&commitemail=shazam@gmail.com&database=hostedapidb&multiStatements=true")
+	require.NoError(t, err)
+	require.Equal(t, Config{
+		Directory:       "/Users/brian/datasets/test",
+		CommitName:      "Billy Batson",
+		CommitEmail:     "shazam@gmail.com",
+		Database:        "hostedapidb",
+		MultiStatements: true,
+	}, cfg)
+
+	_, err = ParseDSN("file:///Users/brian/datasets/test?commitname=Billy%20Batson")
+	require.Error(t, err)
+}
+
+func TestConnectorSharesEngine(t *testing.T) {
+	_, db, cleanupFunc := initializeTestConnector(t)
+	defer cleanupFunc()
+
+	ctx := context.Background()
+	conn1, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn1.Close()
+	conn2, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn2.Close()
+
+	_, err = conn1.ExecContext(ctx, "create table t (id int primary key)")
+	require.NoError(t, err)
+	_, err = conn1.ExecContext(ctx, "insert into t values (1), (2)")
+	require.NoError(t, err)
+
+	requireResults(t, conn2, "select count(*) from t", [][]any{{2}})
+}
+
+func TestConnectorStats(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err = db.ExecContext(ctx, "create database testdb")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "create table testdb.t (id int primary key)")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = db.ExecContext(ctx, "insert into testdb.t values (?)", i)
+		require.NoError(t, err)
+	}
+	_, err = db.ExecContext(ctx, "insert into testdb.t values (1)")
+	require.Error(t, err)
+
+	var insertStats *StatementStats
+	stats := connector.Stats()
+	for i := range stats {
+		if stats[i].Digest == "insert into testdb.t values (:v1)" {
+			insertStats = &stats[i]
+		}
+	}
+	require.NotNil(t, insertStats)
+	require.Equal(t, int64(4), insertStats.Count)
+	require.Equal(t, int64(1), insertStats.Errors)
+	require.True(t, insertStats.MaxLatency > 0)
+	require.True(t, insertStats.MeanLatency() <= insertStats.MaxLatency)
+
+	// The stats are also in the information_schema table, with latencies in picoseconds
+	var count, errors, maxLatency uint64
+	require.NoError(t, db.QueryRowContext(ctx, "select count_star, sum_errors, max_timer_wait from information_schema."+
+		StatementsTableName+" where digest_text = 'insert into testdb.t values (:v1)'").Scan(&count, &errors, &maxLatency))
+	require.Equal(t, uint64(4), count)
+	require.Equal(t, uint64(1), errors)
+	require.Equal(t, uint64(insertStats.MaxLatency.Nanoseconds())*1000, maxLatency)
+	var tables int
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from information_schema.tables where table_name = ?",
+		StatementsTableName).Scan(&tables))
+	require.Equal(t, 1, tables)
+
+	connector.ResetStats()
+	require.Empty(t, connector.Stats())
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	require.Equal(t, "select * from t where id = :v1", normalizeQuery("SELECT * FROM t WHERE id = 42;"))
+	require.Equal(t, "select * from t where id = :v1", normalizeQuery("/* tag */ select * from t where id = ?"))
+	require.Equal(t, "not valid sql", normalizeQuery("  not valid sql;  "))
+}
+
+func TestStatsRegistryConcurrentRecords(t *testing.T) {
+	r := newStatsRegistry()
+	var wg sync.WaitGroup
+	for g := 1; g <= 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				r.record(fmt.Sprintf("select %d", i), "", "", time.Duration(g)*time.Millisecond, nil)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	stats := r.snapshot()
+	require.Len(t, stats, 1)
+	require.Equal(t, "select :v1", stats[0].Digest)
+	require.Equal(t, int64(8000), stats[0].Count)
+	require.Equal(t, 8*time.Millisecond, stats[0].MaxLatency)
+	require.Equal(t, 36*1000*time.Millisecond, stats[0].TotalLatency)
+}
+
+// initializeTestConnector creates a Connector on a new temporary directory containing a database called testdb, and
+// returns it with a sql.DB using it. The returned cleanup function closes the sql.DB and removes the directory.
+func initializeTestConnector(t testing.TB) (connector *Connector, db *sql.DB, cleanUpFunc func()) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+
+	connector, err = NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		Database:    "testdb",
+	})
+	require.NoError(t, err)
+
+	db = sql.OpenDB(connector)
+	cleanUpFunc = func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+
+	_, err = db.ExecContext(context.Background(), "create database testdb")
+	require.NoError(t, err)
+
+	return connector, db, cleanUpFunc
+}
+
+func TestNewConnectorContext(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewConnectorContext(ctx, cfg)
+	require.ErrorIs(t, err, context.Canceled)
+
+	db, err := OpenWithSignals(context.Background(), cfg)
+	require.NoError(t, err)
+	require.NoError(t, db.PingContext(context.Background()))
+	require.NoError(t, db.Close())
+}
+
+func TestEngineFlags(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		Version:     "1.2.3",
+		EngineFlags: map[string]any{"max_connections": 200},
+	}
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	var value int
+	require.NoError(t, db.QueryRow("select @@global.max_connections").Scan(&value))
+	require.Equal(t, 200, value)
+
+	cfg.EngineFlags = map[string]any{"not_a_variable": 1}
+	_, err = NewConnector(cfg)
+	require.Error(t, err)
+}
+
+func TestOpenUsesConnector(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dsn := "file://" + dir + "?commitname=Billy%20Batson&commitemail=shazam@gmail.com&denywrites=true"
+	cfg, err := ParseDSN(dsn)
+	require.NoError(t, err)
+
+	db, err := sql.Open(DoltDriverName, dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	require.NoError(t, conn.Raw(func(driverConn any) error {
+		doltConn := driverConn.(*DoltConn)
+		require.NotNil(t, doltConn.connector)
+		require.Equal(t, cfg, doltConn.connector.cfg)
+		return nil
+	}))
+
+	_, err = conn.ExecContext(ctx, "create database testdb")
+	require.ErrorIs(t, err, ErrWritesDenied)
+	require.NoError(t, conn.Close())
+}
@@ -0,0 +1,20 @@
+package embedded
+
+import "context"
+
+type databaseKey struct{}
+
+// WithDatabase returns a copy of |ctx| with which statements run against |database| as their current database, as if
+// it had been selected with USE, without changing the connection's session. The current database is switched for the
+// duration of each statement and restored after it, so a pool's connections can serve the databases of several
+// tenants concurrently. WithBranch, AsOf and AsOfTime apply to |database| in place of the connection's current
+// database.
+func WithDatabase(ctx context.Context, database string) context.Context {
+	return context.WithValue(ctx, databaseKey{}, database)
+}
+
+// ContextDatabase returns the database set in |ctx| with WithDatabase, or the empty string.
+func ContextDatabase(ctx context.Context) string {
+	database, _ := ctx.Value(databaseKey{}).(string)
+	return database
+}
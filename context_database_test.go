@@ -0,0 +1,86 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDatabase(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com", Database: "tenant_a"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	for _, query := range []string{
+		"create database tenant_a",
+		"create database tenant_b",
+		"create table tenant_a.t (v varchar(10) primary key)",
+		"create table tenant_b.t (v varchar(10) primary key)",
+		"insert into tenant_a.t values ('a')",
+		"insert into tenant_b.t values ('b')",
+	} {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	tenantB := WithDatabase(ctx, "tenant_b")
+	require.Equal(t, "tenant_b", ContextDatabase(tenantB))
+	require.Empty(t, ContextDatabase(ctx))
+
+	// The connection's current database is restored after each statement
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	var v string
+	require.NoError(t, conn.QueryRowContext(tenantB, "select v from t").Scan(&v))
+	require.Equal(t, "b", v)
+	require.NoError(t, conn.QueryRowContext(ctx, "select v from t").Scan(&v))
+	require.Equal(t, "a", v)
+	_, err = conn.ExecContext(tenantB, "insert into t values ('b2')")
+	require.NoError(t, err)
+	var current string
+	require.NoError(t, conn.QueryRowContext(ctx, "select database()").Scan(&current))
+	require.Equal(t, "tenant_a", current)
+	require.NoError(t, conn.Close())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			queryCtx, want := ctx, 1
+			if i%2 == 0 {
+				queryCtx, want = tenantB, 2
+			}
+			var count int
+			require.NoError(t, db.QueryRowContext(queryCtx, "select count(*) from t").Scan(&count))
+			require.Equal(t, want, count)
+		}(i)
+	}
+	wg.Wait()
+
+	// Branches apply to the context's database
+	_, err = db.ExecContext(tenantB, "call dolt_commit('-Am', 'b')")
+	require.NoError(t, err)
+	_, err = db.ExecContext(tenantB, "call dolt_branch('feature')")
+	require.NoError(t, err)
+	_, err = db.ExecContext(WithBranch(tenantB, "feature"), "insert into t values ('feature')")
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, db.QueryRowContext(WithBranch(tenantB, "feature"), "select count(*) from t").Scan(&count))
+	require.Equal(t, 3, count)
+	require.NoError(t, db.QueryRowContext(tenantB, "select count(*) from t").Scan(&count))
+	require.Equal(t, 2, count)
+
+	err = db.QueryRowContext(WithDatabase(ctx, "nosuchdb"), "select v from t").Scan(&v)
+	require.ErrorContains(t, err, "database not found")
+}
@@ -0,0 +1,213 @@
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofrs/flock"
+)
+
+const (
+	// ownerLockFile is the file locked by the process owning a directory in cooperative mode
+	ownerLockFile = ".dolt_driver.lock"
+	// ownerSocketFile is the unix socket the owner of a directory serves its engine on in cooperative mode
+	ownerSocketFile = ".dolt_driver.sock"
+	// electionInterval is the interval at which proxying connectors try to take over their directory
+	electionInterval = 100 * time.Millisecond
+)
+
+// ErrProxied is returned by the Connector methods that need direct access to the engine, such as TableStats and
+// Watch, when the connector proxies its queries to the process owning its directory in cooperative mode.
+var ErrProxied = errors.New("the connector proxies queries to the process owning its directory")
+
+// OwnershipChange is sent to Config.OnOwnershipChange when a connector in cooperative mode takes over its directory.
+type OwnershipChange struct {
+	// Directory is the connector's directory
+	Directory string
+	// Owner is true once the connector owns the directory, serving its engine to the other processes
+	Owner bool
+	// Err is set if the connector acquired the directory's lock but failed to open the engine. It keeps proxying, and
+	// tries again later.
+	Err error
+}
+
+// owner is the state of a connector owning its directory in cooperative mode.
+type owner struct {
+	lock *flock.Flock
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// election is the state of a proxying connector waiting to take over its directory.
+type election struct {
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// openCooperative makes the connector the owner of its directory, or a proxy to its owner if another process owns
+// it.
+func (c *Connector) openCooperative(ctx context.Context) error {
+	lock := flock.New(filepath.Join(c.cfg.Directory, ownerLockFile))
+	if ok, err := lock.TryLock(); err != nil {
+		return err
+	} else if ok {
+		return c.becomeOwner(ctx, lock)
+	}
+
+	proxy, err := newProxyConnector(c.cfg)
+	if err != nil {
+		lock.Close()
+		return err
+	}
+	c.proxy = proxy
+	c.startElection(lock)
+	return nil
+}
+
+// becomeOwner opens the engine of the directory that |lock| grants the connector, and serves it to the other processes
+// using the directory on the owner socket. A socket left behind by a previous owner is replaced.
+func (c *Connector) becomeOwner(ctx context.Context, lock *flock.Flock) error {
+	fs, err := filesys.LocalFS.WithWorkingDir(c.cfg.Directory)
+	if err != nil {
+		lock.Unlock()
+		return err
+	}
+
+	cfg, err := c.engineConfig()
+	if err != nil {
+		lock.Unlock()
+		return err
+	}
+	eng, err := openSharedEngine(ctx, fs, cfg, c.stats)
+	if err != nil {
+		lock.Unlock()
+		return err
+	}
+
+	path := filepath.Join(c.cfg.Directory, ownerSocketFile)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		eng.close()
+		lock.Unlock()
+		return err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		eng.close()
+		lock.Unlock()
+		return err
+	}
+
+	serveCtx, stop := context.WithCancel(context.Background())
+	c.owner = &owner{lock: lock, stop: stop, done: make(chan struct{})}
+
+	c.mu.Lock()
+	c.engine = eng
+	c.proxy = nil
+	c.mu.Unlock()
+
+	go func() {
+		defer close(c.owner.done)
+		if err := c.serveMySQL(serveCtx, l); err != nil {
+			c.cfg.printf("serving %s: %v", path, err)
+		}
+	}()
+
+	return nil
+}
+
+// startElection waits in the background for the owner of the directory to release |lock|, and takes over the
+// directory when it does. Connections to the previous owner fail, and are replaced by connections to the connector's
+// own engine.
+func (c *Connector) startElection(lock *flock.Flock) {
+	ctx, stop := context.WithCancel(context.Background())
+	c.election = &election{stop: stop, done: make(chan struct{})}
+
+	go func() {
+		defer close(c.election.done)
+		for {
+			ok, err := lock.TryLockContext(ctx, electionInterval)
+			if ok {
+				if err = c.becomeOwner(ctx, lock); err == nil {
+					c.notifyOwnershipChange(OwnershipChange{Directory: c.cfg.Directory, Owner: true})
+					return
+				}
+			}
+			if ctx.Err() != nil {
+				lock.Close()
+				return
+			}
+			c.notifyOwnershipChange(OwnershipChange{Directory: c.cfg.Directory, Err: err})
+
+			select {
+			case <-ctx.Done():
+				lock.Close()
+				return
+			case <-time.After(electionInterval):
+			}
+		}
+	}()
+}
+
+func (c *Connector) notifyOwnershipChange(change OwnershipChange) {
+	if c.cfg.OnOwnershipChange != nil {
+		c.cfg.OnOwnershipChange(change)
+	}
+}
+
+// close stops waiting to take over the directory.
+func (e *election) close() {
+	if e == nil {
+		return
+	}
+	e.stop()
+	<-e.done
+}
+
+// close stops serving the owner socket. The lock is released by release, once the engine is closed.
+func (o *owner) close() {
+	if o == nil {
+		return
+	}
+	o.stop()
+	<-o.done
+}
+
+// release releases the ownership of the directory.
+func (o *owner) release() {
+	if o != nil {
+		o.lock.Close()
+	}
+}
+
+// proxied returns the connector proxying to the owner of the directory, or nil if the connector has its own engine.
+func (c *Connector) proxied() driver.Connector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.proxy
+}
+
+// newProxyConnector returns a connector for the engine served by the owner of |cfg.Directory|, connecting with the
+// settings of |cfg|.
+func newProxyConnector(cfg Config) (driver.Connector, error) {
+	mysqlCfg := mysql.NewConfig()
+	mysqlCfg.Net = "unix"
+	mysqlCfg.Addr = filepath.Join(cfg.Directory, ownerSocketFile)
+	mysqlCfg.User = "root"
+	if len(cfg.Users) > 0 {
+		mysqlCfg.User = cfg.User
+		mysqlCfg.Passwd = cfg.Password
+		mysqlCfg.AllowNativePasswords = true
+	}
+	mysqlCfg.DBName = cfg.Database
+	mysqlCfg.MultiStatements = cfg.MultiStatements
+	mysqlCfg.ClientFoundRows = cfg.ClientFoundRows
+
+	return mysql.NewConnector(mysqlCfg)
+}
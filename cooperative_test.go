@@ -0,0 +1,130 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCooperative(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	cfg := Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		Cooperative: true,
+	}
+	owner, err := NewConnector(cfg)
+	require.NoError(t, err)
+	require.Nil(t, owner.proxy)
+	ownerDB := sql.OpenDB(owner)
+	defer ownerDB.Close()
+
+	_, err = ownerDB.ExecContext(ctx, "create database testdb")
+	require.NoError(t, err)
+	_, err = ownerDB.ExecContext(ctx, "create table testdb.t (pk int primary key)")
+	require.NoError(t, err)
+
+	// A second connector on the directory stands in for another process
+	cfg.Database = "testdb"
+	proxied, err := NewConnector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, proxied.proxy)
+	proxiedDB := sql.OpenDB(proxied)
+	defer proxiedDB.Close()
+
+	_, err = proxiedDB.ExecContext(ctx, "insert into t values (1), (2)")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, ownerDB.QueryRowContext(ctx, "select count(*) from testdb.t").Scan(&count))
+	require.Equal(t, 2, count)
+	require.NoError(t, proxiedDB.QueryRowContext(ctx, "select count(*) from t").Scan(&count))
+	require.Equal(t, 2, count)
+
+	_, err = proxied.TableStats(ctx, "testdb", "t")
+	require.ErrorIs(t, err, ErrProxied)
+
+	// The guards of the driver's connections wouldn't apply to proxied ones, so they can't be set
+	for _, guard := range []func(*Config){
+		func(cfg *Config) { cfg.DenyWrites = true },
+		func(cfg *Config) { cfg.MaxRows = 10 },
+		func(cfg *Config) { cfg.TransactionCommits = true },
+		func(cfg *Config) { cfg.MinFreeDisk = 1 << 20 },
+	} {
+		guarded := cfg
+		guard(&guarded)
+		_, err = NewConnector(guarded)
+		require.ErrorContains(t, err, "in cooperative mode")
+	}
+
+	// Once the owner is closed, the next connector owns the directory
+	require.NoError(t, ownerDB.Close())
+	next, err := NewConnector(cfg)
+	require.NoError(t, err)
+	require.Nil(t, next.proxy)
+	require.NoError(t, next.Close())
+}
+
+func TestCooperativeFailover(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	changes := make(chan OwnershipChange, 1)
+	cfg := Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		Cooperative: true,
+		OnOwnershipChange: func(change OwnershipChange) {
+			changes <- change
+		},
+	}
+	owner, err := NewConnector(cfg)
+	require.NoError(t, err)
+	ownerDB := sql.OpenDB(owner)
+	_, err = ownerDB.ExecContext(ctx, "create database testdb")
+	require.NoError(t, err)
+
+	cfg.Database = "testdb"
+	proxied, err := NewConnector(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, proxied.proxied())
+	proxiedDB := sql.OpenDB(proxied)
+	defer proxiedDB.Close()
+
+	_, err = proxiedDB.ExecContext(ctx, "create table t (pk int primary key)")
+	require.NoError(t, err)
+	_, err = proxiedDB.ExecContext(ctx, "insert into t values (1)")
+	require.NoError(t, err)
+
+	require.NoError(t, ownerDB.Close())
+	select {
+	case change := <-changes:
+		require.NoError(t, change.Err)
+		require.True(t, change.Owner)
+		require.Equal(t, dir, change.Directory)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the proxying connector didn't take over the directory")
+	}
+	require.Nil(t, proxied.proxied())
+
+	// Connections to the previous owner are replaced by connections to the new owner's engine
+	var count int
+	require.NoError(t, proxiedDB.QueryRowContext(ctx, "select count(*) from t").Scan(&count))
+	require.Equal(t, 1, count)
+
+	stats, err := proxied.TableStats(ctx, "testdb", "t")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), stats.Rows)
+}
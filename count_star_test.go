@@ -0,0 +1,75 @@
+package embedded
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountStar(t *testing.T) {
+	_, db, cleanup := initializeTestConnector(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, query := range []string{
+		"create table t (id int primary key, v int)",
+		"create table k (v int)",
+		"insert into t values (1, 1), (2, 2)",
+		"insert into k values (1), (1), (2)",
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	count := func(query string) int {
+		var n int
+		require.NoError(t, db.QueryRowContext(ctx, query).Scan(&n), query)
+		return n
+	}
+
+	// Counts are read from the row count of tables with a primary key, and scanned otherwise
+	require.Equal(t, 2, count("select count(*) from t"))
+	require.Equal(t, 2, count("/* tag */ SELECT COUNT( 1 ) FROM `t`;"))
+	require.Equal(t, 3, count("select count(*) from k"))
+	require.Equal(t, 1, count("select count(*) from t where id > 1"))
+	explain := func(query string) string {
+		rows, err := db.QueryContext(ctx, "explain "+query)
+		require.NoError(t, err)
+		defer rows.Close()
+		var plan []string
+		for rows.Next() {
+			var line string
+			require.NoError(t, rows.Scan(&line))
+			plan = append(plan, line)
+		}
+		require.NoError(t, rows.Err())
+		return strings.Join(plan, "\n")
+	}
+	require.Contains(t, explain("select count(*) from t"), "table_count(t)")
+	require.NotContains(t, explain("select count(*) from k"), "table_count")
+
+	// The column is named like the engine names it
+	rows, err := db.QueryContext(ctx, "select COUNT(*) from t")
+	require.NoError(t, err)
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+	require.Equal(t, []string{"COUNT(*)"}, columns)
+	require.NoError(t, rows.Close())
+
+	// Transactions count their own changes, and other sessions only see them once committed
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "insert into t values (3, 3)")
+	require.NoError(t, err)
+	var n int
+	require.NoError(t, tx.QueryRowContext(ctx, "select count(*) from t").Scan(&n))
+	require.Equal(t, 3, n)
+	require.Equal(t, 2, count("select count(*) from t"))
+	require.NoError(t, tx.Commit())
+	require.Equal(t, 3, count("select count(*) from t"))
+
+	// Missing tables fail like with the engine
+	err = db.QueryRowContext(ctx, "select count(*) from missing").Scan(&n)
+	require.ErrorContains(t, err, "table not found: missing")
+}
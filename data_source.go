@@ -3,12 +3,15 @@ package embedded
 import (
 	"fmt"
 	"net/url"
+	"path/filepath"
+	"runtime"
 	"strings"
 )
 
 const (
 	fileUrlPrefix    = "file://"
 	fileUrlPrefixLen = len(fileUrlPrefix)
+	localhostPrefix  = "localhost/"
 )
 
 // DoltDataSource provides access to the data provided by the connection string
@@ -18,7 +21,10 @@ type DoltDataSource struct {
 }
 
 // ParseDataSource takes the connection string and parses out the parameters and the local filesys directory where the
-// dolt database lives
+// dolt database lives. The directory is resolved like the dolt CLI resolves paths: relative directories, such as
+// file://./data, are relative to the working directory, and symlinks are resolved. file://localhost/path is the local
+// path /path, and Windows drive and UNC paths, such as file://C:\dbs, file:///C:/dbs or file://\\server\share\dbs, are
+// accepted.
 func ParseDataSource(dataSource string) (*DoltDataSource, error) {
 	if !strings.HasPrefix(dataSource, fileUrlPrefix) {
 		return nil, fmt.Errorf("datasource url '%s' must have a file url scheme", dataSource)
@@ -41,6 +47,16 @@ func ParseDataSource(dataSource string) (*DoltDataSource, error) {
 		}
 	}
 
+	// Directories written by Config.FormatDSN are percent-encoded. Windows paths can contain % without encoding it, so
+	// directories that aren't valid encodings are used as they are.
+	if unescaped, err := url.PathUnescape(directory); err == nil {
+		directory = unescaped
+	}
+	directory, err := dataSourceDirectory(directory)
+	if err != nil {
+		return nil, err
+	}
+
 	lowerParams := make(map[string][]string, len(params))
 	for name, val := range params {
 		lowerParams[strings.ToLower(name)] = val
@@ -54,5 +70,51 @@ func ParseDataSource(dataSource string) (*DoltDataSource, error) {
 
 func (ds *DoltDataSource) ParamIsTrue(paramName string) bool {
 	values, ok := ds.Params[paramName]
-	return ok && len(values) == 1 && strings.ToLower(values[0]) == "true"
+	return ok && len(values) == 1 && parseBoolParam(values[0])
+}
+
+// dataSourceDirectory returns the local directory of the path |path| of a file url.
+func dataSourceDirectory(path string) (string, error) {
+	if strings.HasPrefix(path, localhostPrefix) {
+		path = path[len(localhostPrefix)-1:]
+	}
+	// file:///C:/dbs has the path /C:/dbs
+	if len(path) > 2 && path[0] == '/' && isDrivePath(path[1:]) {
+		path = path[1:]
+	}
+	if path == "" {
+		return "", fmt.Errorf("datasource url must include a directory")
+	}
+
+	// Windows paths can't be resolved on other systems
+	if runtime.GOOS != "windows" && (isDrivePath(path) || strings.HasPrefix(path, `\\`)) {
+		return path, nil
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// A directory that doesn't exist is reported when the connector is opened
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path, nil
+}
+
+// isDrivePath returns whether |path| starts with a Windows drive letter, such as C:.
+func isDrivePath(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
+	}
+	c := path[0]
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// directoryEscaper percent-encodes the characters of a directory that ParseDataSource would otherwise misread.
+var directoryEscaper = strings.NewReplacer("%", "%25", "?", "%3F", "#", "%23", " ", "%20")
+
+// escapeDirectory returns |dir| percent-encoded for the path of a file url.
+func escapeDirectory(dir string) string {
+	return directoryEscaper.Replace(dir)
 }
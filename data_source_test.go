@@ -1,6 +1,10 @@
 package embedded
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -23,6 +27,24 @@ func TestParseDataSource(t *testing.T) {
 				DatabaseParam:    {"hostedapidb"},
 			},
 		},
+		{
+			name:              "windows forward slash dsn test",
+			dsn:               `file:///C:/Users/dbs?commitname=Billy%20Batson`,
+			expectedDirectory: `C:/Users/dbs`,
+			expectedParams:    map[string][]string{CommitNameParam: {"Billy Batson"}},
+		},
+		{
+			name:              "windows unc dsn test",
+			dsn:               `file://\\server\share\dbs?commitname=Billy%20Batson`,
+			expectedDirectory: `\\server\share\dbs`,
+			expectedParams:    map[string][]string{CommitNameParam: {"Billy Batson"}},
+		},
+		{
+			name:              "localhost dsn test",
+			dsn:               `file://localhost/Users/brian/datasets/test?commitname=Billy%20Batson`,
+			expectedDirectory: `/Users/brian/datasets/test`,
+			expectedParams:    map[string][]string{CommitNameParam: {"Billy Batson"}},
+		},
 		{
 			name:              "unix dsn test",
 			dsn:               `file:///Users/brian/datasets/test?commitname=Billy%20Batson&commitemail=shazam@gmail.com&database=hostedapidb&multiStatements=true&clientFoundRows=true`,
@@ -39,9 +61,100 @@ func TestParseDataSource(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			if runtime.GOOS == "windows" && strings.HasPrefix(test.expectedDirectory, "/") {
+				t.Skip("unix paths are relative to the current drive on windows")
+			}
 			ds, err := ParseDataSource(test.dsn)
 			require.NoError(t, err)
+			require.Equal(t, test.expectedDirectory, ds.Directory)
 			require.Equal(t, test.expectedParams, ds.Params)
 		})
 	}
 }
+
+func TestParseDataSourceResolvesPaths(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "data"), 0755))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	for _, dsn := range []string{"file://./data", "file://data", "file://./data/../data"} {
+		ds, err := ParseDataSource(dsn + "?commitname=Billy%20Batson")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, "data"), ds.Directory, dsn)
+	}
+
+	if err := os.Symlink(filepath.Join(dir, "data"), filepath.Join(dir, "link")); err != nil {
+		t.Skipf("can't create a symlink: %v", err)
+	}
+	ds, err := ParseDataSource("file://" + filepath.Join(dir, "link"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "data"), ds.Directory)
+
+	// Directories that don't exist are left for NewConnector to report
+	ds, err = ParseDataSource("file://" + filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "missing"), ds.Directory)
+
+	_, err = ParseDataSource("file://?commitname=Billy%20Batson")
+	require.Error(t, err)
+}
+
+func TestFormatDSN(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	cfg := Config{
+		Directory:   filepath.Join(dir, "dbs #1 ?100% ünïcode"),
+		CommitName:  "Billy & Mary Batson #1",
+		CommitEmail: "billy+shazam@gmail.com",
+		Database:    "données & co",
+		Password:    "p@ss=word?&#",
+	}
+	dsn := cfg.FormatDSN()
+	require.NotContains(t, dsn, " ")
+	parsed, err := ParseDSN(dsn)
+	require.NoError(t, err)
+	require.Equal(t, cfg, parsed)
+	require.Equal(t, dsn, parsed.FormatDSN())
+
+	// Escaped and unescaped non-ASCII and spaces are both accepted
+	parsed, err = ParseDSN("file://" + filepath.Join(dir, "my%20dbs") + "?commitname=Zoë Batson&commitemail=z%C3%B6e@gmail.com")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "my dbs"), parsed.Directory)
+	require.Equal(t, "Zoë Batson", parsed.CommitName)
+	require.Equal(t, "zöe@gmail.com", parsed.CommitEmail)
+}
+
+func TestFormatDSNCanonical(t *testing.T) {
+	cfg := Config{
+		Directory:       "/data/dbs",
+		Directories:     []string{"/mnt/b", "/mnt/a"},
+		CommitName:      "Billy Batson",
+		CommitEmail:     "shazam@gmail.com",
+		Database:        "worker1",
+		MultiStatements: true,
+		MaxRows:         100,
+	}
+	require.Equal(t, "file:///data/dbs?commitname=Billy+Batson&commitemail=shazam%40gmail.com&database=worker1"+
+		"&multistatements=true&maxrows=100&path=%2Fmnt%2Fb&path=%2Fmnt%2Fa", cfg.FormatDSN())
+
+	// Parameter order and case don't change the canonical data source name
+	parsed, err := ParseDSN("file:///data/dbs?MaxRows=100&path=%2Fmnt%2Fb&Database=worker1&multistatements=true" +
+		"&commitemail=shazam%40gmail.com&path=/mnt/a&commitname=Billy%20Batson&denywrites=false")
+	require.NoError(t, err)
+	require.Equal(t, cfg.FormatDSN(), parsed.FormatDSN())
+
+	cfg.Database = "worker2"
+	require.Contains(t, cfg.FormatDSN(), "&database=worker2&")
+}
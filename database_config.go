@@ -0,0 +1,100 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	"github.com/dolthub/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// DatabaseConfig holds the settings of one of the databases of a connector, in Config.Databases.
+type DatabaseConfig struct {
+	// ReadOnly rejects statements writing to the database's data or schema, on any of its branches, with the engine's
+	// read-only database error
+	ReadOnly bool
+	// Branch is the branch connections start on when they use the database, instead of the database's default branch.
+	// Connections can still check out other branches.
+	Branch string
+	// Skip leaves the database out of the engine, as if its directory wasn't a dolt database
+	Skip bool
+}
+
+// skipped returns whether the database in subdirectory |dir| is skipped by |dbs|.
+func skipped(dbs map[string]DatabaseConfig, dir string) bool {
+	return dbs[dbfactory.DirToDBName(dir)].Skip
+}
+
+// applyDatabaseConfigs applies the read-only and branch settings of |dbs| to the engine |se|. A branch that doesn't
+// exist is an error, since the engine would otherwise leave its database out.
+func applyDatabaseConfigs(ctx context.Context, se *engine.SqlEngine, dbs map[string]DatabaseConfig) error {
+	catalog := se.GetUnderlyingEngine().Analyzer.Catalog
+	readOnly := make(map[string]bool)
+	for name, db := range dbs {
+		if db.ReadOnly {
+			readOnly[strings.ToLower(name)] = true
+		}
+		if db.Branch == "" || db.Skip {
+			continue
+		}
+
+		if err := gms.SystemVariables.SetGlobal(dsess.DefaultBranchKey(name), db.Branch); err != nil {
+			return err
+		}
+		gmsCtx, err := se.NewLocalContext(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err = catalog.Database(gmsCtx, name); err != nil {
+			return fmt.Errorf("branch '%s' of database '%s': %w", db.Branch, name, err)
+		}
+	}
+
+	if len(readOnly) > 0 {
+		if pro, ok := catalog.DbProvider.(*sqle.DoltDatabaseProvider); ok {
+			catalog.DbProvider = readOnlyProvider{DoltDatabaseProvider: pro, readOnly: readOnly}
+		}
+	}
+	return nil
+}
+
+// readOnlyProvider is the database provider of an engine with read-only databases. It returns those databases, and
+// their revision databases, wrapped in a sqle.ReadOnlyDatabase, which the engine's analyzer rejects writes to.
+type readOnlyProvider struct {
+	*sqle.DoltDatabaseProvider
+	// readOnly holds the lower case names of the read-only databases
+	readOnly map[string]bool
+}
+
+// Database returns the database |name|, read-only if it is one of the provider's read-only databases.
+func (p readOnlyProvider) Database(ctx *gms.Context, name string) (gms.Database, error) {
+	db, err := p.DoltDatabaseProvider.Database(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.wrap(db), nil
+}
+
+// AllDatabases returns all the databases of the provider, with the read-only ones wrapped.
+func (p readOnlyProvider) AllDatabases(ctx *gms.Context) []gms.Database {
+	all := p.DoltDatabaseProvider.AllDatabases(ctx)
+	for i, db := range all {
+		all[i] = p.wrap(db)
+	}
+	return all
+}
+
+func (p readOnlyProvider) wrap(db gms.Database) gms.Database {
+	base, _ := dsess.SplitRevisionDbName(db.Name())
+	if !p.readOnly[strings.ToLower(base)] {
+		return db
+	}
+	if rw, ok := db.(sqle.Database); ok {
+		return sqle.ReadOnlyDatabase{Database: rw}
+	}
+	return db
+}
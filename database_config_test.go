@@ -0,0 +1,94 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseConfigs(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"}
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	for _, database := range []string{"archive", "pinned", "broken"} {
+		for _, query := range []string{
+			"create database " + database,
+			"use " + database,
+			"create table t (v varchar(10) primary key)",
+			"insert into t values ('main')",
+			"call dolt_commit('-Am', 'main')",
+		} {
+			_, err = conn.ExecContext(ctx, query)
+			require.NoError(t, err, query)
+		}
+	}
+	_, err = conn.ExecContext(ctx, "use pinned")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "call dolt_branch('release')")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	require.NoError(t, db.Close())
+
+	cfg.Databases = map[string]DatabaseConfig{"pinned": {Branch: "missing"}}
+	_, err = NewConnector(cfg)
+	require.ErrorContains(t, err, "branch 'missing' of database 'pinned'")
+
+	cfg.Databases = map[string]DatabaseConfig{
+		"archive": {ReadOnly: true},
+		"pinned":  {Branch: "release"},
+		"broken":  {Skip: true},
+	}
+	var progress []OpenProgress
+	cfg.OnOpenProgress = func(p OpenProgress) { progress = append(progress, p) }
+	connector, err = NewConnector(cfg)
+	require.NoError(t, err)
+	db = sql.OpenDB(connector)
+	defer db.Close()
+	require.Equal(t, 2, progress[len(progress)-1].Discovered)
+
+	conn, err = db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Skipped databases aren't loaded
+	requireResults(t, conn, "select count(*) from information_schema.schemata where schema_name = 'broken'", [][]any{{int64(0)}})
+	added, err := connector.RefreshDatabases(ctx)
+	require.NoError(t, err)
+	require.Empty(t, added)
+
+	// Read-only databases can be read but not written, on any branch
+	requireResults(t, conn, "select v from archive.t", [][]any{{"main"}})
+	for _, query := range []string{
+		"insert into archive.t values ('more')",
+		"update archive.t set v = 'other'",
+		"delete from archive.t",
+		"create table archive.u (id int primary key)",
+		"alter table archive.t add column c int",
+		"drop table archive.t",
+		"insert into `archive/main`.t values ('more')",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.ErrorContains(t, err, "read-only", query)
+	}
+	_, err = conn.ExecContext(ctx, "insert into pinned.t select * from archive.t where v = 'none'")
+	require.NoError(t, err)
+
+	// Connections start on the pinned branch of a database
+	_, err = conn.ExecContext(ctx, "use pinned")
+	require.NoError(t, err)
+	requireResults(t, conn, "select active_branch()", [][]any{{"release"}})
+	_, err = conn.ExecContext(ctx, "insert into t values ('release')")
+	require.NoError(t, err)
+	requireResults(t, conn, "select v from `pinned/main`.t", [][]any{{"main"}})
+}
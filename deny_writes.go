@@ -0,0 +1,75 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrWritesDenied is the error returned when a connection opened with denywrites=true executes a statement that could
+// modify data or schema. Match it with errors.Is, which compares MySQL error numbers.
+var ErrWritesDenied = &mysql.MySQLError{
+	Number:  1290,
+	Message: "the connection was opened with denywrites=true so it cannot execute this statement",
+}
+
+// checkWritesDenied returns an ErrWritesDenied error if |query| could modify data or schema. Statements are classified
+// from their syntax rather than the engine's analysis, so the check doesn't depend on engine read-only mode and can't
+// be bypassed by the state of the database. |options| are those of the session's sql_mode, such as ANSI_QUOTES, which
+// change how the engine parses the query. Anything not known to be read-only is denied: that includes statements that
+// don't parse, CALL, since a stored procedure may write, and PREPARE/EXECUTE, since the prepared text isn't known until
+// execution.
+func checkWritesDenied(ctx context.Context, query string, options sqlparser.ParserOptions) error {
+	stmt, err := sqlparser.ParseWithOptions(ctx, query, options)
+	if err != nil {
+		return &mysql.MySQLError{
+			Number:  ErrWritesDenied.Number,
+			Message: fmt.Sprintf("%s: %s", ErrWritesDenied.Message, err.Error()),
+		}
+	}
+
+	if !isReadOnlyStatement(stmt) {
+		return &mysql.MySQLError{
+			Number:  ErrWritesDenied.Number,
+			Message: fmt.Sprintf("%s: %s", ErrWritesDenied.Message, sqlparser.String(stmt)),
+		}
+	}
+
+	return nil
+}
+
+// isReadOnlyStatement returns whether |stmt| is known not to modify data or schema.
+func isReadOnlyStatement(stmt sqlparser.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Select:
+		return isReadOnlyInto(stmt.Into)
+	case *sqlparser.SetOp:
+		return isReadOnlyInto(stmt.Into) && isReadOnlyStatement(stmt.Left) && isReadOnlyStatement(stmt.Right)
+	case *sqlparser.ParenSelect:
+		return isReadOnlyStatement(stmt.Select)
+	case *sqlparser.Explain:
+		// EXPLAIN ANALYZE executes the statement
+		return !stmt.Analyze || isReadOnlyStatement(stmt.Statement)
+	case *sqlparser.Set:
+		// Session and user variables only affect this connection
+		for _, expr := range stmt.Exprs {
+			switch expr.Scope {
+			case sqlparser.SetScope_Global, sqlparser.SetScope_Persist, sqlparser.SetScope_PersistOnly:
+				return false
+			}
+		}
+		return true
+	case *sqlparser.Show, *sqlparser.OtherRead, *sqlparser.Use, *sqlparser.Begin, *sqlparser.Commit,
+		*sqlparser.Rollback, *sqlparser.Savepoint, *sqlparser.RollbackSavepoint, *sqlparser.ReleaseSavepoint:
+		return true
+	default:
+		return false
+	}
+}
+
+// isReadOnlyInto returns whether the INTO clause |into| of a SELECT only assigns variables, rather than writing files.
+func isReadOnlyInto(into *sqlparser.Into) bool {
+	return into == nil || (into.Outfile == "" && into.Dumpfile == "")
+}
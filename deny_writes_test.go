@@ -0,0 +1,107 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWritesDenied(t *testing.T) {
+	tests := []struct {
+		query  string
+		denied bool
+	}{
+		{"select * from t where id = ?", false},
+		{"select * from t1 union select * from t2", false},
+		{"(select 1) union (select 2)", false},
+		{"select 1 into @a", false},
+		{"select * from t into outfile '/tmp/t.csv'", true},
+		{"with cte as (select 1) select * from cte", false},
+		{"show tables", false},
+		{"describe t", false},
+		{"explain select * from t", false},
+		{"explain analyze select * from t", false},
+		{"use mydb", false},
+		{"set @a = 1, autocommit = 0", false},
+		{"set global max_connections = 10", true},
+		{"begin", false},
+		{"commit", false},
+		{"insert into t values (1)", true},
+		{"replace into t values (1)", true},
+		{"update t set a = 1", true},
+		{"delete from t", true},
+		{"create table t2 (id int primary key)", true},
+		{"alter table t add column c int", true},
+		{"drop database mydb", true},
+		{"truncate table t", true},
+		{"call dolt_commit('-am', 'message')", true},
+		{"prepare s from 'insert into t values (1)'", true},
+		{"not sql at all", true},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			err := checkWritesDenied(ctx, test.query, sqlparser.ParserOptions{})
+			if test.denied {
+				require.ErrorIs(t, err, ErrWritesDenied)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDenyWritesParam(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	query := url.Values{
+		CommitNameParam:  []string{"Billy Batson"},
+		CommitEmailParam: []string{"shazam@gmail.com"},
+		DenyWritesParam:  []string{"true"},
+	}
+	dsn := url.URL{Scheme: "file", Path: encodeDir(dir), RawQuery: query.Encode()}
+	db, err := sql.Open(DoltDriverName, dsn.String())
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	var one int
+	require.NoError(t, db.QueryRowContext(ctx, "select 1").Scan(&one))
+	require.Equal(t, 1, one)
+
+	_, err = db.ExecContext(ctx, "create database testdb")
+	require.ErrorIs(t, err, ErrWritesDenied)
+	var mysqlErr *mysql.MySQLError
+	require.True(t, errors.As(err, &mysqlErr))
+	require.Equal(t, uint16(1290), mysqlErr.Number)
+
+	rows, err := db.QueryContext(ctx, "show databases")
+	require.NoError(t, err)
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		require.NotEqual(t, "testdb", name)
+	}
+	require.NoError(t, rows.Close())
+
+	// Statements are parsed with the session's sql_mode, and those that don't parse are denied
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, "set sql_mode = 'ANSI_QUOTES'")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, `insert into "t" values (2)`)
+	require.ErrorIs(t, err, ErrWritesDenied)
+	require.NoError(t, conn.QueryRowContext(ctx, `select "one" from (select 1 as "one") as "t"`).Scan(&one))
+	require.Equal(t, 1, one)
+}
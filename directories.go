@@ -0,0 +1,139 @@
+package embedded
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+)
+
+// directories returns the directories of the databases of the connector: Directory, followed by Directories.
+func (cfg Config) directories() []string {
+	return append([]string{cfg.Directory}, cfg.Directories...)
+}
+
+// validateDirectories returns an error if one of |dirs| isn't an existing directory.
+func validateDirectories(dirs []string) error {
+	for _, dir := range dirs {
+		exists, isDir := filesys.LocalFS.Exists(dir)
+		if !exists {
+			return fmt.Errorf("'%s' does not exist", dir)
+		} else if !isDir {
+			return fmt.Errorf("%s: is a file.  Need to specify a directory", dir)
+		}
+	}
+	return nil
+}
+
+// databaseDirsIn returns the names of the subdirectories of |dirs| that are dolt databases, leaving out those skipped
+// by |dbs|. Databases with the same name in two of the directories are an error, since the engine couldn't tell them
+// apart.
+func databaseDirsIn(dirs []string, dbs map[string]DatabaseConfig) ([]string, error) {
+	seen := make(map[string]string)
+	var names []string
+	for _, dir := range dirs {
+		dbDirs, err := databaseDirs(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range dbDirs {
+			if skipped(dbs, name) {
+				continue
+			}
+			if other, ok := seen[name]; ok {
+				return nil, fmt.Errorf("database directory '%s' is in both '%s' and '%s'", name, other, dir)
+			}
+			seen[name] = dir
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// multiDirFS is the file system of a connector's Directory, which also holds the databases of its other Directories.
+// env.MultiEnvForDirectory lists the databases of its directory with Iter and opens each of them with WithWorkingDir,
+// so multiDirFS lists the databases of the other directories along with its own, and opens them in their directory.
+// Databases created with CREATE DATABASE are created in Directory. The databases skipped by Config.Databases aren't
+// listed, so they aren't loaded.
+type multiDirFS struct {
+	filesys.Filesys
+	// databases holds the paths of the databases of the other directories by name
+	databases map[string]string
+	// dbs holds the settings of the databases, used to skip them
+	dbs map[string]DatabaseConfig
+}
+
+// newMultiDirFS returns the file system of |fs|, whose directory is the connector's Directory, holding the databases
+// of the other directories |dirs| too, but not those skipped by |dbs|. It returns |fs| if |dirs| is empty and no
+// database is skipped.
+func newMultiDirFS(fs filesys.Filesys, dirs []string, dbs map[string]DatabaseConfig) (filesys.Filesys, error) {
+	skips := false
+	for _, db := range dbs {
+		skips = skips || db.Skip
+	}
+	if len(dirs) == 0 && !skips {
+		return fs, nil
+	}
+
+	dir, err := fs.Abs("")
+	if err != nil {
+		return nil, err
+	}
+	// Check that the databases of the directories have distinct names
+	if _, err = databaseDirsIn(append([]string{dir}, dirs...), dbs); err != nil {
+		return nil, err
+	}
+
+	databases := make(map[string]string)
+	for _, dir := range dirs {
+		names, err := databaseDirs(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if !skipped(dbs, name) {
+				databases[name] = filepath.Join(dir, name)
+			}
+		}
+	}
+
+	return multiDirFS{Filesys: fs, databases: databases, dbs: dbs}, nil
+}
+
+// Iter lists the databases of the other directories after the contents of the directory itself, leaving out the
+// skipped databases.
+func (fs multiDirFS) Iter(dir string, recursive bool, cb filesys.FSIterCB) error {
+	top := !recursive && filepath.Clean(dir) == "."
+	stopped := false
+	err := fs.Filesys.Iter(dir, recursive, func(path string, size int64, isDir bool) bool {
+		if top && isDir && skipped(fs.dbs, filepath.Base(path)) {
+			return false
+		}
+		stopped = cb(path, size, isDir)
+		return stopped
+	})
+	if err != nil || stopped || !top {
+		return err
+	}
+
+	names := make([]string, 0, len(fs.databases))
+	for name := range fs.databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if cb(fs.databases[name], 0, true) {
+			break
+		}
+	}
+	return nil
+}
+
+// WithWorkingDir opens the databases of the other directories in their own directory.
+func (fs multiDirFS) WithWorkingDir(dir string) (filesys.Filesys, error) {
+	if path, ok := fs.databases[dir]; ok {
+		return filesys.LocalFS.WithWorkingDir(path)
+	}
+	return fs.Filesys.WithWorkingDir(dir)
+}
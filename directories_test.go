@@ -0,0 +1,97 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectories(t *testing.T) {
+	ctx := context.Background()
+	newDirectory := func(databases ...string) string {
+		dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+		require.NoError(t, err)
+		db := sql.OpenDB(connector)
+		defer db.Close()
+		for _, database := range databases {
+			_, err = db.ExecContext(ctx, "create database "+database)
+			require.NoError(t, err)
+			_, err = db.ExecContext(ctx, "create table "+database+".t (v varchar(10) primary key)")
+			require.NoError(t, err)
+			_, err = db.ExecContext(ctx, "insert into "+database+".t values ('"+database+"')")
+			require.NoError(t, err)
+		}
+		return dir
+	}
+
+	primary := newDirectory("tenant1")
+	other := newDirectory("tenant2", "tenant3")
+
+	query := url.Values{
+		CommitNameParam:  []string{"Billy Batson"},
+		CommitEmailParam: []string{"shazam@gmail.com"},
+		PathParam:        []string{other},
+	}
+	cfg, err := ParseDSN("file://" + primary + "?" + query.Encode())
+	require.NoError(t, err)
+	require.Equal(t, []string{other}, cfg.Directories)
+
+	var progress []OpenProgress
+	cfg.OnOpenProgress = func(p OpenProgress) { progress = append(progress, p) }
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	require.Equal(t, 3, progress[len(progress)-1].Discovered)
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, database := range []string{"tenant1", "tenant2", "tenant3"} {
+		requireResults(t, conn, "select v from "+database+".t", [][]any{{database}})
+	}
+
+	// Databases of the other directory are written in place, and new databases are created in Directory
+	_, err = conn.ExecContext(ctx, "insert into tenant2.t values ('more')")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "create database tenant4")
+	require.NoError(t, err)
+	require.DirExists(t, primary+"/tenant4")
+	require.NoDirExists(t, other+"/tenant1")
+	require.NoError(t, conn.Close())
+	require.NoError(t, db.Close())
+
+	connector, err = NewConnector(Config{Directory: other, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+	require.NoError(t, err)
+	db = sql.OpenDB(connector)
+	defer db.Close()
+	conn, err = db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	requireResults(t, conn, "select v from tenant2.t order by v", [][]any{{"more"}, {"tenant2"}})
+
+	// The databases of the directories must have distinct names
+	_, err = NewConnector(Config{
+		Directory:   primary,
+		Directories: []string{newDirectory("tenant1")},
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	})
+	require.ErrorContains(t, err, "database directory 'tenant1' is in both")
+
+	_, err = NewConnector(Config{
+		Directory:   primary,
+		Directories: []string{primary + "/missing"},
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	})
+	require.ErrorContains(t, err, "does not exist")
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package embedded
+
+// diskUsageSupported reports whether diskUsage is supported on this platform.
+const diskUsageSupported = false
+
+// diskUsage isn't supported on this platform, and always returns zero.
+func diskUsage(dir string) (free uint64, total uint64, err error) {
+	return 0, 0, nil
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package embedded
+
+import "golang.org/x/sys/unix"
+
+// diskUsageSupported reports whether diskUsage is supported on this platform.
+const diskUsageSupported = true
+
+// diskUsage returns the number of bytes available to the process on the file system of |dir|, and its size.
+func diskUsage(dir string) (free uint64, total uint64, err error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,78 @@
+package embedded
+
+import (
+	"fmt"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrLowDiskSpace is the error returned when a statement that could modify data or schema is executed while a file
+// system of the connector's directories has less free space than Config.MinFreeDisk. Match it with errors.Is, which
+// compares MySQL error numbers.
+var ErrLowDiskSpace = &mysql.MySQLError{
+	Number:  1021,
+	Message: "the disk has less free space than the connector's minimum, so it cannot execute this statement",
+}
+
+// diskGuard rejects writes while the file systems of a connector's directories are low on space, so that they fail
+// before the storage layer runs out of space in the middle of writing chunks.
+type diskGuard struct {
+	dirs []string
+	min  uint64
+}
+
+// newDiskGuard returns a diskGuard for |cfg|, or nil if Config.MinFreeDisk isn't set or the platform can't report
+// free space.
+func newDiskGuard(cfg Config) *diskGuard {
+	if cfg.MinFreeDisk <= 0 || !diskUsageSupported {
+		return nil
+	}
+	return &diskGuard{dirs: cfg.directories(), min: uint64(cfg.MinFreeDisk)}
+}
+
+// free returns the least free space of the file systems of the directories.
+func (g *diskGuard) free() (uint64, error) {
+	var least uint64
+	for i, dir := range g.dirs {
+		free, _, err := diskUsage(dir)
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 || free < least {
+			least = free
+		}
+	}
+	return least, nil
+}
+
+// low returns whether a file system of the directories has less free space than the minimum. A nil *diskGuard is
+// never low.
+func (g *diskGuard) low() bool {
+	if g == nil {
+		return false
+	}
+	free, err := g.free()
+	return err == nil && free < g.min
+}
+
+// check returns an ErrLowDiskSpace error if |query| could modify data or schema while the disk is low on space.
+// Statements are classified like with Config.DenyWrites. Writes aren't rejected if the free space can't be read. A nil
+// *diskGuard doesn't check anything.
+func (g *diskGuard) check(query string) error {
+	if g == nil {
+		return nil
+	}
+	if stmt, err := sqlparser.Parse(query); err == nil && isReadOnlyStatement(stmt) {
+		return nil
+	}
+
+	free, err := g.free()
+	if err != nil || free >= g.min {
+		return nil
+	}
+	return &mysql.MySQLError{
+		Number:  ErrLowDiskSpace.Number,
+		Message: fmt.Sprintf("%s: %d bytes free, %d required", ErrLowDiskSpace.Message, free, g.min),
+	}
+}
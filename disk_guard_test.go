@@ -0,0 +1,67 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinFreeDisk(t *testing.T) {
+	if !diskUsageSupported {
+		t.Skip("free disk space isn't reported on this platform")
+	}
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	open := func(minFreeDisk int64) *sql.DB {
+		query := url.Values{
+			CommitNameParam:  []string{"Billy Batson"},
+			CommitEmailParam: []string{"shazam@gmail.com"},
+			DatabaseParam:    []string{"testdb"},
+			MinFreeDiskParam: []string{strconv.FormatInt(minFreeDisk, 10)},
+		}
+		dsn := url.URL{Scheme: "file", Path: encodeDir(dir), RawQuery: query.Encode()}
+		db, err := sql.Open(DoltDriverName, dsn.String())
+		require.NoError(t, err)
+		return db
+	}
+
+	ctx := context.Background()
+	db := open(1)
+	_, err = db.ExecContext(ctx, "create database testdb")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "create table t (id int primary key)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "insert into t values (1)")
+	require.NoError(t, err)
+	report := Health(ctx, db)
+	require.False(t, report.LowDiskSpace)
+	require.NotZero(t, report.DiskTotal)
+	require.True(t, report.Healthy())
+	require.NoError(t, db.Close())
+
+	// No file system has this much space, so writes fail while reads still work
+	db = open(math.MaxInt64)
+	defer db.Close()
+	_, err = db.ExecContext(ctx, "insert into t values (2)")
+	require.ErrorIs(t, err, ErrLowDiskSpace)
+	require.ErrorContains(t, err, "bytes free")
+	_, err = db.ExecContext(ctx, "create table t2 (id int primary key)")
+	require.ErrorIs(t, err, ErrLowDiskSpace)
+
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from t").Scan(&count))
+	require.Equal(t, 1, count)
+
+	report = Health(ctx, db)
+	require.True(t, report.LowDiskSpace)
+	require.Less(t, report.DiskFree, uint64(math.MaxInt64))
+	require.False(t, report.Healthy())
+}
@@ -4,26 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
-	"fmt"
 
-	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
 	"github.com/dolthub/dolt/go/cmd/dolt/errhand"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
 	"github.com/dolthub/dolt/go/libraries/utils/config"
 	"github.com/dolthub/dolt/go/libraries/utils/filesys"
-	gmssql "github.com/dolthub/go-mysql-server/sql"
-	"github.com/dolthub/vitess/go/mysql"
 )
 
-const (
-	DoltDriverName = "dolt"
+const DoltDriverName = "dolt"
 
-	CommitNameParam      = "commitname"
-	CommitEmailParam     = "commitemail"
-	DatabaseParam        = "database"
-	MultiStatementsParam = "multistatements"
-	ClientFoundRowsParam = "clientfoundrows"
-)
+// defaultDoltVersion is the dolt version the engine is opened as when Config.Version isn't set.
+const defaultDoltVersion = "0.40.17"
 
 var _ driver.Driver = (*doltDriver)(nil)
 
@@ -42,79 +33,37 @@ type doltDriver struct {
 //
 // The path needs to point to a directory whose subdirectories are dolt databases.  If a "Create Database" command is
 // run a new subdirectory will be created in this path.
+//
+// Open is equivalent to opening a Connector for the Config returned by ParseDSN, and connecting with it. Each connection
+// has its own connector and engine, which are closed with the connection, so prefer sharing one Connector between the
+// connections of a sql.DB with sql.OpenDB.
 func (d *doltDriver) Open(dataSource string) (driver.Conn, error) {
-	ctx := context.Background()
-	var fs filesys.Filesys = filesys.LocalFS
-
-	ds, err := ParseDataSource(dataSource)
+	cfg, err := ParseDSN(dataSource)
 	if err != nil {
 		return nil, err
 	}
 
-	exists, isDir := fs.Exists(ds.Directory)
-	if !exists {
-		return nil, fmt.Errorf("'%s' does not exist", ds.Directory)
-	} else if !isDir {
-		return nil, fmt.Errorf("%s: is a file.  Need to specify a directory", ds.Directory)
-	}
-
-	fs, err = fs.WithWorkingDir(ds.Directory)
+	connector, err := NewConnector(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	name := ds.Params[CommitNameParam]
-	if name == nil {
-		return nil, fmt.Errorf("datasource '%s' must include the parameter '%s'", dataSource, CommitNameParam)
-	}
-
-	email := ds.Params[CommitEmailParam]
-	if email == nil {
-		return nil, fmt.Errorf("datasource '%s' must include the parameter '%s'", dataSource, CommitEmailParam)
-	}
-
-	cfg := config.NewMapConfig(map[string]string{
-		config.UserNameKey:  name[0],
-		config.UserEmailKey: email[0],
-	})
-
-	mrEnv, err := LoadMultiEnvFromDir(ctx, cfg, fs, ds.Directory, "0.40.17")
+	conn, err := connector.Connect(context.Background())
 	if err != nil {
+		connector.Close()
 		return nil, err
 	}
 
-	seCfg := &engine.SqlEngineConfig{
-		IsReadOnly: false,
-		ServerUser: "root",
-		Autocommit: true,
-	}
-
-	se, err := engine.NewSqlEngine(ctx, mrEnv, seCfg)
-	if err != nil {
-		return nil, err
+	doltConn, ok := conn.(*DoltConn)
+	if !ok {
+		// The connector proxies to the owner of the directory in cooperative mode, and the proxied connection doesn't
+		// need it.
+		connector.Close()
+		return conn, nil
 	}
+	doltConn.connector = connector
 
-	gmsCtx, err := se.NewLocalContext(ctx)
-	if err != nil {
-		return nil, err
-	}
-	if database, ok := ds.Params[DatabaseParam]; ok && len(database) == 1 {
-		gmsCtx.SetCurrentDatabase(database[0])
-	}
-	if ds.ParamIsTrue(ClientFoundRowsParam) {
-		client := gmsCtx.Client()
-		gmsCtx.SetClient(gmssql.Client{
-			User:         client.User,
-			Address:      client.Address,
-			Capabilities: client.Capabilities | mysql.CapabilityClientFoundRows,
-		})
-	}
-
-	return &DoltConn{
-		DataSource: ds,
-		se:         se,
-		gmsCtx:     gmsCtx,
-	}, nil
+	return doltConn, nil
 }
 
 // LoadMultiEnvFromDir looks at each subfolder of the given path as a Dolt repository and attempts to return a MultiRepoEnv
@@ -126,11 +75,38 @@ func LoadMultiEnvFromDir(
 	fs filesys.Filesys,
 	path, version string,
 ) (*env.MultiRepoEnv, error) {
+	return loadMultiEnv(ctx, cfg, fs, path, nil, nil, version, nil)
+}
+
+// loadMultiEnv is LoadMultiEnvFromDir, also loading the databases in the subfolders of |moreDirs|, and reporting the
+// databases it loads to |progress| if it isn't nil.
+func loadMultiEnv(
+	ctx context.Context,
+	cfg config.ReadWriteConfig,
+	fs filesys.Filesys,
+	path string,
+	moreDirs []string,
+	dbs map[string]DatabaseConfig,
+	version string,
+	progress *openProgress,
+) (*env.MultiRepoEnv, error) {
 
 	multiDbDirFs, err := fs.WithWorkingDir(path)
 	if err != nil {
 		return nil, errhand.VerboseErrorFromError(err)
 	}
+	if multiDbDirFs, err = newMultiDirFS(multiDbDirFs, moreDirs, dbs); err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		multiDbDirFs = progressFS{Filesys: multiDbDirFs, progress: progress}
+	}
+
+	mrEnv, err := env.MultiEnvForDirectory(ctx, cfg, multiDbDirFs, version, nil)
+	if err != nil {
+		return nil, err
+	}
+	progress.loaded()
 
-	return env.MultiEnvForDirectory(ctx, cfg, multiDbDirFs, version, nil)
+	return mrEnv, nil
 }
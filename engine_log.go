@@ -0,0 +1,301 @@
+package embedded
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/sirupsen/logrus"
+)
+
+// engineLog routes the messages the engine logs with logrus to Config.Logger. The engine logs the messages of a
+// session with the session's logger, which the connector's sessions get from newSessionLogger, and its other messages
+// with logrus's process-wide standard logger, whose messages from the engine are routed to the loggers of all the open
+// connectors with a Config.Logger while there are any, see routeStandardLogger. A nil *engineLog leaves logging alone.
+type engineLog struct {
+	logger *slog.Logger
+	// logrus is the logger of the connector's sessions, which forwards everything it logs to |logger|
+	logrus *logrus.Logger
+
+	closeOnce sync.Once
+}
+
+// newEngineLog returns the engineLog routing the engine's messages to Config.Logger, or nil if it isn't set.
+func newEngineLog(cfg Config) *engineLog {
+	if cfg.Logger == nil {
+		return nil
+	}
+
+	l := &engineLog{logger: cfg.Logger}
+	l.logrus = &logrus.Logger{
+		Out:       io.Discard,
+		Formatter: discardFormatter{},
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrusLevel(cfg.Logger),
+		ExitFunc:  logrus.StandardLogger().ExitFunc,
+	}
+	l.logrus.AddHook(slogHook{l})
+	routeStandardLogger(l)
+	return l
+}
+
+// newSessionLogger returns the logger of the new session of the connector with the id |id|, or nil if the session
+// should keep the engine's default one. A nil *engineLog returns nil.
+func (l *engineLog) newSessionLogger(id uint32) *logrus.Entry {
+	if l == nil {
+		return nil
+	}
+	return logrus.NewEntry(l.logrus).WithField(gms.ConnectionIdLogField, id)
+}
+
+// close stops routing the engine's process-wide messages to the connector's logger, once it is closed. A nil
+// *engineLog does nothing.
+func (l *engineLog) close() {
+	if l == nil {
+		return
+	}
+	l.closeOnce.Do(func() { unrouteStandardLogger(l) })
+}
+
+// log logs |entry| with the connector's logger, with the fields of the entry as attributes, if the logger's handler
+// is enabled for its level.
+func (l *engineLog) log(entry *logrus.Entry) {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := slogLevel(entry.Level)
+	handler := l.logger.Handler()
+	if !handler.Enabled(ctx, level) {
+		return
+	}
+
+	record := slog.NewRecord(entry.Time, level, entry.Message, 0)
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		record.AddAttrs(slog.Any(key, entry.Data[key]))
+	}
+	_ = handler.Handle(ctx, record)
+}
+
+// slogHook forwards the entries logged with logrus to an engineLog.
+type slogHook struct {
+	log *engineLog
+}
+
+func (h slogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h slogHook) Fire(entry *logrus.Entry) error {
+	h.log.log(entry)
+	return nil
+}
+
+// discardFormatter formats nothing, for the loggers whose entries are only forwarded by their hooks.
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+// slogLevel returns the slog level of the logrus level |level|. Logrus's trace level is below slog's debug level.
+func slogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return slog.LevelError
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	default:
+		return slog.LevelDebug - 4
+	}
+}
+
+// logrusLevel returns the most verbose logrus level whose messages |logger| logs, so that the engine doesn't spend
+// time on the messages it would discard. The handler's levels are read once, when the connector is created.
+func logrusLevel(logger *slog.Logger) logrus.Level {
+	for _, level := range []logrus.Level{logrus.TraceLevel, logrus.DebugLevel, logrus.InfoLevel, logrus.WarnLevel} {
+		if logger.Enabled(context.Background(), slogLevel(level)) {
+			return level
+		}
+	}
+	return logrus.ErrorLevel
+}
+
+// standardLogger is the state of the redirection of logrus's standard logger, which the application may log with too.
+// While connectors with a Config.Logger are open, the messages the engine logs with it are forwarded to their loggers
+// instead of being written to its output, stderr by default, and while connectors with Config.Quiet are open, they are
+// discarded. The application's own messages are still written to its output, and passed to its hooks, as before. The
+// standard logger's formatter, level and hooks are restored once the last of the connectors is closed.
+var standardLogger struct {
+	mu     sync.Mutex
+	routes []*engineLog
+	// quiet is the number of open connectors with Config.Quiet
+	quiet int
+
+	// redirected is set while the standard logger is redirected, and formatter, level and hooks are the settings it
+	// had before
+	redirected bool
+	formatter  logrus.Formatter
+	level      logrus.Level
+	hooks      logrus.LevelHooks
+}
+
+// routeStandardLogger forwards the engine's messages logged with logrus's standard logger to |l|, in addition to the
+// other connectors' loggers.
+func routeStandardLogger(l *engineLog) {
+	standardLogger.mu.Lock()
+	defer standardLogger.mu.Unlock()
+	standardLogger.routes = append(standardLogger.routes, l)
+	redirectStandardLogger()
+}
+
+// unrouteStandardLogger stops forwarding the engine's messages logged with logrus's standard logger to |l|.
+func unrouteStandardLogger(l *engineLog) {
+	standardLogger.mu.Lock()
+	defer standardLogger.mu.Unlock()
+	for i, route := range standardLogger.routes {
+		if route == l {
+			standardLogger.routes = append(standardLogger.routes[:i], standardLogger.routes[i+1:]...)
+			break
+		}
+	}
+	redirectStandardLogger()
+}
+
+// redirectStandardLogger keeps logrus's standard logger from writing the engine's messages while any connector routes
+// or silences them, and sets its level to the most verbose of its own level and the levels of the loggers they are
+// routed to, or restores it once none does. standardLogger.mu must be held.
+func redirectStandardLogger() {
+	std := logrus.StandardLogger()
+	redirect := len(standardLogger.routes) > 0 || standardLogger.quiet > 0
+	if redirect && !standardLogger.redirected {
+		standardLogger.formatter = std.Formatter
+		standardLogger.level = std.GetLevel()
+		hooks := make(logrus.LevelHooks)
+		for level, levelHooks := range std.Hooks {
+			for _, hook := range levelHooks {
+				hooks[level] = append(hooks[level], applicationHook{hook: hook, level: standardLogger.level})
+			}
+		}
+		hooks.Add(standardLoggerHook{})
+		standardLogger.hooks = std.ReplaceHooks(hooks)
+		std.SetFormatter(applicationFormatter{formatter: standardLogger.formatter, level: standardLogger.level})
+		standardLogger.redirected = true
+	} else if !redirect && standardLogger.redirected {
+		std.ReplaceHooks(standardLogger.hooks)
+		std.SetFormatter(standardLogger.formatter)
+		std.SetLevel(standardLogger.level)
+		standardLogger.hooks, standardLogger.formatter = nil, nil
+		standardLogger.redirected = false
+		return
+	}
+	if !redirect {
+		return
+	}
+
+	level := standardLogger.level
+	for _, route := range standardLogger.routes {
+		level = max(level, route.logrus.GetLevel())
+	}
+	std.SetLevel(level)
+}
+
+// standardLoggerHook forwards the engine's messages logged with logrus's standard logger to the loggers they are
+// routed to.
+type standardLoggerHook struct{}
+
+func (standardLoggerHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (standardLoggerHook) Fire(entry *logrus.Entry) error {
+	if !loggedByEngine() {
+		return nil
+	}
+	standardLogger.mu.Lock()
+	routes := append([]*engineLog(nil), standardLogger.routes...)
+	standardLogger.mu.Unlock()
+
+	for _, route := range routes {
+		route.log(entry)
+	}
+	return nil
+}
+
+// applicationHook is a hook the application added to logrus's standard logger, which is only passed the application's
+// own messages of the levels it logged before the standard logger was redirected.
+type applicationHook struct {
+	hook  logrus.Hook
+	level logrus.Level
+}
+
+func (h applicationHook) Levels() []logrus.Level {
+	return h.hook.Levels()
+}
+
+func (h applicationHook) Fire(entry *logrus.Entry) error {
+	if entry.Level > h.level || loggedByEngine() {
+		return nil
+	}
+	return h.hook.Fire(entry)
+}
+
+// applicationFormatter is the formatter of logrus's standard logger while it's redirected, which formats the
+// application's own messages of the levels it logged before with the application's formatter, and nothing else.
+type applicationFormatter struct {
+	formatter logrus.Formatter
+	level     logrus.Level
+}
+
+func (f applicationFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if entry.Level > f.level || loggedByEngine() {
+		return nil, nil
+	}
+	return f.formatter.Format(entry)
+}
+
+const (
+	// logrusPackage is the prefix of the names of logrus's functions
+	logrusPackage = "github.com/sirupsen/logrus."
+	// dolthubModules is the prefix of the names of the functions of the engine's modules, such as dolt,
+	// go-mysql-server and vitess
+	dolthubModules = "github.com/dolthub/"
+)
+
+// driverPackage is the prefix of the names of the driver's own functions, which is a dolthub module too
+var driverPackage = reflect.TypeOf(engineLog{}).PkgPath() + "."
+
+// loggedByEngine returns whether the entry that logrus is logging, from a hook or formatter of logrus's standard
+// logger, was logged by the engine rather than by the application: whether the function that called logrus belongs to
+// one of dolthub's modules other than the driver.
+func loggedByEngine() bool {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	inLogrus := false
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, logrusPackage) {
+			inLogrus = true
+		} else if inLogrus {
+			return strings.HasPrefix(frame.Function, dolthubModules) && !strings.HasPrefix(frame.Function, driverPackage)
+		}
+		if !more {
+			return false
+		}
+	}
+}
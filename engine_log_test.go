@@ -0,0 +1,238 @@
+package embedded
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// logRecords is a slog.Handler keeping the records it handles.
+type logRecords struct {
+	level slog.Level
+
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *logRecords) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *logRecords) Handle(_ context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record)
+	return nil
+}
+
+func (h *logRecords) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *logRecords) WithGroup(string) slog.Handler { return h }
+
+// find returns the attributes of the first record whose message contains |message|, and whether there is one.
+func (h *logRecords) find(message string) (slog.Level, map[string]any, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, record := range h.records {
+		if strings.Contains(record.Message, message) {
+			attrs := make(map[string]any)
+			record.Attrs(func(attr slog.Attr) bool {
+				attrs[attr.Key] = attr.Value.Any()
+				return true
+			})
+			return record.Level, attrs, true
+		}
+	}
+	return 0, nil, false
+}
+
+// messageHook is a logrus hook keeping the messages of the entries it's fired with.
+type messageHook struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (h *messageHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *messageHook) Fire(entry *logrus.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, entry.Message)
+	return nil
+}
+
+// mergeWorkingSets commits concurrent transactions of |conn| and |other| inserting |ids| into |table|, whose working
+// sets the storage layer merges, logging "working set merge took" with logrus's standard logger at trace level.
+func mergeWorkingSets(t *testing.T, conn, other *sql.Conn, table string, ids [2]int) {
+	ctx := context.Background()
+	for _, stmt := range []struct {
+		conn  *sql.Conn
+		query string
+	}{
+		{conn, "begin"},
+		{other, "begin"},
+		{conn, fmt.Sprintf("replace into %s (id) values (%d)", table, ids[0])},
+		{other, fmt.Sprintf("replace into %s (id) values (%d)", table, ids[1])},
+		{conn, "commit"},
+		{other, "commit"},
+	} {
+		_, err := stmt.conn.ExecContext(ctx, stmt.query)
+		require.NoError(t, err, stmt.query)
+	}
+}
+
+func TestEngineLogger(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Stands in for stderr, which the standard logger writes to by default
+	var stderr bytes.Buffer
+	std := logrus.StandardLogger()
+	out, stdLevel := std.Out, std.GetLevel()
+	std.SetOutput(&stderr)
+	hook := &messageHook{}
+	hooks := std.ReplaceHooks(logrus.LevelHooks{})
+	std.AddHook(hook)
+	defer func() {
+		std.SetOutput(out)
+		std.SetLevel(stdLevel)
+		std.ReplaceHooks(hooks)
+	}()
+
+	records := &logRecords{level: slog.LevelDebug - 4}
+	connector, err := NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		Database:    "testdb",
+		Logger:      slog.New(records),
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connectorOnly{connector})
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	other, err := db.Conn(ctx)
+	require.NoError(t, err)
+	exec := func(conn *sql.Conn, query string) {
+		_, err := conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	exec(conn, "create database testdb")
+	exec(conn, "use testdb")
+	exec(other, "use testdb")
+	exec(conn, "create table t (id int primary key)")
+
+	// The messages of the storage layer are logged with the connector's logger, here the merge of concurrent
+	// transactions, at logrus's trace level
+	mergeWorkingSets(t, conn, other, "t", [2]int{1, 2})
+	level, _, ok := records.find("working set merge took")
+	require.True(t, ok)
+	require.Equal(t, slog.LevelDebug-4, level)
+
+	// and so are the messages of the sessions, with their fields
+	var id uint32
+	require.NoError(t, conn.Raw(func(driverConn any) error {
+		gmsCtx := driverConn.(*DoltConn).gmsCtx
+		id = gmsCtx.Session.ID()
+		gmsCtx.GetLogger().Warnf("checking %s", "sessions")
+		return nil
+	}))
+	level, attrs, ok := records.find("checking sessions")
+	require.True(t, ok)
+	require.Equal(t, slog.LevelWarn, level)
+	require.Equal(t, map[string]any{"connectionDb": "testdb", "connectionID": uint64(id)}, attrs)
+
+	// The application's own messages are still written to the standard logger's output, and passed to its hooks, at
+	// its level
+	logrus.Warn("from the application")
+	logrus.Trace("application trace")
+	_, _, ok = records.find("from the application")
+	require.False(t, ok)
+
+	require.NoError(t, conn.Close())
+	require.NoError(t, other.Close())
+	require.NoError(t, db.Close())
+	require.NoError(t, connector.Close())
+	require.Contains(t, stderr.String(), "from the application")
+	require.NotContains(t, stderr.String(), "application trace")
+	require.NotContains(t, stderr.String(), "working set merge took")
+	require.Equal(t, []string{"from the application"}, hook.messages)
+
+	// Once the connector is closed, the standard logger writes to its output again
+	logrus.Warn("after close")
+	require.Contains(t, stderr.String(), "after close")
+	_, _, ok = records.find("after close")
+	require.False(t, ok)
+	require.Equal(t, stdLevel, std.GetLevel())
+}
+
+func TestEngineLoggerLevel(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	std := logrus.StandardLogger()
+	stdLevel := std.GetLevel()
+	std.SetLevel(logrus.ErrorLevel)
+	defer std.SetLevel(stdLevel)
+
+	records := &logRecords{level: slog.LevelWarn}
+	connector, err := NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		Logger:      slog.New(records),
+	})
+	require.NoError(t, err)
+	defer connector.Close()
+
+	// The engine doesn't log the messages below the levels the handler is enabled for
+	require.Equal(t, logrus.WarnLevel, connector.log.logrus.GetLevel())
+	require.Equal(t, logrus.WarnLevel, logrus.GetLevel())
+	db := sql.OpenDB(connectorOnly{connector})
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	other, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer other.Close()
+	for _, query := range []string{"create database testdb", "create table testdb.t (id int primary key)"} {
+		_, err := conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	for _, c := range []*sql.Conn{conn, other} {
+		_, err := c.ExecContext(ctx, "use testdb")
+		require.NoError(t, err)
+	}
+	mergeWorkingSets(t, conn, other, "t", [2]int{1, 2})
+	_, _, ok := records.find("working set merge took")
+	require.False(t, ok)
+
+	// nor does the standard logger write the application's messages below its own level
+	var stderr bytes.Buffer
+	out := std.Out
+	std.SetOutput(&stderr)
+	defer std.SetOutput(out)
+	logrus.Warn("below the level")
+	logrus.Error("at the level")
+	require.NotContains(t, stderr.String(), "below the level")
+	require.Contains(t, stderr.String(), "at the level")
+	_, _, ok = records.find("at the level")
+	require.False(t, ok)
+}
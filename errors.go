@@ -12,6 +12,10 @@ func translateError(err error) error {
 	if err == nil {
 		return nil
 	}
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+		// Already translated, or one of the driver's own errors
+		return mysqlErr
+	}
 	vitessErr := sql.CastSQLError(err)
 	return &mysql.MySQLError{
 		Number:  uint16(vitessErr.Num),
@@ -0,0 +1,43 @@
+package embedded
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/eventscheduler"
+)
+
+// EventScheduler is the state of the engine's event scheduler, like MySQL's --event-scheduler option. The engine doesn't
+// support changing it at runtime by setting the event_scheduler system variable, so it is fixed when the engine opens.
+type EventScheduler string
+
+const (
+	// EventSchedulerOff doesn't run events. It is the default, since events run in the application's process.
+	EventSchedulerOff EventScheduler = "off"
+	// EventSchedulerOn runs the events created with CREATE EVENT on their schedule, for as long as the connector is open
+	EventSchedulerOn EventScheduler = "on"
+	// EventSchedulerDisabled doesn't run events, like EventSchedulerOff
+	EventSchedulerDisabled EventScheduler = "disabled"
+)
+
+// validate returns an error if |s| isn't one of the event scheduler states. The empty state is EventSchedulerOff.
+func (s EventScheduler) validate() error {
+	switch EventScheduler(strings.ToLower(string(s))) {
+	case "", EventSchedulerOff, EventSchedulerOn, EventSchedulerDisabled:
+		return nil
+	default:
+		return fmt.Errorf("unknown event scheduler state '%s'", s)
+	}
+}
+
+// status returns the engine's event scheduler status for |s|.
+func (s EventScheduler) status() eventscheduler.SchedulerStatus {
+	switch EventScheduler(strings.ToLower(string(s))) {
+	case EventSchedulerOn:
+		return eventscheduler.SchedulerOn
+	case EventSchedulerDisabled:
+		return eventscheduler.SchedulerDisabled
+	default:
+		return eventscheduler.SchedulerOff
+	}
+}
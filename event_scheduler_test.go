@@ -0,0 +1,84 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventScheduler(t *testing.T) {
+	// Lets the scheduler check for events to run every second rather than every 30 seconds
+	t.Setenv("DOLT_EVENT_SCHEDULER_PERIOD", "1")
+
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg, err := ParseDSN("file://" + dir + "?commitname=Billy%20Batson&commitemail=shazam@gmail.com&eventscheduler=on")
+	require.NoError(t, err)
+	require.Equal(t, EventSchedulerOn, cfg.EventScheduler)
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, query := range []string{
+		"create database test",
+		"use test",
+		"create table ticks (id int primary key auto_increment, at datetime)",
+		"create event tick on schedule every 1 second do insert into ticks (at) values (now())",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	require.Eventually(t, func() bool {
+		var count int
+		require.NoError(t, conn.QueryRowContext(ctx, "select count(*) from ticks").Scan(&count))
+		return count > 0
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestEventSchedulerOff(t *testing.T) {
+	t.Setenv("DOLT_EVENT_SCHEDULER_PERIOD", "1")
+
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com", EventScheduler: "sometimes"})
+	require.ErrorContains(t, err, "unknown event scheduler state 'sometimes'")
+
+	// The event scheduler is off by default, so events are created but never run
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, query := range []string{
+		"create database test",
+		"use test",
+		"create table ticks (id int primary key auto_increment, at datetime)",
+		"create event tick on schedule every 1 second do insert into ticks (at) values (now())",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	var count int
+	require.NoError(t, conn.QueryRowContext(ctx, "select count(*) from ticks").Scan(&count))
+	require.Zero(t, count)
+}
@@ -0,0 +1,172 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/datas"
+	"github.com/dolthub/dolt/go/store/hash"
+	"github.com/dolthub/dolt/go/store/types"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// FSCKReport is the result of checking the integrity of a database's storage with Connector.FSCK.
+type FSCKReport struct {
+	Database string
+	// Root is the address of the chunk holding the database's branches, tags and working sets, from which every other
+	// chunk of the database is reachable
+	Root string
+	// Chunks is the number of chunks checked
+	Chunks int
+	// Missing are the addresses of the chunks that are referenced but can't be found
+	Missing []string
+	// Corrupt are the chunks that were found but can't be read, or whose contents don't match their address
+	Corrupt []CorruptChunk
+}
+
+// CorruptChunk is a chunk of a database that can't be read, as reported by Connector.FSCK.
+type CorruptChunk struct {
+	Address string
+	Err     error
+}
+
+// OK returns whether no chunk of the database is missing or corrupt.
+func (r FSCKReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Corrupt) == 0
+}
+
+// errChunkHashMismatch is the error of a CorruptChunk whose contents don't match its address.
+var errChunkHashMismatch = errors.New("the chunk's contents don't match its address")
+
+// FSCK checks the integrity of the storage of |database|: every chunk reachable from the database's root, through
+// the history of its branches, tags and working sets, is read and its contents are checked against its address. Each
+// missing or corrupt chunk is listed in the report, so that an application can check its databases on startup and
+// offer to restore them from a backup. An error is returned if the check itself can't run, such as when the database
+// doesn't exist or |ctx| is canceled.
+//
+// Every chunk of the database is read, so this takes time proportional to the size of the database and its history.
+// Commits made while it runs aren't checked.
+func (c *Connector) FSCK(ctx context.Context, database string) (FSCKReport, error) {
+	if err := ctx.Err(); err != nil {
+		return FSCKReport{}, err
+	}
+
+	eng, gmsCtx, err := c.newDatabaseSession(ctx, database)
+	if err != nil {
+		return FSCKReport{}, err
+	}
+	defer eng.release()
+
+	dbData, ok := dsess.DSessFromSess(gmsCtx.Session).GetDbData(gmsCtx, database)
+	if !ok || dbData.Ddb == nil {
+		return FSCKReport{}, translateError(gms.ErrDatabaseNotFound.New(database))
+	}
+	cs := datas.ChunkStoreFromDatabase(doltdb.HackDatasDatabaseFromDoltDB(dbData.Ddb))
+
+	report := FSCKReport{Database: database}
+	if err := fsckChunkStore(ctx, cs, &report); err != nil {
+		return FSCKReport{}, fmt.Errorf("checking database '%s': %w", database, err)
+	}
+	return report, nil
+}
+
+// fsckChunkStore walks the chunks of |cs| reachable from its root, a level of the chunk graph at a time, adding those
+// that are missing or corrupt to |report|.
+func fsckChunkStore(ctx context.Context, cs chunks.ChunkStore, report *FSCKReport) error {
+	walkAddrs, err := types.WalkAddrsForChunkStore(cs)
+	if err != nil {
+		return err
+	}
+	root, err := cs.Root(ctx)
+	if err != nil {
+		return err
+	}
+	report.Root = root.String()
+	if root.IsEmpty() {
+		return nil
+	}
+
+	visited := hash.NewHashSet(root)
+	level := hash.NewHashSet(root)
+	for len(level) > 0 {
+		found, err := fsckGetChunks(ctx, cs, level, report)
+		if err != nil {
+			return err
+		}
+
+		next := hash.NewHashSet()
+		for _, chunk := range found {
+			err := walkAddrs(chunk, func(addr hash.Hash, _ bool) error {
+				if !visited.Has(addr) {
+					visited.Insert(addr)
+					next.Insert(addr)
+				}
+				return nil
+			})
+			if err != nil {
+				report.Corrupt = append(report.Corrupt, CorruptChunk{Address: chunk.Hash().String(), Err: err})
+			}
+		}
+		level = next
+	}
+
+	sort.Strings(report.Missing)
+	sort.Slice(report.Corrupt, func(i, j int) bool { return report.Corrupt[i].Address < report.Corrupt[j].Address })
+	return nil
+}
+
+// fsckGetChunks reads the chunks |addrs|, returning those that are intact and adding the others to |report|. If the
+// chunks can't be read together, they are read one at a time to find those that can't be read.
+func fsckGetChunks(ctx context.Context, cs chunks.ChunkStore, addrs hash.HashSet, report *FSCKReport) ([]chunks.Chunk, error) {
+	report.Chunks += len(addrs)
+
+	read := make(map[hash.Hash][]byte, len(addrs))
+	unreadable := hash.NewHashSet()
+	var mu sync.Mutex
+	err := cs.GetMany(ctx, addrs, func(_ context.Context, chunk *chunks.Chunk) {
+		// Chunks may be found concurrently
+		mu.Lock()
+		defer mu.Unlock()
+		read[chunk.Hash()] = chunk.Data()
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		clear(read)
+		for addr := range addrs {
+			chunk, err := cs.Get(ctx, addr)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			} else if err != nil {
+				report.Corrupt = append(report.Corrupt, CorruptChunk{Address: addr.String(), Err: err})
+				unreadable.Insert(addr)
+				continue
+			}
+			if !chunk.IsEmpty() {
+				read[addr] = chunk.Data()
+			}
+		}
+	}
+
+	var found []chunks.Chunk
+	for addr := range addrs {
+		data, ok := read[addr]
+		switch {
+		case unreadable.Has(addr):
+		case !ok:
+			report.Missing = append(report.Missing, addr.String())
+		case hash.Of(data) != addr:
+			report.Corrupt = append(report.Corrupt, CorruptChunk{Address: addr.String(), Err: errChunkHashMismatch})
+		default:
+			found = append(found, chunks.NewChunkWithHash(addr, data))
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,109 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/datas"
+	"github.com/dolthub/dolt/go/store/hash"
+	"github.com/dolthub/dolt/go/store/types"
+	"github.com/stretchr/testify/require"
+)
+
+// faultyChunkStore is a chunk store that has lost one chunk, and returns the wrong contents for another.
+type faultyChunkStore struct {
+	chunks.ChunkStore
+	missing, corrupt hash.Hash
+}
+
+func (cs faultyChunkStore) Get(ctx context.Context, h hash.Hash) (chunks.Chunk, error) {
+	switch h {
+	case cs.missing:
+		return chunks.EmptyChunk, nil
+	case cs.corrupt:
+		return chunks.NewChunkWithHash(h, []byte("garbage")), nil
+	}
+	return cs.ChunkStore.Get(ctx, h)
+}
+
+func (cs faultyChunkStore) GetMany(ctx context.Context, hashes hash.HashSet, found func(context.Context, *chunks.Chunk)) error {
+	return cs.ChunkStore.GetMany(ctx, hashes, func(ctx context.Context, c *chunks.Chunk) {
+		switch c.Hash() {
+		case cs.missing:
+		case cs.corrupt:
+			garbage := chunks.NewChunkWithHash(c.Hash(), []byte("garbage"))
+			found(ctx, &garbage)
+		default:
+			found(ctx, c)
+		}
+	})
+}
+
+func TestFSCK(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+	require.NoError(t, err)
+	defer connector.Close()
+	db := sql.OpenDB(connectorOnly{connector})
+	defer db.Close()
+	for _, query := range []string{
+		"create database testdb",
+		"use testdb",
+		"create table t (id int primary key, name varchar(20))",
+		"insert into t values (1, 'one'), (2, 'two')",
+		"call dolt_commit('-Am', 'first')",
+		"update t set name = 'uno' where id = 1",
+		"call dolt_commit('-am', 'second')",
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	report, err := connector.FSCK(ctx, "testdb")
+	require.NoError(t, err)
+	require.True(t, report.OK())
+	require.Equal(t, "testdb", report.Database)
+	require.NotEmpty(t, report.Root)
+	require.Greater(t, report.Chunks, 10)
+
+	_, err = connector.FSCK(ctx, "nosuchdb")
+	require.Error(t, err)
+
+	// Damage the chunks of two of the root's references
+	eng, gmsCtx, err := connector.newDatabaseSession(ctx, "testdb")
+	require.NoError(t, err)
+	defer eng.release()
+	dbData, ok := dsess.DSessFromSess(gmsCtx.Session).GetDbData(gmsCtx, "testdb")
+	require.True(t, ok)
+	cs := datas.ChunkStoreFromDatabase(doltdb.HackDatasDatabaseFromDoltDB(dbData.Ddb))
+
+	root, err := cs.Root(ctx)
+	require.NoError(t, err)
+	rootChunk, err := cs.Get(ctx, root)
+	require.NoError(t, err)
+	walkAddrs, err := types.WalkAddrsForChunkStore(cs)
+	require.NoError(t, err)
+	var refs []hash.Hash
+	require.NoError(t, walkAddrs(rootChunk, func(h hash.Hash, _ bool) error {
+		refs = append(refs, h)
+		return nil
+	}))
+	require.GreaterOrEqual(t, len(refs), 2)
+
+	damaged := FSCKReport{}
+	require.NoError(t, fsckChunkStore(ctx, faultyChunkStore{ChunkStore: cs, missing: refs[0], corrupt: refs[1]}, &damaged))
+	require.False(t, damaged.OK())
+	require.Equal(t, report.Root, damaged.Root)
+	require.Equal(t, []string{refs[0].String()}, damaged.Missing)
+	require.Equal(t, []CorruptChunk{{Address: refs[1].String(), Err: errChunkHashMismatch}}, damaged.Corrupt)
+	require.Less(t, damaged.Chunks, report.Chunks)
+}
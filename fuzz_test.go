@@ -0,0 +1,123 @@
+package embedded
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The fuzz targets check that malformed data source names and pathological SQL scripts return errors rather than
+// panicking. Their seed corpora are the f.Add calls below and the files under testdata/fuzz, which go test runs like
+// regular tests. Run a target with, for example:
+//
+//	go test -run '^$' -fuzz '^FuzzSplitStatements$' -fuzztime 1m
+
+func FuzzParseDSN(f *testing.F) {
+	for _, dsn := range []string{
+		"file:///tmp/dbs?commitname=Billy%20Batson&commitemail=shazam@gmail.com&database=testdb",
+		"file://./data?multistatements=true&clientfoundrows=true",
+		`file://C:\Users\RUNNER~1\AppData\Local\Temp\db?commitname=a`,
+		`file://\\server\share\dbs`,
+		"file:///C:/Users/dbs?maxexecutiontime=100&maxrows=-1",
+		"file:///tmp/dbs?databases=a,b&path=/x&path=/y",
+		"file:///tmp/%zz?%",
+		"file://",
+		"file:///?=&=&&;",
+		"mysql://root@localhost/db",
+	} {
+		f.Add(dsn)
+	}
+
+	f.Fuzz(func(t *testing.T, dsn string) {
+		cfg, err := ParseDSN(dsn)
+		if err != nil {
+			return
+		}
+		// A parsed configuration formats back to a data source name that parses
+		_, err = ParseDSN(cfg.FormatDSN())
+		require.NoError(t, err, "formatted %q", cfg.FormatDSN())
+	})
+}
+
+func FuzzParseDataSource(f *testing.F) {
+	for _, dsn := range []string{
+		"file:///tmp/dbs?commitname=Billy%20Batson&commitemail=shazam@gmail.com",
+		"file://localhost/tmp/dbs",
+		"file://%2Ftmp%2Fdbs?database=a",
+		"file://C:%5Cdbs",
+		"file:///tmp/dbs?a=1&a=2&A=3",
+		"file://?",
+	} {
+		f.Add(dsn)
+	}
+
+	f.Fuzz(func(t *testing.T, dsn string) {
+		ds, err := ParseDataSource(dsn)
+		if err != nil {
+			return
+		}
+		for name := range ds.Params {
+			require.Equal(t, strings.ToLower(name), name)
+		}
+	})
+}
+
+func FuzzSplitStatements(f *testing.F) {
+	for _, script := range []string{
+		"create table t (id int primary key); insert into t values (1), (2);",
+		"insert into t values ('a;b', \"c;d\"); select `x;y` from t -- comment;\n;",
+		"/*!80000 set @a = 1; */ select 1; /* ; */ select 2",
+		"create trigger tr before insert on t for each row begin set new.v = 1; set new.w = 2; end; select 1",
+		"create procedure p() begin declare x int; select x; end",
+		"select 'unterminated; select 2",
+		";;;  ;\n\t;",
+		"select (((((((((((1))))))))))",
+		"delimiter //\nselect 1//",
+		"select _utf8mb4'\xff\xfe';",
+	} {
+		f.Add(script)
+	}
+
+	f.Fuzz(func(t *testing.T, script string) {
+		stmts, err := SplitStatements(script)
+		if err != nil {
+			return
+		}
+		end := 0
+		for _, stmt := range stmts {
+			require.GreaterOrEqual(t, stmt.Start, end)
+			require.Less(t, stmt.Start, stmt.End)
+			require.LessOrEqual(t, stmt.End, len(script))
+			require.Equal(t, script[stmt.Start:stmt.End], stmt.Query)
+			end = stmt.End
+		}
+	})
+}
+
+func FuzzQuerySplitter(f *testing.F) {
+	for _, script := range []string{
+		"select 1; select 2",
+		"insert into t values ('a;b', (1, \"c;d\"));select `;`",
+		"select 'a\\'; select 2",
+		"select ((1;",
+		"\xff;\xfe",
+	} {
+		f.Add(script)
+	}
+
+	f.Fuzz(func(t *testing.T, script string) {
+		qs := NewQuerySplitter(script)
+		// Each query consumes at least a byte of the script
+		for i := 0; i <= len(script); i++ {
+			if !qs.HasMore() {
+				return
+			}
+			if _, err := qs.Next(); err == io.EOF {
+				return
+			}
+		}
+		t.Fatalf("the splitter didn't reach the end of %q", script)
+	})
+}
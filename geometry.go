@@ -0,0 +1,60 @@
+package embedded
+
+import (
+	"fmt"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/expression"
+	"github.com/dolthub/go-mysql-server/sql/expression/function/spatial"
+	"github.com/dolthub/go-mysql-server/sql/types"
+)
+
+// GeometryFormat is the format geometry values are returned in by rows.
+type GeometryFormat string
+
+const (
+	// GeometryMySQL returns geometry values as []byte in MySQL's internal format: the SRID as a 4-byte little-endian
+	// integer, followed by the WKB of the geometry. MySQL clients receive this format, so it is the default.
+	GeometryMySQL GeometryFormat = "mysql"
+	// GeometryWKB returns geometry values as []byte in the standard well-known binary format, without the SRID,
+	// like ST_AsWKB. Most Go geometry libraries decode this format.
+	GeometryWKB GeometryFormat = "wkb"
+	// GeometryWKT returns geometry values as well-known text strings, like ST_AsText
+	GeometryWKT GeometryFormat = "wkt"
+	// GeometryGeoJSON returns geometry values as GeoJSON strings, like ST_AsGeoJSON
+	GeometryGeoJSON GeometryFormat = "geojson"
+)
+
+// validate returns an error if |f| isn't one of the supported geometry formats. The empty format is GeometryMySQL.
+func (f GeometryFormat) validate() error {
+	switch f {
+	case "", GeometryMySQL, GeometryWKB, GeometryWKT, GeometryGeoJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown geometry format '%s'", f)
+	}
+}
+
+// convertGeometry returns |geom| in the format |f|. Geometries with a geographic SRID are converted with the axis order
+// of the SRID, like the corresponding MySQL functions do.
+func (f GeometryFormat) convertGeometry(ctx *gms.Context, geom types.GeometryValue) (any, error) {
+	literal := expression.NewLiteral(geom, types.GeometryType{})
+	switch f {
+	case GeometryWKB:
+		return spatial.NewAsWKB(literal).Eval(ctx, nil)
+	case GeometryWKT:
+		return spatial.NewAsWKT(literal).Eval(ctx, nil)
+	case GeometryGeoJSON:
+		asGeoJSON, err := spatial.NewAsGeoJSON(literal)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := asGeoJSON.Eval(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return doc.(types.JSONDocument).JSONString()
+	default:
+		return geom.Serialize(), nil
+	}
+}
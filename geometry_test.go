@@ -0,0 +1,92 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeometryFormat(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	open := func(format GeometryFormat) *sql.DB {
+		connector, err := NewConnector(Config{
+			Directory:      dir,
+			CommitName:     "Billy Batson",
+			CommitEmail:    "shazam@gmail.com",
+			Database:       "testdb",
+			GeometryFormat: format,
+		})
+		require.NoError(t, err)
+		return sql.OpenDB(connector)
+	}
+
+	db := open("")
+	for _, query := range []string{
+		"create database testdb",
+		"create table testdb.places (id int primary key, geom geometry)",
+		"insert into testdb.places values (1, point(5, -5)), (2, st_geomfromtext('point(1 2)', 4326))",
+	} {
+		_, err = db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	wkbPoint := func(x, y float64) []byte {
+		buf := []byte{1, 1, 0, 0, 0}
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(x))
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(y))
+	}
+	tests := []struct {
+		format GeometryFormat
+		want   []any
+	}{
+		{GeometryMySQL, []any{
+			append([]byte{0, 0, 0, 0}, wkbPoint(5, -5)...),
+			append([]byte{0xe6, 0x10, 0, 0}, wkbPoint(2, 1)...),
+		}},
+		// Like MySQL's functions, WKB and WKT use the latitude-longitude axis order of SRID 4326, and GeoJSON its
+		// longitude-latitude order
+		{GeometryWKB, []any{wkbPoint(5, -5), wkbPoint(1, 2)}},
+		{GeometryWKT, []any{"POINT(5 -5)", "POINT(1 2)"}},
+		{GeometryGeoJSON, []any{
+			`{"type": "Point", "coordinates": [5, -5]}`,
+			`{"type": "Point", "coordinates": [2, 1]}`,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			db := open(test.format)
+			defer db.Close()
+
+			rows, err := db.QueryContext(ctx, "select geom from places order by id")
+			require.NoError(t, err)
+			defer rows.Close()
+
+			var got []any
+			for rows.Next() {
+				var geom any
+				require.NoError(t, rows.Scan(&geom))
+				got = append(got, geom)
+			}
+			require.NoError(t, rows.Err())
+			require.Equal(t, test.want, got)
+		})
+	}
+
+	_, err = NewConnector(Config{
+		Directory:      dir,
+		CommitName:     "Billy Batson",
+		CommitEmail:    "shazam@gmail.com",
+		GeometryFormat: "kml",
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/store/chunks"
+)
+
+// LockStatus describes the access a database's engine has to its files.
+type LockStatus string
+
+const (
+	// LockExclusive means the engine holds the database's lock, and can write to it
+	LockExclusive LockStatus = "exclusive"
+	// LockReadOnly means another process held the database's lock when it was opened, so it is read only
+	LockReadOnly LockStatus = "read-only"
+	// LockShared means the database's files aren't locked, as with in-memory databases
+	LockShared LockStatus = "shared"
+)
+
+// HealthReport describes the state of the engine used by a sql.DB, as returned by Health.
+type HealthReport struct {
+	// EngineOpen is true if a connection to the engine could be made and pinged
+	EngineOpen bool
+	// Err is the first error encountered while building the report
+	Err error
+	// Proxied is true if the sql.DB's connections proxy to another process owning the directory in cooperative mode.
+	// The rest of the report isn't available for proxied connections.
+	Proxied bool
+	// Directory is the directory of the databases
+	Directory string
+	// DiskFree is the number of bytes available to the process on the file system of Directory, and DiskTotal its size
+	DiskFree  uint64
+	DiskTotal uint64
+	// LowDiskSpace is true if a file system of the connector's directories has less free space than
+	// Config.MinFreeDisk, so that writes fail with ErrLowDiskSpace
+	LowDiskSpace bool
+	// Databases are the reports of each dolt database, sorted by name
+	Databases []DatabaseHealth
+}
+
+// DatabaseHealth describes the state of a database, as seen by the current branch of a new connection.
+type DatabaseHealth struct {
+	Name string
+	// Lock is the access the engine has to the database's files
+	Lock LockStatus
+	// LastCommit is the time of the HEAD commit, and LastCommitAge the time elapsed since then
+	LastCommit    time.Time
+	LastCommitAge time.Duration
+	// Dirty reports whether the working set has changes that haven't been committed
+	Dirty bool
+}
+
+// Healthy returns whether the engine is open, the report was built without errors and there is enough disk space to
+// write.
+func (r HealthReport) Healthy() bool {
+	return r.EngineOpen && r.Err == nil && !r.LowDiskSpace
+}
+
+// Health returns a report on the state of the engine used by |db|, suitable for health check endpoints. Problems are
+// reported in the HealthReport rather than as an error, so that it can always be served.
+func Health(ctx context.Context, db *sql.DB) HealthReport {
+	var report HealthReport
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		report.Err = err
+		return report
+	}
+	defer conn.Close()
+
+	if report.Err = conn.PingContext(ctx); report.Err != nil {
+		return report
+	}
+	report.EngineOpen = true
+
+	report.Err = conn.Raw(func(driverConn any) error {
+		doltConn, ok := driverConn.(*DoltConn)
+		if !ok {
+			report.Proxied = true
+			return nil
+		}
+		return doltConn.health(&report)
+	})
+
+	return report
+}
+
+// health fills in |report| with the state of this connection's engine.
+func (d *DoltConn) health(report *HealthReport) error {
+	report.Directory = d.DataSource.Directory
+
+	var err error
+	if report.DiskFree, report.DiskTotal, err = diskUsage(report.Directory); err != nil {
+		return err
+	}
+	report.LowDiskSpace = d.diskGuard.low()
+
+	sess := dsess.DSessFromSess(d.gmsCtx.Session)
+	for _, db := range d.se.GetUnderlyingEngine().Analyzer.Catalog.AllDatabases(d.gmsCtx) {
+		dbData, ok := sess.GetDbData(d.gmsCtx, db.Name())
+		if !ok {
+			// not a dolt database, like information_schema
+			continue
+		}
+
+		head, commit, err := d.head(db.Name())
+		if err != nil {
+			return err
+		}
+		meta, err := commit.GetCommitMeta(d.gmsCtx)
+		if err != nil {
+			return err
+		}
+
+		dbHealth := DatabaseHealth{
+			Name:          db.Name(),
+			LastCommit:    meta.Time(),
+			LastCommitAge: d.clock.since(meta.Time()),
+			Dirty:         head.Dirty,
+		}
+		switch mode := dbData.Ddb.AccessMode(); mode {
+		case chunks.ExclusiveAccessMode_Exclusive:
+			dbHealth.Lock = LockExclusive
+		case chunks.ExclusiveAccessMode_ReadOnly:
+			dbHealth.Lock = LockReadOnly
+		case chunks.ExclusiveAccessMode_Shared:
+			dbHealth.Lock = LockShared
+		default:
+			return fmt.Errorf("unknown access mode of database %s: %d", db.Name(), mode)
+		}
+
+		report.Databases = append(report.Databases, dbHealth)
+	}
+
+	sort.Slice(report.Databases, func(i, j int) bool {
+		return report.Databases[i].Name < report.Databases[j].Name
+	})
+	return nil
+}
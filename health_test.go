@@ -0,0 +1,39 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	_, db, cleanUp := initializeTestConnector(t)
+	defer cleanUp()
+
+	ctx := context.Background()
+	report := Health(ctx, db)
+	require.NoError(t, report.Err)
+	require.True(t, report.Healthy())
+	require.False(t, report.Proxied)
+	require.NotZero(t, report.DiskFree)
+	require.Len(t, report.Databases, 1)
+
+	testdb := report.Databases[0]
+	require.Equal(t, "testdb", testdb.Name)
+	require.Equal(t, LockExclusive, testdb.Lock)
+	require.False(t, testdb.Dirty)
+	require.WithinDuration(t, time.Now(), testdb.LastCommit, time.Minute)
+
+	_, err := db.ExecContext(ctx, "create table t (pk int primary key)")
+	require.NoError(t, err)
+	report = Health(ctx, db)
+	require.NoError(t, report.Err)
+	require.True(t, report.Databases[0].Dirty)
+
+	require.NoError(t, db.Close())
+	report = Health(ctx, db)
+	require.Error(t, report.Err)
+	require.False(t, report.Healthy())
+}
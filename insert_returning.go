@@ -0,0 +1,189 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// insertReturningSavepoint is the savepoint InsertReturningIDs rolls back to when it fails inside a transaction.
+const insertReturningSavepoint = "dolt_driver_insert_returning_ids"
+
+// ErrNotInsertValues is returned by InsertReturningIDs for statements other than INSERT or REPLACE with a VALUES list.
+var ErrNotInsertValues = errors.New("only INSERT ... VALUES and REPLACE ... VALUES statements can return their inserted ids")
+
+// InsertReturningIDs executes |query|, an INSERT or REPLACE statement with a VALUES list, on |conn| and returns the
+// AUTO_INCREMENT id generated for each of its rows, in order. driver.Result only carries the id generated for the first
+// row, and the ids of a multi-row insert aren't necessarily consecutive when other sessions insert into the same table
+// concurrently, so batch inserts can't derive the others from it.
+//
+// The rows are inserted one at a time, in a transaction, or a savepoint of the connection's transaction, so that they
+// are all inserted or none are. The id of a row that didn't generate one, because it set the AUTO_INCREMENT column
+// explicitly or was ignored as a duplicate for instance, is 0. Afterwards, LAST_INSERT_ID() returns the first generated
+// id, as it does after a multi-row insert.
+func InsertReturningIDs(ctx context.Context, conn *sql.Conn, query string, args ...any) ([]int64, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		var err error
+		if values[i], err = driver.DefaultParameterConverter.ConvertValue(arg); err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i, err)
+		}
+	}
+
+	var ids []int64
+	err := conn.Raw(func(driverConn any) error {
+		doltConn, ok := driverConn.(*DoltConn)
+		if !ok {
+			return fmt.Errorf("not a dolt connection: %T", driverConn)
+		}
+
+		var err error
+		ids, err = doltConn.insertReturningIDs(ctx, query, values)
+		return err
+	})
+
+	return ids, err
+}
+
+func (d *DoltConn) insertReturningIDs(ctx context.Context, query string, args []driver.Value) (_ []int64, err error) {
+	rows, err := splitInsertRows(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	inTransaction := d.gmsCtx.GetIgnoreAutoCommit()
+	if inTransaction {
+		err = d.execControl(ctx, "SAVEPOINT "+insertReturningSavepoint)
+	} else {
+		err = d.execControl(ctx, "START TRANSACTION")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		switch {
+		case err == nil && inTransaction:
+			err = d.execControl(ctx, "RELEASE SAVEPOINT "+insertReturningSavepoint)
+		case err == nil:
+			err = d.execControl(ctx, "COMMIT")
+		case inTransaction:
+			d.execControl(ctx, "ROLLBACK TO SAVEPOINT "+insertReturningSavepoint)
+		default:
+			d.execControl(ctx, "ROLLBACK")
+		}
+	}()
+
+	lastInsertID := d.gmsCtx.Session.GetLastQueryInfoInt(gms.LastInsertId)
+	var firstID int64
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		d.gmsCtx.Session.SetLastQueryInfoInt(gms.LastInsertId, 0)
+
+		stmt, err := d.prepareSingleStatement(row.query)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = stmt.execContext(ctx, row.args); err != nil {
+			return nil, err
+		}
+
+		ids[i] = d.gmsCtx.Session.GetLastQueryInfoInt(gms.LastInsertId)
+		if firstID == 0 {
+			firstID = ids[i]
+		}
+	}
+
+	if firstID == 0 {
+		firstID = lastInsertID
+	}
+	d.gmsCtx.Session.SetLastQueryInfoInt(gms.LastInsertId, firstID)
+
+	return ids, nil
+}
+
+// insertRow is a single-row statement of a multi-row INSERT, with the arguments of its placeholders.
+type insertRow struct {
+	query string
+	args  []driver.Value
+}
+
+// splitInsertRows returns an INSERT or REPLACE statement for each row of the VALUES list of |query|, with the arguments
+// among |args| that its placeholders are bound to.
+func splitInsertRows(query string, args []driver.Value) ([]insertRow, error) {
+	parsed, err := sqlparser.Parse(query)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	insert, ok := parsed.(*sqlparser.Insert)
+	if !ok {
+		return nil, ErrNotInsertValues
+	}
+
+	var values sqlparser.AliasedValues
+	switch rows := insert.Rows.(type) {
+	case *sqlparser.AliasedValues:
+		values = *rows
+	case sqlparser.Values:
+		values = sqlparser.AliasedValues{Values: rows}
+	default:
+		return nil, ErrNotInsertValues
+	}
+
+	rows := make([]insertRow, len(values.Values))
+	for i, tuple := range values.Values {
+		row := *insert
+		values.Values = sqlparser.Values{tuple}
+		row.Rows = &values
+
+		// Reparse the row's statement, so that numbering its placeholders doesn't modify those of |insert|, which
+		// the ON DUPLICATE KEY UPDATE clause of every row shares.
+		rowStmt, err := sqlparser.Parse(sqlparser.String(&row))
+		if err != nil {
+			return nil, translateError(err)
+		}
+		if rows[i].args, err = bindRowArgs(rowStmt, args); err != nil {
+			return nil, err
+		}
+		rows[i].query = sqlparser.String(rowStmt)
+	}
+
+	return rows, nil
+}
+
+// bindRowArgs numbers the placeholders of |stmt| from 1, and returns the arguments among |args| they are bound to.
+// The placeholders of |stmt| are numbered after their position in the statement it was split from.
+func bindRowArgs(stmt sqlparser.Statement, args []driver.Value) ([]driver.Value, error) {
+	var rowArgs []driver.Value
+	err := sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		val, ok := node.(*sqlparser.SQLVal)
+		if !ok || val.Type != sqlparser.ValArg {
+			return true, nil
+		}
+
+		n, err := strconv.Atoi(strings.TrimPrefix(string(val.Val), ":v"))
+		if err != nil || n < 1 || n > len(args) {
+			return false, fmt.Errorf("no argument for placeholder %s", val.Val)
+		}
+		rowArgs = append(rowArgs, args[n-1])
+		val.Val = []byte(":v" + strconv.Itoa(len(rowArgs)))
+		return true, nil
+	}, stmt)
+
+	return rowArgs, err
+}
+
+// execControl executes a transaction control statement, which is mirrored and recorded like the statements of
+// BeginTx and doltTx.
+func (d *DoltConn) execControl(ctx context.Context, query string) error {
+	_, _, _, err := d.se.Query(d.gmsCtx, query)
+	d.shadow.mirror(ctx, query, nil, 0, err)
+	d.recorder.recordExec(query, nil, 0, err)
+	return translateError(err)
+}
@@ -0,0 +1,78 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertReturningIDs(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for _, query := range []string{
+		"create database testdb",
+		"use testdb",
+		"create table t (id int primary key auto_increment, v varchar(10))",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	count := func() int {
+		var n int
+		require.NoError(t, conn.QueryRowContext(ctx, "select count(*) from t").Scan(&n))
+		return n
+	}
+
+	ids, err := InsertReturningIDs(ctx, conn, "insert into t (v) values (?), (?), ('c')", "a", "b")
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2, 3}, ids)
+
+	// Explicit ids aren't generated, and the generated ids skip past them
+	ids, err = InsertReturningIDs(ctx, conn, "insert into t (id, v) values (null, ?), (10, ?), (null, ?)", "d", "e", "f")
+	require.NoError(t, err)
+	require.Equal(t, []int64{4, 0, 11}, ids)
+	var lastInsertID int64
+	require.NoError(t, conn.QueryRowContext(ctx, "select last_insert_id()").Scan(&lastInsertID))
+	require.Equal(t, int64(4), lastInsertID)
+	require.Equal(t, 6, count())
+
+	// A failing row undoes the whole insert
+	_, err = InsertReturningIDs(ctx, conn, "insert into t (id, v) values (null, 'g'), (1, 'h')")
+	require.Error(t, err)
+	require.Equal(t, 6, count())
+
+	// Inside a transaction, only the insert is undone
+	_, err = conn.ExecContext(ctx, "start transaction")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "insert into t (v) values ('i')")
+	require.NoError(t, err)
+	_, err = InsertReturningIDs(ctx, conn, "insert into t (id, v) values (null, 'j'), (1, 'k')")
+	require.Error(t, err)
+	require.Equal(t, 7, count())
+	_, err = conn.ExecContext(ctx, "rollback")
+	require.NoError(t, err)
+	require.Equal(t, 6, count())
+
+	_, err = InsertReturningIDs(ctx, conn, "insert into t (v) select v from t")
+	require.ErrorIs(t, err, ErrNotInsertValues)
+}
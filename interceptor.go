@@ -0,0 +1,71 @@
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// Operation identifies the driver call an Interceptor is invoked for.
+type Operation int
+
+const (
+	// OperationPrepare is a statement being prepared. In multistatements mode, it's invoked once for the whole query
+	// text before it is split into individual statements.
+	OperationPrepare Operation = iota
+	// OperationExec is a statement being executed by Exec
+	OperationExec
+	// OperationQuery is a statement being executed by Query
+	OperationQuery
+)
+
+// InterceptedCall describes a driver call about to run. Interceptors may modify it to change what happens.
+type InterceptedCall struct {
+	// Operation is the driver call being made
+	Operation Operation
+	// Query is the SQL about to run. Interceptors may rewrite it.
+	Query string
+	// Args are the values bound to the placeholders in Query. They are always empty for OperationPrepare.
+	Args []driver.Value
+
+	// Result may be set by an interceptor of an OperationExec call to return it to the caller without executing the
+	// statement.
+	Result driver.Result
+	// Rows may be set by an interceptor of an OperationQuery call to return them to the caller without executing the
+	// statement.
+	Rows driver.Rows
+}
+
+// shortCircuited returns whether an interceptor supplied the outcome of the call.
+func (call *InterceptedCall) shortCircuited() bool {
+	return call.Result != nil || call.Rows != nil
+}
+
+// Interceptor is a middleware hook invoked before the statements run by a Connector's connections are prepared and
+// executed. An interceptor can rewrite the SQL, short-circuit the call by supplying its result, or veto it by returning
+// an error, which is returned to the caller.
+type Interceptor interface {
+	Intercept(ctx context.Context, call *InterceptedCall) error
+}
+
+// InterceptorFunc adapts a function to the Interceptor interface.
+type InterceptorFunc func(ctx context.Context, call *InterceptedCall) error
+
+// Intercept calls f(ctx, call).
+func (f InterceptorFunc) Intercept(ctx context.Context, call *InterceptedCall) error {
+	return f(ctx, call)
+}
+
+// intercept runs |call| through |interceptors| in order. It stops at the first interceptor that returns an error or
+// short-circuits the call.
+func intercept(ctx context.Context, interceptors []Interceptor, call *InterceptedCall) error {
+	for _, interceptor := range interceptors {
+		if err := interceptor.Intercept(ctx, call); err != nil {
+			return err
+		}
+		if call.shortCircuited() {
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryInterceptors(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	errDropBlocked := errors.New("DROP is not allowed")
+	var ops []Operation
+
+	connector, err := NewConnector(Config{
+		Directory:       dir,
+		CommitName:      "Billy Batson",
+		CommitEmail:     "shazam@gmail.com",
+		Database:        "testdb",
+		MultiStatements: true,
+		QueryInterceptors: []Interceptor{
+			InterceptorFunc(func(ctx context.Context, call *InterceptedCall) error {
+				ops = append(ops, call.Operation)
+				return nil
+			}),
+			InterceptorFunc(func(ctx context.Context, call *InterceptedCall) error {
+				if call.Operation != OperationPrepare && strings.HasPrefix(strings.ToLower(call.Query), "drop") {
+					return errDropBlocked
+				}
+				return nil
+			}),
+			InterceptorFunc(func(ctx context.Context, call *InterceptedCall) error {
+				call.Query = strings.ReplaceAll(call.Query, "legacy_table", "t")
+				return nil
+			}),
+			InterceptorFunc(func(ctx context.Context, call *InterceptedCall) error {
+				if call.Operation == OperationQuery && call.Query == "select cached" {
+					call.Rows = &staticRows{columns: []string{"cached"}, rows: [][]driver.Value{{"hit"}}}
+				}
+				return nil
+			}),
+		},
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err = db.ExecContext(ctx, "create database testdb; create table testdb.t (id int primary key)")
+	require.NoError(t, err)
+
+	// Rewrites apply to every statement
+	_, err = db.ExecContext(ctx, "insert into legacy_table values (1), (2)")
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from legacy_table").Scan(&count))
+	require.Equal(t, 2, count)
+
+	// Vetoed statements return the interceptor's error and don't run
+	_, err = db.ExecContext(ctx, "drop table t")
+	require.ErrorIs(t, err, errDropBlocked)
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from t").Scan(&count))
+
+	// Short-circuited queries return the interceptor's rows
+	var cached string
+	require.NoError(t, db.QueryRowContext(ctx, "select cached").Scan(&cached))
+	require.Equal(t, "hit", cached)
+
+	require.Contains(t, ops, OperationPrepare)
+	require.Contains(t, ops, OperationExec)
+	require.Contains(t, ops, OperationQuery)
+}
+
+// staticRows is a driver.Rows holding a fixed result set.
+type staticRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *staticRows) Columns() []string {
+	return r.columns
+}
+
+func (r *staticRows) Close() error {
+	return nil
+}
+
+func (r *staticRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
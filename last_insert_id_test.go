@@ -0,0 +1,81 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionLastInsertID(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	other, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer other.Close()
+
+	var connID, otherID int64
+	require.NoError(t, conn.QueryRowContext(ctx, "select connection_id()").Scan(&connID))
+	require.NoError(t, other.QueryRowContext(ctx, "select connection_id()").Scan(&otherID))
+	require.NotEqual(t, connID, otherID)
+
+	for _, query := range []string{
+		"create database testdb",
+		"use testdb",
+		"create table t (id int primary key auto_increment, v int)",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+	_, err = other.ExecContext(ctx, "use testdb")
+	require.NoError(t, err)
+
+	lastInsertID := func(conn *sql.Conn) int64 {
+		var id int64
+		require.NoError(t, conn.QueryRowContext(ctx, "select last_insert_id()").Scan(&id))
+		return id
+	}
+
+	// A multi-row insert reports the first generated id, and only to its own session
+	res, err := conn.ExecContext(ctx, "insert into t (v) values (1), (2), (3)")
+	require.NoError(t, err)
+	id, err := res.LastInsertId()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), id)
+	require.Equal(t, int64(1), lastInsertID(conn))
+	require.Equal(t, int64(0), lastInsertID(other))
+
+	// Statements that don't insert rows leave it unchanged
+	res, err = conn.ExecContext(ctx, "update t set v = 4 where id = 2")
+	require.NoError(t, err)
+	id, err = res.LastInsertId()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), id)
+	require.Equal(t, int64(1), lastInsertID(conn))
+
+	// LAST_INSERT_ID(expr) sets it, and is reported in the result
+	res, err = conn.ExecContext(ctx, "set @x = last_insert_id(42)")
+	require.NoError(t, err)
+	id, err = res.LastInsertId()
+	require.NoError(t, err)
+	require.Equal(t, int64(42), id)
+	require.Equal(t, int64(42), lastInsertID(conn))
+	require.Equal(t, int64(0), lastInsertID(other))
+}
@@ -0,0 +1,56 @@
+//go:build linux
+
+package embedded
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// procLocks lists the file locks held on the host, with the process that took each of them.
+const procLocks = "/proc/locks"
+
+// lockHolder returns the process that took the flock held on the file |path|, as listed in /proc/locks, and whether it
+// could be identified. The process is the one that took the lock, even if it's gone and the lock is held by a process
+// that inherited its file descriptor.
+func lockHolder(path string) (pid int, known bool) {
+	var stat unix.Stat_t
+	if err := unix.Stat(path, &stat); err != nil {
+		return 0, false
+	}
+	file := fmt.Sprintf("%02x:%02x:%d", unix.Major(uint64(stat.Dev)), unix.Minor(uint64(stat.Dev)), stat.Ino)
+
+	f, err := os.Open(procLocks)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	// Each line is like "1: FLOCK  ADVISORY  WRITE 1234 fe:00:9618531 0 EOF", and the lines of the processes waiting
+	// for a lock have a "->" after the id
+	holders := make(map[int]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || fields[1] != "FLOCK" || fields[5] != file {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[4])
+		if err != nil || pid <= 0 {
+			return 0, false
+		}
+		holders[pid] = true
+	}
+	if scanner.Err() != nil || len(holders) != 1 {
+		return 0, false
+	}
+	for pid := range holders {
+		return pid, true
+	}
+	return 0, false
+}
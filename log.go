@@ -0,0 +1,150 @@
+package embedded
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env/actions/commitwalk"
+	"github.com/dolthub/dolt/go/store/hash"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// Commit is a commit of a dolt database, as listed by Connector.Log.
+type Commit struct {
+	Hash string
+	// Author is the name of the committer
+	Author string
+	// Email is the email of the committer
+	Email   string
+	Time    time.Time
+	Message string
+	// Parents are the hashes of the commit's parents, the first parent first. Merge commits have two parents, and the
+	// initial commit of the database has none.
+	Parents []string
+}
+
+// LogOptions selects the commits listed by Connector.Log.
+type LogOptions struct {
+	// Ref is the commit spec of the commit whose history is listed, such as a branch, a tag, a commit hash or HEAD~3.
+	// It defaults to HEAD, the head of the branch new connections use.
+	Ref string
+	// Limit is the maximum number of commits listed. There is no limit if it is zero.
+	Limit int
+	// Since leaves out the commits made before it, if it isn't zero.
+	Since time.Time
+}
+
+// LogIterator iterates over the commits listed by Connector.Log. It holds a session of the connector's engine, so it
+// must be closed.
+type LogIterator struct {
+	eng    *sharedEngine
+	gmsCtx *gms.Context
+	itr    doltdb.CommitItr
+	opts   LogOptions
+	count  int
+}
+
+// Log returns an iterator over the history of |database|, ordered like dolt log: newest first, with each commit
+// listed after all of its children. Reading the commits from storage rather than scanning the dolt_log table gives
+// their parents without parsing them.
+func (c *Connector) Log(ctx context.Context, database string, opts LogOptions) (*LogIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	eng, gmsCtx, err := c.newDatabaseSession(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := opts.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	ddb, commit, err := resolveCommit(gmsCtx, database, ref)
+	if err != nil {
+		eng.release()
+		return nil, translateError(err)
+	}
+	h, err := commit.HashOf()
+	if err != nil {
+		eng.release()
+		return nil, err
+	}
+	itr, err := commitwalk.GetTopologicalOrderIterator(gmsCtx, ddb, []hash.Hash{h}, nil)
+	if err != nil {
+		eng.release()
+		return nil, err
+	}
+
+	return &LogIterator{eng: eng, gmsCtx: gmsCtx, itr: itr, opts: opts}, nil
+}
+
+// Next returns the next commit, or io.EOF once all the commits have been listed.
+func (it *LogIterator) Next() (Commit, error) {
+	if it.eng == nil || (it.opts.Limit > 0 && it.count >= it.opts.Limit) {
+		return Commit{}, io.EOF
+	}
+
+	for {
+		h, optCmt, err := it.itr.Next(it.gmsCtx)
+		if err != nil {
+			return Commit{}, err
+		}
+		commit, ok := optCmt.ToCommit()
+		if !ok {
+			return Commit{}, doltdb.ErrGhostCommitEncountered
+		}
+		meta, err := commit.GetCommitMeta(it.gmsCtx)
+		if err != nil {
+			return Commit{}, err
+		}
+		if !it.opts.Since.IsZero() && meta.Time().Before(it.opts.Since) {
+			continue
+		}
+
+		parents, err := commit.ParentHashes(it.gmsCtx)
+		if err != nil {
+			return Commit{}, err
+		}
+		c := Commit{
+			Hash:    h.String(),
+			Author:  meta.Name,
+			Email:   meta.Email,
+			Time:    meta.Time(),
+			Message: meta.Description,
+			Parents: make([]string, len(parents)),
+		}
+		for i, p := range parents {
+			c.Parents[i] = p.String()
+		}
+
+		it.count++
+		return c, nil
+	}
+}
+
+// All returns the remaining commits.
+func (it *LogIterator) All() ([]Commit, error) {
+	var commits []Commit
+	for {
+		c, err := it.Next()
+		if err == io.EOF {
+			return commits, nil
+		} else if err != nil {
+			return nil, err
+		}
+		commits = append(commits, c)
+	}
+}
+
+// Close releases the iterator's session. Next returns io.EOF once the iterator is closed.
+func (it *LogIterator) Close() error {
+	if it.eng != nil {
+		it.eng.release()
+		it.eng = nil
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, query := range []string{
+		"create database test",
+		"use test",
+		"create table t (v int primary key)",
+		"call dolt_commit('-Am', 'create t', '--date', '2024-01-01T00:00:00Z')",
+		"call dolt_checkout('-b', 'feature')",
+		"insert into t values (1)",
+		"call dolt_commit('-am', 'feature', '--date', '2024-01-02T00:00:00Z', '--author', 'Mary Batson <mary@gmail.com>')",
+		"call dolt_checkout('main')",
+		"create table u (v int primary key)",
+		"call dolt_commit('-Am', 'create u', '--date', '2024-01-03T00:00:00Z')",
+		"call dolt_merge('feature', '--no-ff', '-m', 'merge feature')",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	it, err := connector.Log(ctx, "test", LogOptions{})
+	require.NoError(t, err)
+	commits, err := it.All()
+	require.NoError(t, err)
+	require.NoError(t, it.Close())
+
+	// The commits are in the order of the dolt_log table
+	rows, err := conn.QueryContext(ctx, "select commit_hash, committer, email, message from dolt_log")
+	require.NoError(t, err)
+	var i int
+	for ; rows.Next(); i++ {
+		var c Commit
+		require.NoError(t, rows.Scan(&c.Hash, &c.Author, &c.Email, &c.Message))
+		require.Equal(t, c.Hash, commits[i].Hash)
+		require.Equal(t, c.Author, commits[i].Author)
+		require.Equal(t, c.Email, commits[i].Email)
+		require.Equal(t, c.Message, commits[i].Message)
+	}
+	require.NoError(t, rows.Close())
+	require.Len(t, commits, i)
+
+	require.Equal(t, "merge feature", commits[0].Message)
+	require.Len(t, commits[0].Parents, 2)
+	byHash := make(map[string]Commit)
+	for _, c := range commits {
+		byHash[c.Hash] = c
+	}
+	require.Equal(t, "create u", byHash[commits[0].Parents[0]].Message)
+	require.Equal(t, "feature", byHash[commits[0].Parents[1]].Message)
+	require.Equal(t, "Mary Batson", byHash[commits[0].Parents[1]].Author)
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), byHash[commits[0].Parents[1]].Time.UTC())
+	require.Empty(t, commits[len(commits)-1].Parents)
+
+	// Ref, Limit and Since select commits
+	it, err = connector.Log(ctx, "test", LogOptions{Ref: "feature", Limit: 2})
+	require.NoError(t, err)
+	defer it.Close()
+	c, err := it.Next()
+	require.NoError(t, err)
+	require.Equal(t, "feature", c.Message)
+	c, err = it.Next()
+	require.NoError(t, err)
+	require.Equal(t, "create t", c.Message)
+	_, err = it.Next()
+	require.Equal(t, io.EOF, err)
+
+	it, err = connector.Log(ctx, "test", LogOptions{Since: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	defer it.Close()
+	since, err := it.All()
+	require.NoError(t, err)
+	require.Len(t, since, len(commits)-1)
+	for _, c := range since {
+		require.NotEqual(t, "create t", c.Message)
+	}
+
+	_, err = connector.Log(ctx, "test", LogOptions{Ref: "missing"})
+	require.Error(t, err)
+	_, err = connector.Log(ctx, "missing", LogOptions{})
+	require.Error(t, err)
+}
@@ -0,0 +1,256 @@
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// openDatabases tracks the databases the engine of a connector with Config.MaxOpenDatabases loads, in the order they
+// were last used. The engine can't load or unload databases while it is running, so the other databases are skipped
+// when it opens, and when a statement uses one of them, a new engine loading it in place of the least recently used
+// database is opened, like RefreshDatabases does. The chunk stores of the databases the new engine doesn't load are
+// closed once no engine uses them.
+type openDatabases struct {
+	c   *Connector
+	max int
+
+	mu sync.Mutex
+	// names holds the names of the databases the engine loads, the least recently used first
+	names []string
+}
+
+// newOpenDatabases returns the openDatabases of |c|, or nil if Config.MaxOpenDatabases isn't set. The engine starts
+// with Config.Database, and the first other databases in name order.
+func newOpenDatabases(c *Connector) (*openDatabases, error) {
+	cfg := c.cfg
+	if cfg.MaxOpenDatabases <= 0 {
+		return nil, nil
+	}
+
+	all, err := databaseNames(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(all)
+
+	var initial string
+	var names []string
+	for _, name := range all {
+		if strings.EqualFold(name, cfg.Database) {
+			initial = name
+		} else {
+			names = append(names, name)
+		}
+	}
+	max := int(cfg.MaxOpenDatabases)
+	if initial != "" {
+		max--
+	}
+	if len(names) > max {
+		names = names[:max]
+	}
+	if initial != "" {
+		names = append(names, initial)
+	}
+	return &openDatabases{c: c, max: int(cfg.MaxOpenDatabases), names: names}, nil
+}
+
+// databaseNames returns the names of the databases of the directories of |cfg|, leaving out the skipped ones.
+func databaseNames(cfg Config) ([]string, error) {
+	dirs, err := databaseDirsIn(cfg.directories(), cfg.Databases)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(dirs))
+	for i, dir := range dirs {
+		names[i] = dbfactory.DirToDBName(dir)
+	}
+	return names, nil
+}
+
+// index returns the position of the database |name| in o.names, or -1. The caller holds o.mu.
+func (o *openDatabases) index(name string) int {
+	for i, open := range o.names {
+		if strings.EqualFold(open, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// used records that a statement used the database |name|, which can be a revision database. A nil *openDatabases does
+// nothing.
+func (o *openDatabases) used(name string) {
+	if o == nil || name == "" {
+		return
+	}
+
+	base, _ := dsess.SplitRevisionDbName(name)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if i := o.index(base); i >= 0 {
+		o.names = append(append(o.names[:i:i], o.names[i+1:]...), o.names[i])
+	}
+}
+
+// config returns |cfg| with the databases the engine doesn't load skipped. A nil *openDatabases returns |cfg|.
+func (o *openDatabases) config(cfg Config) (Config, error) {
+	if o == nil {
+		return cfg, nil
+	}
+
+	all, err := databaseNames(cfg)
+	if err != nil {
+		return Config{}, err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	dbs := make(map[string]DatabaseConfig, len(cfg.Databases)+len(all))
+	for name, db := range cfg.Databases {
+		dbs[name] = db
+	}
+	for _, name := range all {
+		if o.index(name) < 0 {
+			db := dbs[name]
+			db.Skip = true
+			dbs[name] = db
+		}
+	}
+	cfg.Databases = dbs
+	return cfg, nil
+}
+
+// engineConfig returns the configuration the connector's engines are opened with, which skips the databases closed to
+// stay under Config.MaxOpenDatabases.
+func (c *Connector) engineConfig() (Config, error) {
+	return c.databases.config(c.cfg)
+}
+
+// open makes the connector's engine load the database |name|, which can be a revision database, if it is one of the
+// databases of the connector's directories that the engine doesn't load. It opens a new engine for new connections in
+// that case, closing the least recently used database, and returns true. Connections using the previous engine become
+// invalid, like after RefreshDatabases. A nil *openDatabases does nothing.
+func (o *openDatabases) open(ctx context.Context, name string) (bool, error) {
+	if o == nil || name == "" {
+		return false, nil
+	}
+
+	c := o.c
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.Lock()
+	proxied := c.proxy != nil
+	c.mu.Unlock()
+	if proxied {
+		return false, nil
+	}
+
+	base, _ := dsess.SplitRevisionDbName(name)
+	o.mu.Lock()
+	loaded := o.index(base) >= 0
+	o.mu.Unlock()
+	if loaded {
+		o.used(base)
+		return false, nil
+	}
+
+	all, err := databaseNames(c.cfg)
+	if err != nil {
+		return false, err
+	}
+	found := ""
+	for _, db := range all {
+		if strings.EqualFold(db, base) {
+			found = db
+		}
+	}
+	if found == "" {
+		return false, nil
+	}
+
+	o.mu.Lock()
+	previous := o.names
+	o.names = append(append([]string(nil), o.names...), found)
+	if len(o.names) > o.max {
+		o.names = o.names[len(o.names)-o.max:]
+	}
+	o.mu.Unlock()
+
+	eng, err := c.openDatabasesEngine(ctx)
+	if err != nil {
+		o.mu.Lock()
+		o.names = previous
+		o.mu.Unlock()
+		return false, err
+	}
+
+	c.mu.Lock()
+	current := c.engine
+	c.engine = eng
+	c.mu.Unlock()
+
+	var evicted []*doltdb.DoltDB
+	for path, ddb := range current.stores {
+		if eng.stores[path] != ddb {
+			evicted = append(evicted, ddb)
+		}
+	}
+	evictStores(evicted)
+	current.retire()
+
+	return true, nil
+}
+
+// openDatabasesEngine opens an engine loading the databases of c.databases.
+func (c *Connector) openDatabasesEngine(ctx context.Context) (*sharedEngine, error) {
+	fs, err := filesys.LocalFS.WithWorkingDir(c.cfg.Directory)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := c.engineConfig()
+	if err != nil {
+		return nil, err
+	}
+	return openSharedEngine(ctx, fs, cfg, c.stats)
+}
+
+// databaseNotFoundPrefix precedes the name of the database in the engine's database not found errors.
+const databaseNotFoundPrefix = "database not found: "
+
+// openMissingDatabase opens the database the statement failed to find with |err| in |gmsCtx|, if the connector closed
+// it to stay under Config.MaxOpenDatabases. It returns driver.ErrBadConn when the statement can be retried on a new
+// connection, which loads the database, so that database/sql retries it transparently. Within a transaction or a
+// multi-statement query, which can't be retried, it returns an error saying the database is open for new connections.
+// Otherwise, it returns |err| translated like the statement's other errors.
+func (stmt *doltStmt) openMissingDatabase(ctx context.Context, gmsCtx *gms.Context, err error) error {
+	if stmt.databases == nil || !gms.ErrDatabaseNotFound.Is(err) {
+		return translateError(err)
+	}
+	msg := err.Error()
+	name := msg[strings.LastIndex(msg, databaseNotFoundPrefix)+len(databaseNotFoundPrefix):]
+
+	opened, openErr := stmt.databases.open(ctx, name)
+	if openErr != nil {
+		return fmt.Errorf("opening database '%s': %w", name, openErr)
+	}
+	if !opened && !stmt.engine.isRetired() {
+		return translateError(err)
+	}
+	if stmt.multiQuery != nil || gmsCtx.GetIgnoreAutoCommit() {
+		return fmt.Errorf("database '%s' was closed to stay under the maximum number of open databases, and is "+
+			"open for new connections: %w", name, translateError(err))
+	}
+	return driver.ErrBadConn
+}
@@ -0,0 +1,74 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxOpenDatabases(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	cfg := Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	}
+	setup, err := NewConnector(cfg)
+	require.NoError(t, err)
+	setupDB := sql.OpenDB(setup)
+	for _, name := range []string{"a", "b", "c"} {
+		_, err = setupDB.ExecContext(ctx, "create database "+name)
+		require.NoError(t, err)
+		_, err = setupDB.ExecContext(ctx, "create table "+name+".t (v varchar(10) primary key)")
+		require.NoError(t, err)
+		_, err = setupDB.ExecContext(ctx, "insert into "+name+".t values (?)", name)
+		require.NoError(t, err)
+	}
+	require.NoError(t, setupDB.Close())
+
+	cfg.Database = "a"
+	cfg.MaxOpenDatabases = 2
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	dbs := showDatabases(t, db)
+	require.Contains(t, dbs, "a")
+	require.Contains(t, dbs, "b")
+	require.NotContains(t, dbs, "c")
+
+	// Using a closed database opens it in place of the least recently used one, b
+	var v string
+	require.NoError(t, db.QueryRowContext(ctx, "select v from c.t").Scan(&v))
+	require.Equal(t, "c", v)
+	dbs = showDatabases(t, db)
+	require.Contains(t, dbs, "a")
+	require.Contains(t, dbs, "c")
+	require.NotContains(t, dbs, "b")
+
+	// A statement in a transaction can't be retried on a new connection
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	err = tx.QueryRowContext(ctx, "select v from b.t").Scan(&v)
+	require.ErrorContains(t, err, "open for new connections")
+	require.NoError(t, tx.Rollback())
+	require.NoError(t, db.QueryRowContext(ctx, "select v from b.t").Scan(&v))
+	require.Equal(t, "b", v)
+
+	// Connector methods open the databases they read
+	status, err := connector.Status(ctx, "c")
+	require.NoError(t, err)
+	require.Equal(t, "c", status.Database)
+
+	// Databases that don't exist still aren't found
+	_, err = db.ExecContext(ctx, "use nosuchdb")
+	require.ErrorContains(t, err, "database not found")
+}
@@ -0,0 +1,52 @@
+package embedded
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxRowsWarningCode is the code of the warning left by a result set truncated to MaxRows rows, MySQL's generic
+// ER_UNKNOWN_ERROR, as MySQL has no code for it.
+const maxRowsWarningCode = 1105
+
+// ErrMaxRowsExceeded is returned while reading a result set with more rows than Config.MaxRows when
+// Config.MaxRowsError is set.
+var ErrMaxRowsExceeded = errors.New("result set exceeds the maximum number of rows")
+
+// rowLimit limits the number of rows returned by the queries of a connection.
+type rowLimit struct {
+	// max is the maximum number of rows of a result set, or 0 for no limit
+	max int64
+	// error fails result sets with more rows than |max| instead of truncating them
+	error bool
+}
+
+// reached returns whether a result set that returned |returned| rows can't return any more.
+func (l rowLimit) reached(returned int64) bool {
+	return l.max > 0 && returned >= l.max
+}
+
+// truncate ends the result set once it has returned the maximum number of rows. If it has more rows, it is truncated
+// with a warning, or fails with ErrMaxRowsExceeded.
+func (rows *doltRows) truncate() error {
+	_, err := rows.rowIter.Next(rows.gmsCtx)
+	if err == io.EOF {
+		rows.saveWarnings()
+		return io.EOF
+	} else if err != nil {
+		err = translateError(interruptError(rows.gmsCtx, err))
+		rows.recording.finish(err)
+		return err
+	}
+
+	if rows.rowLimit.error {
+		err = fmt.Errorf("%w: %d", ErrMaxRowsExceeded, rows.rowLimit.max)
+		rows.recording.finish(err)
+		return err
+	}
+
+	rows.gmsCtx.Warn(maxRowsWarningCode, "Result set truncated to the maximum of %d rows", rows.rowLimit.max)
+	rows.saveWarnings()
+	return io.EOF
+}
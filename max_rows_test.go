@@ -0,0 +1,88 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxRows(t *testing.T) {
+	const query = "select n from numbers order by n"
+
+	openConn := func(t *testing.T, maxRowsError bool) *sql.Conn {
+		dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		connector, err := NewConnector(Config{
+			Directory:    dir,
+			CommitName:   "Billy Batson",
+			CommitEmail:  "shazam@gmail.com",
+			MaxRows:      3,
+			MaxRowsError: maxRowsError,
+		})
+		require.NoError(t, err)
+		db := sql.OpenDB(connector)
+		t.Cleanup(func() { db.Close() })
+
+		conn, err := db.Conn(context.Background())
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		_, err = conn.ExecContext(context.Background(), "create database test")
+		require.NoError(t, err)
+		_, err = conn.ExecContext(context.Background(), "use test")
+		require.NoError(t, err)
+		_, err = conn.ExecContext(context.Background(), "create table numbers (n int primary key)")
+		require.NoError(t, err)
+		_, err = conn.ExecContext(context.Background(), "insert into numbers values (1), (2), (3), (4), (5), (6), (7), (8), (9), (10)")
+		require.NoError(t, err)
+		return conn
+	}
+
+	readAll := func(conn *sql.Conn, query string) ([]int, error) {
+		rows, err := conn.QueryContext(context.Background(), query)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var values []int
+		for rows.Next() {
+			var n int
+			if err := rows.Scan(&n); err != nil {
+				return nil, err
+			}
+			values = append(values, n)
+		}
+		return values, rows.Err()
+	}
+
+	t.Run("truncate", func(t *testing.T) {
+		conn := openConn(t, false)
+
+		values, err := readAll(conn, query)
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 2, 3}, values)
+		requireResults(t, conn, "show warnings", [][]any{{"Warning", 1105, "Result set truncated to the maximum of 3 rows"}})
+
+		// Result sets within the limit are complete, without a warning
+		values, err = readAll(conn, "select n from numbers where n <= 3 order by n")
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 2, 3}, values)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		conn := openConn(t, true)
+
+		_, err := readAll(conn, query)
+		require.ErrorIs(t, err, ErrMaxRowsExceeded)
+
+		values, err := readAll(conn, "select n from numbers where n <= 3 order by n")
+		require.NoError(t, err)
+		require.Equal(t, []int{1, 2, 3}, values)
+	})
+}
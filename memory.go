@@ -0,0 +1,82 @@
+package embedded
+
+import (
+	"runtime/metrics"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// MemoryStats is a snapshot of the memory used by a connector, as returned by Connector.MemoryStats.
+type MemoryStats struct {
+	// UsedMemory is the heap and stack memory in use by the process, in bytes, which the engine compares to
+	// MaxQueryMemory. The engine shares the process with the application, so it includes the application's memory.
+	UsedMemory uint64
+	// MaxQueryMemory is the limit set by Config.MaxQueryMemory, or by the MAX_MEMORY environment variable, in bytes, or
+	// 0 if queries aren't limited
+	MaxQueryMemory uint64
+	// QueryCaches is the number of in-memory row caches held by the statements running on the connector's
+	// connections, such as the buffers of joins, DISTINCT and GROUP BY
+	QueryCaches int
+	// OpenDatabases is the number of databases the engine loads, which is at most Config.MaxOpenDatabases if it is set
+	OpenDatabases int
+}
+
+// memoryReporter reports the memory used by the process to the engine's memory manager, which fails the statements
+// buffering rows in memory with gms.ErrNoMemoryAvailable once the used memory exceeds max, after trying to free
+// memory. It reads the runtime's metrics, which unlike runtime.ReadMemStats don't stop the world, since the memory
+// manager checks the used memory for every row it buffers.
+type memoryReporter struct {
+	max uint64
+}
+
+// memoryMetrics are the runtime metrics memoryReporter adds up: the memory of heap objects and of goroutine stacks
+var memoryMetrics = []string{"/memory/classes/heap/objects:bytes", "/memory/classes/heap/stacks:bytes"}
+
+func (r memoryReporter) MaxMemory() uint64 {
+	return r.max
+}
+
+func (r memoryReporter) UsedMemory() uint64 {
+	samples := make([]metrics.Sample, len(memoryMetrics))
+	for i, name := range memoryMetrics {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+
+	var used uint64
+	for _, sample := range samples {
+		if sample.Value.Kind() == metrics.KindUint64 {
+			used += sample.Value.Uint64()
+		}
+	}
+	return used
+}
+
+// newMemoryManager returns the memory manager shared by the sessions of a connector configured by |cfg|. Without
+// Config.MaxQueryMemory, it uses the engine's default limit, set by the MAX_MEMORY environment variable in megabytes.
+func newMemoryManager(cfg Config) *gms.MemoryManager {
+	if cfg.MaxQueryMemory <= 0 {
+		return gms.NewMemoryManager(gms.ProcessMemory)
+	}
+	return gms.NewMemoryManager(memoryReporter{max: uint64(cfg.MaxQueryMemory)})
+}
+
+// MemoryStats returns a snapshot of the memory used by the connector and the process, for embedders to check the
+// driver's footprint against the limits they configured.
+func (c *Connector) MemoryStats() MemoryStats {
+	stats := MemoryStats{
+		UsedMemory:     memoryReporter{}.UsedMemory(),
+		MaxQueryMemory: gms.ProcessMemory.MaxMemory(),
+		QueryCaches:    c.memory.NumCaches(),
+	}
+	if c.cfg.MaxQueryMemory > 0 {
+		stats.MaxQueryMemory = uint64(c.cfg.MaxQueryMemory)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.engine != nil {
+		stats.OpenDatabases = len(c.engine.stores)
+	}
+	return stats
+}
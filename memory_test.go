@@ -0,0 +1,60 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxQueryMemory(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	cfg := Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	}
+	setup, err := NewConnector(cfg)
+	require.NoError(t, err)
+	setupDB := sql.OpenDB(setup)
+	_, err = setupDB.ExecContext(ctx, "create database testdb")
+	require.NoError(t, err)
+	_, err = setupDB.ExecContext(ctx, "create table testdb.t (id int primary key, v int)")
+	require.NoError(t, err)
+	_, err = setupDB.ExecContext(ctx, "insert into testdb.t values (1, 1), (2, 1), (3, 2)")
+	require.NoError(t, err)
+	require.NoError(t, setupDB.Close())
+
+	cfg.Database = "testdb"
+	cfg.MaxQueryMemory = 1
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	stats := connector.MemoryStats()
+	require.Equal(t, uint64(1), stats.MaxQueryMemory)
+	require.Greater(t, stats.UsedMemory, uint64(0))
+	require.Equal(t, 1, stats.OpenDatabases)
+	require.Zero(t, stats.QueryCaches)
+
+	// Statements that don't buffer rows aren't limited
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from t").Scan(&count))
+	require.Equal(t, 3, count)
+
+	rows, err := db.QueryContext(ctx, "select distinct v from t")
+	if err == nil {
+		for rows.Next() {
+		}
+		err = rows.Err()
+		rows.Close()
+	}
+	require.ErrorContains(t, err, "no memory available")
+}
@@ -0,0 +1,77 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestNulls asserts that NULL values of every type are returned as nil, and can be scanned into the sql.Null* types
+// and into pointers.
+func TestNulls(t *testing.T) {
+	conn, cleanupFunc := initializeTestDatabaseConnection(t, false)
+	defer cleanupFunc()
+
+	types := []string{
+		"TINYINT", "SMALLINT UNSIGNED", "INT", "BIGINT", "BIGINT UNSIGNED", "DECIMAL(10, 2)", "FLOAT", "DOUBLE",
+		"BIT(8)", "YEAR", "DATE", "TIME", "DATETIME", "TIMESTAMP", "CHAR(3)", "VARCHAR(10)", "BINARY(3)",
+		"VARBINARY(10)", "TEXT", "BLOB", "ENUM('a', 'b')", "SET('a', 'b')", "JSON", "POINT", "GEOMETRY",
+	}
+
+	ctx := context.Background()
+	columns := make([]string, len(types))
+	for i, typ := range types {
+		columns[i] = fmt.Sprintf("c%d %s", i, typ)
+	}
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("create table nulls (id int primary key, %s)", strings.Join(columns, ", ")))
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "insert into nulls (id) values (1)")
+	require.NoError(t, err)
+
+	for i, typ := range types {
+		t.Run(typ, func(t *testing.T) {
+			query := fmt.Sprintf("select c%d from nulls", i)
+
+			var value any = "not null"
+			require.NoError(t, conn.QueryRowContext(ctx, query).Scan(&value))
+			require.Nil(t, value)
+
+			var nullString sql.NullString
+			require.NoError(t, conn.QueryRowContext(ctx, query).Scan(&nullString))
+			require.False(t, nullString.Valid)
+
+			var ptr *string
+			require.NoError(t, conn.QueryRowContext(ctx, query).Scan(&ptr))
+			require.Nil(t, ptr)
+		})
+	}
+
+	// The sql.Null* types matching the column types
+	var nullInt sql.NullInt64
+	var nullFloat sql.NullFloat64
+	var nullTime sql.NullTime
+	var nullBool sql.NullBool
+	var bytes []byte
+	require.NoError(t, conn.QueryRowContext(ctx, "select c2, c7, c12, c0, c19 from nulls").
+		Scan(&nullInt, &nullFloat, &nullTime, &nullBool, &bytes))
+	require.False(t, nullInt.Valid)
+	require.False(t, nullFloat.Valid)
+	require.False(t, nullTime.Valid)
+	require.False(t, nullBool.Valid)
+	require.Nil(t, bytes)
+
+	// NULL arguments are inserted as NULL
+	var nilTime *time.Time
+	_, err = conn.ExecContext(ctx, "insert into nulls (id, c2, c12, c20, c22) values (?, ?, ?, ?, ?)",
+		2, sql.NullInt64{}, nilTime, nil, sql.NullString{})
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, conn.QueryRowContext(ctx,
+		"select count(*) from nulls where id = 2 and c2 is null and c12 is null and c20 is null and c22 is null").Scan(&count))
+	require.Equal(t, 1, count)
+}
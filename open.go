@@ -0,0 +1,57 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// NewConnectorContext is like NewConnector, but stops waiting for the engine to open when |ctx| is done, returning
+// ctx.Err(). Opening the engine can't be interrupted part way through, so it carries on in the background, and the
+// engine is closed as soon as it has opened, releasing its locks.
+func NewConnectorContext(ctx context.Context, cfg Config) (*Connector, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type openResult struct {
+		connector *Connector
+		err       error
+	}
+
+	opened := make(chan openResult, 1)
+	go func() {
+		connector, err := NewConnector(cfg)
+		opened <- openResult{connector: connector, err: err}
+	}()
+
+	select {
+	case res := <-opened:
+		return res.connector, res.err
+	case <-ctx.Done():
+		go func() {
+			if res := <-opened; res.err == nil {
+				res.connector.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// OpenWithSignals opens the engine configured by |cfg| and returns a sql.DB using it. If the process receives SIGINT
+// or SIGTERM while the engine is opening, or |ctx| is done, the open is abandoned cleanly as described in
+// NewConnectorContext. Signal handling is restored once OpenWithSignals returns, so the application's own handlers
+// are in charge of shutting down afterward.
+func OpenWithSignals(ctx context.Context, cfg Config) (*sql.DB, error) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	connector, err := NewConnectorContext(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.OpenDB(connector), nil
+}
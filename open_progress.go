@@ -0,0 +1,131 @@
+package embedded
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+)
+
+// OpenProgress reports the progress of opening the engine to Config.OnOpenProgress.
+type OpenProgress struct {
+	// Discovered is the number of databases found in the directory
+	Discovered int
+	// Loaded is the number of databases loaded so far
+	Loaded int
+	// Loading is the name of the database being loaded, or empty once they are all loaded
+	Loading string
+	// Done is true once the engine is open, in the last report
+	Done bool
+	// Elapsed is the time since the engine started opening
+	Elapsed time.Duration
+}
+
+// Remaining returns the number of databases that haven't been loaded yet.
+func (p OpenProgress) Remaining() int {
+	return p.Discovered - p.Loaded
+}
+
+// openProgress sends the progress of an engine opening to a callback.
+type openProgress struct {
+	report   func(OpenProgress)
+	start    time.Time
+	dirs     map[string]bool
+	progress OpenProgress
+}
+
+// newOpenProgress returns an openProgress for the databases in |dirs| not skipped by |dbs| reporting to |report|, or
+// nil if |report| is nil.
+func newOpenProgress(dirs []string, dbs map[string]DatabaseConfig, report func(OpenProgress)) (*openProgress, error) {
+	if report == nil {
+		return nil, nil
+	}
+
+	dirs, err := databaseDirsIn(dirs, dbs)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &openProgress{report: report, start: time.Now(), dirs: make(map[string]bool)}
+	for _, d := range dirs {
+		p.dirs[d] = true
+	}
+	p.progress.Discovered = len(dirs)
+	p.send()
+
+	return p, nil
+}
+
+// loading records that the database in subdirectory |dir| started loading, and so that the previous one was loaded.
+// Other directories are ignored.
+func (p *openProgress) loading(dir string) {
+	if !p.dirs[dir] {
+		return
+	}
+
+	if p.progress.Loading != "" {
+		p.progress.Loaded++
+	}
+	p.progress.Loading = dbfactory.DirToDBName(dir)
+	p.send()
+}
+
+// loaded records that all the databases were loaded.
+func (p *openProgress) loaded() {
+	if p == nil {
+		return
+	}
+
+	p.progress.Loaded, p.progress.Loading = p.progress.Discovered, ""
+	p.send()
+}
+
+// done records that the engine is open.
+func (p *openProgress) done() {
+	if p == nil {
+		return
+	}
+
+	p.progress.Done = true
+	p.send()
+}
+
+func (p *openProgress) send() {
+	p.progress.Elapsed = time.Since(p.start)
+	p.report(p.progress)
+}
+
+// progressFS reports the databases env.MultiEnvForDirectory loads, which it opens with WithWorkingDir one after the
+// other.
+type progressFS struct {
+	filesys.Filesys
+	progress *openProgress
+}
+
+func (fs progressFS) WithWorkingDir(dir string) (filesys.Filesys, error) {
+	fs.progress.loading(dir)
+	return fs.Filesys.WithWorkingDir(dir)
+}
+
+// databaseDirs returns the names of the subdirectories of |dir| that are dolt databases.
+func databaseDirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, entry.Name(), dbfactory.DoltDir)); err != nil || !info.IsDir() {
+			continue
+		}
+		dirs = append(dirs, entry.Name())
+	}
+
+	return dirs, nil
+}
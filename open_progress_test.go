@@ -0,0 +1,50 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenProgress(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	}
+	setup, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(setup)
+	for _, name := range []string{"a", "b"} {
+		_, err = db.ExecContext(context.Background(), "create database "+name)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	var reports []OpenProgress
+	cfg.OnOpenProgress = func(p OpenProgress) {
+		reports = append(reports, p)
+	}
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	defer connector.Close()
+
+	for i := range reports {
+		reports[i].Elapsed = 0
+	}
+	require.Equal(t, []OpenProgress{
+		{Discovered: 2},
+		{Discovered: 2, Loading: "a"},
+		{Discovered: 2, Loaded: 1, Loading: "b"},
+		{Discovered: 2, Loaded: 2},
+		{Discovered: 2, Loaded: 2, Done: true},
+	}, reports)
+	require.Equal(t, 0, reports[len(reports)-1].Remaining())
+}
@@ -0,0 +1,54 @@
+package embedded
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// PanicError is returned in place of a panic of the engine while preparing or running a statement, or reading its
+// rows, so that engine bugs don't crash the process. The connection the panic happened on may have been left in an
+// inconsistent state, so it reports itself invalid, and the connection pool discards it rather than reusing it.
+// PanicError doesn't wrap driver.ErrBadConn, because database/sql would then retry the statement, which could have
+// partially executed, on another connection.
+type PanicError struct {
+	// Value is the value the engine panicked with
+	Value any
+	// Stack is the stack trace of the panic
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("engine panicked: %v", e.Value)
+}
+
+// Unwrap returns the value the engine panicked with if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// panicGuard recovers the panics of a connection's engine, and remembers them so that the connection is discarded.
+// It is shared by the connection and its statements and rows.
+type panicGuard struct {
+	panicked atomic.Bool
+}
+
+// recover converts a panic into a *PanicError stored in |err|. It must be deferred directly. A nil *panicGuard still
+// recovers, but doesn't remember the panic.
+func (g *panicGuard) recover(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	if g != nil {
+		g.panicked.Store(true)
+	}
+	*err = &PanicError{Value: r, Stack: string(debug.Stack())}
+}
+
+// hasPanicked returns whether a panic was recovered by the guard.
+func (g *panicGuard) hasPanicked() bool {
+	return g != nil && g.panicked.Load()
+}
@@ -0,0 +1,85 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"testing"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPanicContainment(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		QueryInterceptors: []Interceptor{
+			InterceptorFunc(func(ctx context.Context, call *InterceptedCall) error {
+				if call.Query == "select 'boom'" {
+					panic(errors.New("boom"))
+				}
+				return nil
+			}),
+		},
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	var driverConn *DoltConn
+	require.NoError(t, conn.Raw(func(c any) error {
+		driverConn = c.(*DoltConn)
+		return nil
+	}))
+
+	_, err = conn.ExecContext(ctx, "select 'boom'")
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	require.EqualError(t, errors.Unwrap(panicErr), "boom")
+	require.Contains(t, panicErr.Stack, "TestPanicContainment")
+	require.NoError(t, conn.Close())
+
+	// The connection that panicked is discarded, and the pool opens a new one
+	require.False(t, driverConn.IsValid())
+	var one int
+	require.NoError(t, db.QueryRowContext(ctx, "select 1").Scan(&one))
+	require.NoError(t, db.QueryRowContext(ctx, "select 1").Scan(&one))
+}
+
+func TestPanicContainmentInNext(t *testing.T) {
+	guard := &panicGuard{}
+	rows := &doltRows{
+		sch:     gms.Schema{{Name: "c"}},
+		rowIter: panickingRowIter{},
+		guard:   guard,
+	}
+
+	err := rows.Next(make([]driver.Value, 1))
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	require.Equal(t, "engine panicked: row iterator panicked", err.Error())
+	require.True(t, guard.hasPanicked())
+}
+
+type panickingRowIter struct{}
+
+func (panickingRowIter) Next(*gms.Context) (gms.Row, error) {
+	panic("row iterator panicked")
+}
+
+func (panickingRowIter) Close(*gms.Context) error {
+	return nil
+}
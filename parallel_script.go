@@ -0,0 +1,371 @@
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// parallelScript executes the statements of a connection's multi-statement Exec calls concurrently, with up to
+// Config.ParallelScript sessions, when they are independent INSERT statements. Statements inserting into the same
+// table, or into tables related by a foreign key, depend on each other and execute in order on the same session.
+type parallelScript struct {
+	workers int
+	// newSession creates a session on the connection's engine for a worker
+	newSession func() (*gms.Context, error)
+}
+
+// newParallelScript returns the parallelScript of a connection of |c| using |eng|, or nil if Config.ParallelScript
+// doesn't allow more than one session.
+func newParallelScript(c *Connector, eng *sharedEngine) *parallelScript {
+	if c.cfg.ParallelScript <= 1 {
+		return nil
+	}
+	return &parallelScript{
+		workers:    int(c.cfg.ParallelScript),
+		newSession: func() (*gms.Context, error) { return c.newEngineSession(eng) },
+	}
+}
+
+// tables returns the lower case names of the tables the statements of |d| insert into, or nil if they can't execute
+// concurrently. Statements can only execute concurrently with autocommit, outside of a transaction, when they are
+// all INSERT statements with literal VALUES into tables of the connection's current database, and none of them has
+// arguments. Connections that record, mirror or intercept their statements, or make Dolt commits of their transactions,
+// execute them in order, as do statements executed with a context switching their database or revision.
+func (p *parallelScript) tables(ctx context.Context, d doltMultiStmt, args []driver.Value) []string {
+	if p == nil || len(d.stmts) < 2 || len(args) > 0 {
+		return nil
+	}
+	if ContextDatabase(ctx) != "" || ContextBranch(ctx) != "" || ctx.Value(asOfKey{}) != nil {
+		return nil
+	}
+	first := d.stmts[0]
+	if first.shadow != nil || first.recorder != nil || len(first.interceptors) > 0 || first.commitMessages != nil ||
+		first.gmsCtx.GetIgnoreAutoCommit() {
+		return nil
+	}
+	gmsCtx := first.gmsCtx
+	if autocommit, err := gmsCtx.GetSessionVariable(gmsCtx, gms.AutoCommitSessionVar); err != nil {
+		return nil
+	} else if on, err := gms.ConvertToBool(gmsCtx, autocommit); err != nil || !on {
+		return nil
+	}
+	database := gmsCtx.GetCurrentDatabase()
+	if database == "" {
+		return nil
+	}
+
+	tables := make([]string, len(d.stmts))
+	for i, stmt := range d.stmts {
+		table, ok := insertTable(stmt.query, database)
+		if !ok {
+			return nil
+		}
+		tables[i] = table
+	}
+	return tables
+}
+
+// groups returns the indexes of the statements inserting into each of |tables|, grouping the tables related by
+// foreign keys, which are inserted into in order.
+func groups(tables []string, related map[string]string) [][]int {
+	groupOf := make(map[string]int)
+	var groups [][]int
+	for i, table := range tables {
+		key := related[table]
+		g, ok := groupOf[key]
+		if !ok {
+			g = len(groups)
+			groupOf[key] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// insertTable returns the lower case name of the table |query| inserts into, if it is an INSERT statement with literal
+// VALUES into a table of |database|.
+func insertTable(query, database string) (string, bool) {
+	parsed, err := sqlparser.Parse(query)
+	if err != nil {
+		return "", false
+	}
+	ins, ok := parsed.(*sqlparser.Insert)
+	if !ok || ins.With != nil {
+		return "", false
+	}
+	if qualifier := ins.Table.DbQualifier.String(); qualifier != "" && !strings.EqualFold(qualifier, database) {
+		return "", false
+	}
+
+	var values sqlparser.Values
+	switch rows := ins.Rows.(type) {
+	case sqlparser.Values:
+		values = rows
+	case *sqlparser.AliasedValues:
+		values = rows.Values
+	default:
+		return "", false
+	}
+	for _, tuple := range values {
+		for _, expr := range tuple {
+			if !literalExpr(expr) {
+				return "", false
+			}
+		}
+	}
+	return strings.ToLower(ins.Table.Name.String()), true
+}
+
+// literalExpr returns whether |expr| is a literal value, which doesn't depend on other statements.
+func literalExpr(expr sqlparser.Expr) bool {
+	switch expr := expr.(type) {
+	case *sqlparser.SQLVal, *sqlparser.NullVal, sqlparser.BoolVal, *sqlparser.Default:
+		return true
+	case *sqlparser.UnaryExpr:
+		return literalExpr(expr.Expr)
+	default:
+		return false
+	}
+}
+
+// relatedTables returns, for each of |tables| in |database|, the first of the tables it is related to by foreign
+// keys, directly or not, so that related tables can be inserted into in order. It returns false if the database has
+// triggers, which can write to any table.
+func relatedTables(catalog gms.Catalog, gmsCtx *gms.Context, database string, tables []string) (map[string]string, bool, error) {
+	tx, err := gmsCtx.Session.(gms.TransactionSession).StartTransaction(gmsCtx, gms.ReadOnly)
+	if err != nil {
+		return nil, false, err
+	}
+	gmsCtx.SetTransaction(tx)
+	defer func() {
+		gmsCtx.Session.(gms.TransactionSession).Rollback(gmsCtx, tx)
+		gmsCtx.SetTransaction(nil)
+	}()
+
+	db, err := catalog.Database(gmsCtx, database)
+	if err != nil {
+		return nil, false, err
+	}
+	if triggers, ok := db.(gms.TriggerDatabase); ok {
+		if defs, err := triggers.GetTriggers(gmsCtx); err != nil || len(defs) > 0 {
+			return nil, false, err
+		}
+	}
+
+	// Union the tables related by foreign keys
+	related := make(map[string]string)
+	var find func(table string) string
+	find = func(table string) string {
+		if parent, ok := related[table]; ok && parent != table {
+			root := find(parent)
+			related[table] = root
+			return root
+		}
+		related[table] = table
+		return table
+	}
+	inScript := make(map[string]bool)
+	for _, table := range tables {
+		inScript[table] = true
+		find(table)
+	}
+	for table := range inScript {
+		tbl, ok, err := db.GetTableInsensitive(gmsCtx, table)
+		if err != nil {
+			return nil, false, err
+		} else if !ok {
+			// The statement fails, in order
+			return nil, false, nil
+		}
+		fkTable, ok := tbl.(gms.ForeignKeyTable)
+		if !ok {
+			continue
+		}
+		declared, err := fkTable.GetDeclaredForeignKeys(gmsCtx)
+		if err != nil {
+			return nil, false, err
+		}
+		referenced, err := fkTable.GetReferencedForeignKeys(gmsCtx)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, fk := range append(declared, referenced...) {
+			child, parent := strings.ToLower(fk.Table), strings.ToLower(fk.ParentTable)
+			if inScript[child] && inScript[parent] {
+				related[find(child)] = find(parent)
+			}
+		}
+	}
+
+	for table := range inScript {
+		related[table] = find(table)
+	}
+	return related, true, nil
+}
+
+// exec executes the statements of |d| concurrently if they are independent INSERT statements, and returns false
+// otherwise, in which case the caller executes them in order. Each group of dependent statements executes in order
+// on a session of its own, using the connection's branch and session variables, and the result of the last statement
+// is returned, with the connection's LAST_INSERT_ID() and ROW_COUNT() set as if they had executed in order. After a
+// statement fails, the statements not started yet aren't executed, and the failed statement with the lowest index is
+// returned as a *StatementError. Statements of other groups that come after it in the query may have executed.
+func (p *parallelScript) exec(ctx context.Context, d doltMultiStmt, args []driver.Value) (driver.Result, bool, error) {
+	tables := p.tables(ctx, d, args)
+	if tables == nil {
+		return nil, false, nil
+	}
+
+	first := d.stmts[0]
+	database, err := sessionRevision(first.gmsCtx)
+	if err != nil {
+		return nil, false, translateError(err)
+	}
+	session, err := p.newSession()
+	if err != nil {
+		return nil, false, translateError(err)
+	}
+	if !copySessionVariables(first.gmsCtx, session) {
+		return nil, false, nil
+	}
+	session.SetCurrentDatabase(database)
+	session.SetQueryTime(first.gmsCtx.QueryTime())
+	related, ok, err := relatedTables(first.se.GetUnderlyingEngine().Analyzer.Catalog, session, database, tables)
+	if err != nil {
+		return nil, false, translateError(err)
+	} else if !ok {
+		return nil, false, nil
+	}
+	groups := groups(tables, related)
+	if len(groups) < 2 {
+		return nil, false, nil
+	}
+
+	workers := p.workers
+	if workers > len(groups) {
+		workers = len(groups)
+	}
+	sessions := []*gms.Context{session}
+	for len(sessions) < workers {
+		if session, err = p.newSession(); err != nil {
+			return nil, false, translateError(err)
+		}
+		if !copySessionVariables(first.gmsCtx, session) {
+			return nil, false, nil
+		}
+		session.SetCurrentDatabase(database)
+		session.SetQueryTime(first.gmsCtx.QueryTime())
+		sessions = append(sessions, session)
+	}
+
+	results := make([]driver.Result, len(d.stmts))
+	errs := make([]error, len(d.stmts))
+	// insertIDs are the IDs the statements generated, or zero
+	insertIDs := make([]int64, len(d.stmts))
+	var failed atomic.Bool
+	next := make(chan []int, len(groups))
+	for _, group := range groups {
+		next <- group
+	}
+	close(next)
+
+	var wg sync.WaitGroup
+	for _, session := range sessions {
+		wg.Add(1)
+		go func(session *gms.Context) {
+			defer wg.Done()
+			for group := range next {
+				for _, i := range group {
+					if failed.Load() {
+						return
+					}
+
+					// The statements run as part of the connection's multi-statement query, which KILL cancels
+					stmt := *d.stmts[i]
+					stmt.gmsCtx, stmt.multiQuery = session, session.WithContext(first.multiQuery.Context)
+					session.Session.SetLastQueryInfoInt(gms.LastInsertId, 0)
+					if results[i], errs[i] = stmt.execContext(ctx, args); errs[i] != nil {
+						failed.Store(true)
+						return
+					}
+					insertIDs[i] = session.Session.GetLastQueryInfoInt(gms.LastInsertId)
+				}
+			}
+		}(session)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			setLastQueryInfo(first.gmsCtx, results[:i], insertIDs[:i])
+			return nil, true, &StatementError{Index: i + 1, Query: d.stmts[i].query, Err: err}
+		}
+	}
+	setLastQueryInfo(first.gmsCtx, results, insertIDs)
+	result := results[len(results)-1]
+	if res, ok := result.(*doltResult); ok && res.last == 0 {
+		// Like the insert ID of MySQL's OK packet, and the result of executing the statements in order
+		res.last = first.gmsCtx.Session.GetLastQueryInfoInt(gms.LastInsertId)
+	}
+	return result, true, nil
+}
+
+// copySessionVariables sets the system variables of the worker session |to| to the values they have in the connection
+// session |from|, such as foreign_key_checks or sql_mode, so that the statements execute as they would on the
+// connection. Dolt's variables of the databases' heads are left alone, since the worker uses the connection's branch
+// through its revision database. It returns false if a variable can't be set, in which case the statements execute in
+// order on the connection.
+func copySessionVariables(from, to *gms.Context) bool {
+	for name, value := range from.GetAllSessionVariables() {
+		if ok, _ := dsess.IsHeadRefKey(name); ok || dsess.IsReadOnlyVersionKey(name) {
+			continue
+		}
+		if current, err := to.GetSessionVariable(to, name); err == nil && reflect.DeepEqual(current, value) {
+			continue
+		}
+		if err := to.SetSessionVariable(to, name, value); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// setLastQueryInfo sets the LAST_INSERT_ID() and ROW_COUNT() of the connection session |gmsCtx| to the values they
+// would have if the statements of |results|, which generated |insertIDs|, had executed in order on it: the ID
+// generated by the last statement that generated one, and the number of rows the last statement inserted.
+func setLastQueryInfo(gmsCtx *gms.Context, results []driver.Result, insertIDs []int64) {
+	if len(results) == 0 {
+		return
+	}
+	for i := len(insertIDs) - 1; i >= 0; i-- {
+		if insertIDs[i] != 0 {
+			gmsCtx.Session.SetLastQueryInfoInt(gms.LastInsertId, insertIDs[i])
+			break
+		}
+	}
+	if res, ok := results[len(results)-1].(*doltResult); ok {
+		gmsCtx.Session.SetLastQueryInfoInt(gms.RowCount, res.affected)
+	}
+}
+
+// sessionRevision returns the name of the revision database of the branch the session of |gmsCtx| uses in its
+// current database, so that other sessions can insert into the same branch.
+func sessionRevision(gmsCtx *gms.Context) (string, error) {
+	database := gmsCtx.GetCurrentDatabase()
+	if _, rev := dsess.SplitRevisionDbName(database); rev != "" {
+		return database, nil
+	}
+	headRef, err := dsess.DSessFromSess(gmsCtx.Session).CWBHeadRef(gmsCtx, database)
+	if err != nil {
+		return "", err
+	}
+	return database + dsess.DbRevisionDelimiter + headRef.GetPath(), nil
+}
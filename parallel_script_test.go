@@ -0,0 +1,108 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelScript(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	cfg := Config{
+		Directory:       dir,
+		CommitName:      "Billy Batson",
+		CommitEmail:     "shazam@gmail.com",
+		MultiStatements: true,
+	}
+	setup, err := NewConnector(cfg)
+	require.NoError(t, err)
+	setupDB := sql.OpenDB(setup)
+	_, err = setupDB.ExecContext(ctx, "create database testdb")
+	require.NoError(t, err)
+	for _, table := range []string{"a", "b", "c"} {
+		_, err = setupDB.ExecContext(ctx, "create table testdb."+table+" (id int primary key, v varchar(10))")
+		require.NoError(t, err)
+	}
+	for _, query := range []string{
+		"create table testdb.child (id int primary key, a_id int, foreign key (a_id) references testdb.a (id))",
+		"create table testdb.ai_a (id int primary key auto_increment, v int)",
+		"create table testdb.ai_b (id int primary key auto_increment, v int)",
+		"create table testdb.o (id int primary key)",
+		"create table testdb.ch (id int primary key, o_id int, foreign key (o_id) references testdb.o (id))",
+	} {
+		_, err = setupDB.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	require.NoError(t, setupDB.Close())
+
+	cfg.Database = "testdb"
+	cfg.ParallelScript = 4
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	// Independent inserts into different tables, and dependent ones into tables related by a foreign key
+	var script strings.Builder
+	for i := 1; i <= 20; i++ {
+		for _, table := range []string{"a", "b", "c"} {
+			fmt.Fprintf(&script, "insert into %s values (%d, '%s%d');", table, i, table, i)
+		}
+		fmt.Fprintf(&script, "insert into child values (%d, %d);", i, i)
+	}
+	res, err := db.ExecContext(ctx, script.String())
+	require.NoError(t, err)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), affected)
+
+	for _, table := range []string{"a", "b", "c", "child"} {
+		var count int
+		require.NoError(t, db.QueryRowContext(ctx, "select count(*) from "+table).Scan(&count))
+		require.Equal(t, 20, count, table)
+	}
+
+	// The failing statement with the lowest index is reported
+	_, err = db.ExecContext(ctx, "insert into a values (100, 'x'); insert into b values (1, 'dup'); insert into c values (100, 'x')")
+	var stmtErr *StatementError
+	require.True(t, errors.As(err, &stmtErr))
+	require.Equal(t, 2, stmtErr.Index)
+
+	// Statements that aren't literal inserts execute in order
+	_, err = db.ExecContext(ctx, "insert into a values (200, 'y'); update b set v = 'z' where id = 1; insert into c select 200, v from a where id = 200")
+	require.NoError(t, err)
+	var v string
+	require.NoError(t, db.QueryRowContext(ctx, "select v from c where id = 200").Scan(&v))
+	require.Equal(t, "y", v)
+
+	// The statements execute with the connection's session variables
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, "set foreign_key_checks = 0")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "insert into ch values (1, 1); insert into o values (1); insert into c values (400, 'p'); insert into o values (2)")
+	require.NoError(t, err)
+
+	// and the connection's LAST_INSERT_ID() and ROW_COUNT() are those of the last statements, as if they had executed
+	// in order on it
+	res, err = conn.ExecContext(ctx, "insert into ai_a (v) values (1); insert into ai_b (v) values (1), (2); insert into ai_a (v) values (2); insert into c values (300, 'w')")
+	require.NoError(t, err)
+	var lastInsertID, rowCount int64
+	require.NoError(t, conn.QueryRowContext(ctx, "select last_insert_id(), row_count()").Scan(&lastInsertID, &rowCount))
+	require.Equal(t, int64(2), lastInsertID)
+	require.Equal(t, int64(1), rowCount)
+	lastInsertID, err = res.LastInsertId()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), lastInsertID)
+}
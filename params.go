@@ -0,0 +1,302 @@
+package embedded
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The parameters accepted in a dolt data source name. Params describes each of them.
+const (
+	CommitNameParam       = "commitname"
+	CommitEmailParam      = "commitemail"
+	DatabaseParam         = "database"
+	MultiStatementsParam  = "multistatements"
+	ClientFoundRowsParam  = "clientfoundrows"
+	DenyWritesParam       = "denywrites"
+	UserParam             = "user"
+	PasswordParam         = "password"
+	CooperativeParam      = "cooperative"
+	VersionParam          = "version"
+	MaxExecutionTimeParam = "maxexecutiontime"
+	GeometryFormatParam   = "geometryformat"
+	MaxRowsParam          = "maxrows"
+	MaxRowsErrorParam     = "maxrowserror"
+	PathParam             = "path"
+	EventSchedulerParam   = "eventscheduler"
+	RefreshIntervalParam  = "refreshinterval"
+	MaxOpenDatabasesParam = "maxopendbs"
+	MaxQueryMemoryParam   = "maxquerymemory"
+	ParallelScriptParam   = "parallelscript"
+	CloneParam            = "clone"
+	StandbyParam          = "standby"
+	TempDirParam          = "tempdir"
+	StandbyAsyncParam     = "standbyasync"
+	SyncIntervalParam     = "syncinterval"
+	SyncRemoteParam       = "syncremote"
+	SyncModeParam         = "syncmode"
+	SyncUpstreamParam     = "syncupstream"
+	SyncConflictsParam    = "syncconflicts"
+	MinFreeDiskParam      = "minfreedisk"
+	BreakStaleLocksParam  = "breakstalelocks"
+	StmtCacheSizeParam    = "stmtcachesize"
+	CollationParam        = "collation"
+	TxCommitsParam        = "transactioncommits"
+	QuietParam            = "quiet"
+)
+
+// ParamType is the type of the value of a data source name parameter.
+type ParamType int
+
+const (
+	// ParamString parameters take any string
+	ParamString ParamType = iota
+	// ParamBool parameters are enabled by the value "true", in any case. Any other value disables them.
+	ParamBool
+	// ParamMilliseconds parameters take a whole number of milliseconds
+	ParamMilliseconds
+	// ParamInt parameters take a non-negative whole number
+	ParamInt
+	// ParamList parameters take any string, and can be repeated to give several values
+	ParamList
+)
+
+func (t ParamType) String() string {
+	switch t {
+	case ParamString:
+		return "string"
+	case ParamBool:
+		return "bool"
+	case ParamMilliseconds:
+		return "milliseconds"
+	case ParamInt:
+		return "int"
+	case ParamList:
+		return "list"
+	default:
+		return fmt.Sprintf("ParamType(%d)", int(t))
+	}
+}
+
+// Param describes a parameter accepted in a dolt data source name, and the Config field it sets.
+type Param struct {
+	Name        string
+	Type        ParamType
+	Required    bool
+	Default     string
+	Description string
+	// Secret parameters aren't included in the DoltDataSource of connections
+	Secret bool
+
+	get func(cfg *Config) string
+	set func(cfg *Config, value string) error
+	// list returns all the values of a ParamList parameter, whose |set| adds a value, and whose |get| returns the first
+	list func(cfg *Config) []string
+}
+
+// params is the single list of data source name parameters. ParseDSN, Config.dataSource and the README's parameter
+// reference are all derived from it, so adding a parameter only requires adding it here and to Config.
+var params = []Param{
+	stringParam(CommitNameParam, "The name of the committer seen in the dolt commit log",
+		func(cfg *Config) *string { return &cfg.CommitName }).required(),
+	stringParam(CommitEmailParam, "The email of the committer seen in the dolt commit log",
+		func(cfg *Config) *string { return &cfg.CommitEmail }).required(),
+	stringParam(DatabaseParam, "The initial database to connect to",
+		func(cfg *Config) *string { return &cfg.Database }),
+	boolParam(MultiStatementsParam, "If set to true, allows multiple statements in one query",
+		func(cfg *Config) *bool { return &cfg.MultiStatements }),
+	boolParam(ClientFoundRowsParam, "If set to true, returns the number of matching rows instead of the number of changed rows in UPDATE queries",
+		func(cfg *Config) *bool { return &cfg.ClientFoundRows }),
+	boolParam(DenyWritesParam, "If set to true, rejects any statement that could modify data or schema",
+		func(cfg *Config) *bool { return &cfg.DenyWrites }),
+	stringParam(UserParam, "The user to connect as when access control is enabled with Config.Users",
+		func(cfg *Config) *string { return &cfg.User }),
+	stringParam(PasswordParam, "The password of user",
+		func(cfg *Config) *string { return &cfg.Password }).secret(),
+	boolParam(CooperativeParam, "If set to true, lets several processes use the directory at once by proxying to the first one",
+		func(cfg *Config) *bool { return &cfg.Cooperative }),
+	stringParam(VersionParam, "The dolt version the engine reports itself as",
+		func(cfg *Config) *string { return &cfg.Version }).withDefault(defaultDoltVersion),
+	millisecondsParam(MaxExecutionTimeParam, "The maximum execution time of SELECT statements in milliseconds, or 0 for no limit",
+		func(cfg *Config) *time.Duration { return &cfg.MaxExecutionTime }),
+	stringParam(GeometryFormatParam, "The format geometry values are returned in: mysql, wkb, wkt or geojson",
+		func(cfg *Config) *string { return (*string)(&cfg.GeometryFormat) }).withDefault(string(GeometryMySQL)),
+	intParam(MaxRowsParam, "The maximum number of rows returned by a query, or 0 for no limit",
+		func(cfg *Config) *int64 { return &cfg.MaxRows }),
+	boolParam(MaxRowsErrorParam, "If set to true, queries returning more than maxrows rows fail instead of being truncated",
+		func(cfg *Config) *bool { return &cfg.MaxRowsError }),
+	listParam(PathParam, "Another directory whose subdirectories are dolt databases to serve. Can be repeated",
+		func(cfg *Config) *[]string { return &cfg.Directories }),
+	stringParam(EventSchedulerParam, "The state the event scheduler starts in: on, off or disabled",
+		func(cfg *Config) *string { return (*string)(&cfg.EventScheduler) }).withDefault(string(EventSchedulerOff)),
+	millisecondsParam(RefreshIntervalParam, "The minimum interval in milliseconds between two checks for commits other processes made to databases opened read-only, or 0 to not check",
+		func(cfg *Config) *time.Duration { return &cfg.RefreshInterval }),
+	intParam(MaxOpenDatabasesParam, "The maximum number of databases the engine keeps open, closing the least recently used ones, or 0 for no limit",
+		func(cfg *Config) *int64 { return &cfg.MaxOpenDatabases }),
+	intParam(MaxQueryMemoryParam, "The memory in bytes above which statements buffering rows in memory fail, or 0 for the MAX_MEMORY environment variable",
+		func(cfg *Config) *int64 { return &cfg.MaxQueryMemory }),
+	intParam(ParallelScriptParam, "The number of sessions executing the independent INSERT statements of a multi-statement Exec concurrently",
+		func(cfg *Config) *int64 { return &cfg.ParallelScript }),
+	stringParam(CloneParam, "The URL of a remote database to clone into the directory on first open, and fast-forward to on later opens",
+		func(cfg *Config) *string { return &cfg.Clone }),
+	stringParam(TempDirParam, "The directory the engine writes scratch files to, instead of the system's temp directory",
+		func(cfg *Config) *string { return &cfg.TempDir }),
+	stringParam(StandbyParam, "The URL of a remote, such as a dolt sql-server, that commits are pushed to as they are made, followed by the name of each database",
+		func(cfg *Config) *string { return &cfg.Standby }),
+	boolParam(StandbyAsyncParam, "If set to true, pushes commits to the standby in the background",
+		func(cfg *Config) *bool { return &cfg.StandbyAsync }),
+	millisecondsParam(SyncIntervalParam, "The interval in milliseconds at which databases are synced with their remote in the background, or 0 to not sync",
+		func(cfg *Config) *time.Duration { return &cfg.SyncInterval }),
+	stringParam(SyncRemoteParam, "The name of the remote databases are synced with",
+		func(cfg *Config) *string { return &cfg.SyncRemote }).withDefault(defaultSyncRemote),
+	stringParam(SyncModeParam, "The direction of syncs: pull, push or both",
+		func(cfg *Config) *string { return (*string)(&cfg.SyncMode) }).withDefault(string(SyncPull)),
+	stringParam(SyncUpstreamParam, "The branch of the remote that databases are synced with, if not the local branch",
+		func(cfg *Config) *string { return &cfg.SyncUpstream }),
+	stringParam(SyncConflictsParam, "The policy for the conflicts a sync runs into: fail, ours or theirs",
+		func(cfg *Config) *string { return (*string)(&cfg.SyncConflicts) }).withDefault(string(SyncConflictsFail)),
+	intParam(MinFreeDiskParam, "The bytes of free disk space below which writes fail, or 0 to not check",
+		func(cfg *Config) *int64 { return &cfg.MinFreeDisk }),
+	boolParam(BreakStaleLocksParam, "If set to true, breaks the database locks held on behalf of processes that are gone when the engine opens",
+		func(cfg *Config) *bool { return &cfg.BreakStaleLocks }),
+	intParam(StmtCacheSizeParam, "The number of statements executed with arguments whose parsed form each connection caches, or 0 for 256",
+		func(cfg *Config) *int64 { return &cfg.StatementCacheSize }),
+	stringParam(CollationParam, "The collation of the connections, and the default collation of the databases they create, such as utf8mb4_0900_ai_ci",
+		func(cfg *Config) *string { return &cfg.Collation }),
+	boolParam(TxCommitsParam, "If set to true, each transaction that changes data makes a Dolt commit, with the message of a /* dolt:msg: ... */ comment",
+		func(cfg *Config) *bool { return &cfg.TransactionCommits }),
+	boolParam(QuietParam, "If set to true, the engine never writes to stdout or stderr",
+		func(cfg *Config) *bool { return &cfg.Quiet }),
+}
+
+// Params returns the descriptions of all the parameters accepted in a dolt data source name.
+func Params() []Param {
+	return append([]Param(nil), params...)
+}
+
+func stringParam(name, description string, field func(cfg *Config) *string) Param {
+	return Param{
+		Name:        name,
+		Type:        ParamString,
+		Description: description,
+		get:         func(cfg *Config) string { return *field(cfg) },
+		set: func(cfg *Config, value string) error {
+			*field(cfg) = value
+			return nil
+		},
+	}
+}
+
+func boolParam(name, description string, field func(cfg *Config) *bool) Param {
+	return Param{
+		Name:        name,
+		Type:        ParamBool,
+		Default:     "false",
+		Description: description,
+		get: func(cfg *Config) string {
+			if *field(cfg) {
+				return "true"
+			}
+			return ""
+		},
+		set: func(cfg *Config, value string) error {
+			*field(cfg) = parseBoolParam(value)
+			return nil
+		},
+	}
+}
+
+func millisecondsParam(name, description string, field func(cfg *Config) *time.Duration) Param {
+	return Param{
+		Name:        name,
+		Type:        ParamMilliseconds,
+		Default:     "0",
+		Description: description,
+		get: func(cfg *Config) string {
+			if *field(cfg) == 0 {
+				return ""
+			}
+			return strconv.FormatInt(field(cfg).Milliseconds(), 10)
+		},
+		set: func(cfg *Config, value string) error {
+			ms, err := strconv.ParseUint(value, 10, 63)
+			if err != nil {
+				return err
+			}
+			*field(cfg) = time.Duration(ms) * time.Millisecond
+			return nil
+		},
+	}
+}
+
+func intParam(name, description string, field func(cfg *Config) *int64) Param {
+	return Param{
+		Name:        name,
+		Type:        ParamInt,
+		Default:     "0",
+		Description: description,
+		get: func(cfg *Config) string {
+			if *field(cfg) == 0 {
+				return ""
+			}
+			return strconv.FormatInt(*field(cfg), 10)
+		},
+		set: func(cfg *Config, value string) error {
+			n, err := strconv.ParseUint(value, 10, 63)
+			if err != nil {
+				return err
+			}
+			*field(cfg) = int64(n)
+			return nil
+		},
+	}
+}
+
+func listParam(name, description string, field func(cfg *Config) *[]string) Param {
+	return Param{
+		Name:        name,
+		Type:        ParamList,
+		Description: description,
+		get: func(cfg *Config) string {
+			if len(*field(cfg)) == 0 {
+				return ""
+			}
+			return (*field(cfg))[0]
+		},
+		set: func(cfg *Config, value string) error {
+			*field(cfg) = append(*field(cfg), value)
+			return nil
+		},
+		list: func(cfg *Config) []string { return *field(cfg) },
+	}
+}
+
+func (p Param) required() Param {
+	p.Required = true
+	return p
+}
+
+func (p Param) secret() Param {
+	p.Secret = true
+	return p
+}
+
+func (p Param) withDefault(value string) Param {
+	p.Default = value
+	return p
+}
+
+// parseBoolParam returns the value of a ParamBool parameter set to |value|.
+func parseBoolParam(value string) bool {
+	return strings.ToLower(value) == "true"
+}
+
+// paramsReference returns the parameter reference included in the README, one "name - description" line per parameter.
+func paramsReference() string {
+	var sb strings.Builder
+	for _, p := range params {
+		fmt.Fprintf(&sb, "%s - %s\n", p.Name, p.Description)
+	}
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package embedded
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParams(t *testing.T) {
+	names := make(map[string]bool)
+	for _, p := range Params() {
+		require.Equal(t, strings.ToLower(p.Name), p.Name, "parameter names are matched in lower case")
+		require.False(t, names[p.Name], "duplicate parameter %s", p.Name)
+		names[p.Name] = true
+		require.NotEmpty(t, p.Description)
+		require.NotNil(t, p.get)
+		require.NotNil(t, p.set)
+	}
+}
+
+func TestParamsRoundTrip(t *testing.T) {
+	for _, p := range Params() {
+		t.Run(p.Name, func(t *testing.T) {
+			value := "value-of-" + p.Name
+			switch p.Type {
+			case ParamBool:
+				value = "true"
+			case ParamMilliseconds:
+				value = "1500"
+			case ParamInt:
+				value = "42"
+			}
+
+			var cfg Config
+			require.NoError(t, p.set(&cfg, value))
+			require.Equal(t, value, p.get(&cfg))
+
+			query := url.Values{
+				CommitNameParam:  []string{"Billy Batson"},
+				CommitEmailParam: []string{"shazam@gmail.com"},
+			}
+			query.Set(p.Name, value)
+			parsed, err := ParseDSN("file:///dir?" + query.Encode())
+			require.NoError(t, err)
+			require.Equal(t, value, p.get(&parsed))
+
+			_, inDataSource := parsed.dataSource().Params[p.Name]
+			require.Equal(t, !p.Secret, inDataSource)
+
+			reparsed, err := ParseDSN(parsed.FormatDSN())
+			require.NoError(t, err)
+			require.Equal(t, parsed, reparsed)
+		})
+	}
+}
+
+func TestRequiredParams(t *testing.T) {
+	for _, p := range Params() {
+		if !p.Required {
+			continue
+		}
+
+		query := url.Values{}
+		for _, other := range Params() {
+			if other.Required && other.Name != p.Name {
+				query.Set(other.Name, "value")
+			}
+		}
+		_, err := ParseDSN("file:///dir?" + query.Encode())
+		require.ErrorContains(t, err, p.Name)
+	}
+}
+
+func TestParseBoolParam(t *testing.T) {
+	require.True(t, parseBoolParam("true"))
+	require.True(t, parseBoolParam("TRUE"))
+	require.False(t, parseBoolParam("false"))
+	require.False(t, parseBoolParam("1"))
+	require.False(t, parseBoolParam(""))
+}
+
+func TestReadmeParamsReference(t *testing.T) {
+	readme, err := os.ReadFile("README.md")
+	require.NoError(t, err)
+	require.Contains(t, string(readme), paramsReference(), "update the README's parameter list from paramsReference()")
+}
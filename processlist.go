@@ -0,0 +1,130 @@
+package embedded
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrQueryInterrupted is the error returned by a statement that was canceled with KILL QUERY or KILL CONNECTION, like
+// MySQL's ER_QUERY_INTERRUPTED. Match it with errors.Is, which compares MySQL error numbers.
+var ErrQueryInterrupted = &mysql.MySQLError{
+	Number:  1317,
+	Message: "Query execution was interrupted",
+}
+
+// queryPidBase offsets the process ids of the driver's statements past those the MySQL server started by ServeMySQL
+// assigns to its own queries, since both register them in the engine's process list.
+const queryPidBase = 1 << 32
+
+var lastQueryPid atomic.Uint64
+
+// nextQueryPid returns a new process id for a statement.
+func nextQueryPid() uint64 {
+	return queryPidBase + lastQueryPid.Add(1)
+}
+
+// sessionRegistry holds the open connections of a connector by session id, so that KILL CONNECTION can find them.
+type sessionRegistry struct {
+	mu    sync.Mutex
+	conns map[uint32]*DoltConn
+}
+
+func newSessionRegistry() *sessionRegistry {
+	return &sessionRegistry{conns: make(map[uint32]*DoltConn)}
+}
+
+func (r *sessionRegistry) add(conn *DoltConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conns[conn.gmsCtx.Session.ID()] = conn
+}
+
+func (r *sessionRegistry) remove(conn *DoltConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.conns, conn.gmsCtx.Session.ID())
+}
+
+// killConnection marks the connection with session id |connID| as killed, so that the connection pool discards it. Its
+// running statement, if any, has already been canceled through the process list.
+func (r *sessionRegistry) killConnection(connID uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if conn, ok := r.conns[connID]; ok {
+		conn.killed.Store(true)
+	}
+	return nil
+}
+
+// register adds |gmsCtx|'s session to the process list of |eng|, so that it is listed by SHOW PROCESSLIST and can be
+// killed by the other sessions of the engine.
+func (r *sessionRegistry) register(eng *sharedEngine, gmsCtx *gms.Context) {
+	processList := eng.se.GetUnderlyingEngine().ProcessList
+	gmsCtx.ApplyOpts(
+		gms.WithProcessList(processList),
+		gms.WithServices(gms.Services{KillConnection: r.killConnection}),
+	)
+
+	processList.AddConnection(gmsCtx.Session.ID(), gmsCtx.Client().Address)
+	processList.ConnectionReady(gmsCtx.Session)
+}
+
+// unregister removes the connection from the process list of its engine, canceling its running statement if any.
+func (d *DoltConn) unregister() {
+	d.gmsCtx.ProcessList.RemoveConnection(d.gmsCtx.Session.ID())
+	if d.sessions != nil {
+		d.sessions.remove(d)
+	}
+}
+
+// beginQuery registers |query| as the running query of |gmsCtx|'s session in the process list, where KILL cancels it,
+// and returns the context to execute it in. The returned function ends the query, and must be called once its results
+// have been read.
+func beginQuery(gmsCtx *gms.Context, query string) (*gms.Context, func(), error) {
+	gmsCtx = gmsCtx.WithContext(gmsCtx.Context)
+	gmsCtx.ApplyOpts(gms.WithPid(nextQueryPid()))
+	gmsCtx, err := gmsCtx.ProcessList.BeginQuery(gmsCtx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return gmsCtx, func() { gmsCtx.ProcessList.EndQuery(gmsCtx) }, nil
+}
+
+// multiStatementProcessList is the process list of the statements of a multi-statement query. The engine ends the
+// query of each statement in the process list as soon as the statement completes, but the process list only tracks a
+// single query per connection, so the query is left running until the whole multi-statement query is done.
+type multiStatementProcessList struct {
+	gms.ProcessList
+}
+
+func (multiStatementProcessList) EndQuery(*gms.Context) {}
+
+// statementContext returns the context to execute |query| in. It is registered as the session's running query in the
+// process list, unless the statement is part of a multi-statement query, which is registered as a whole. If the
+// session's max_execution_time applies to |query|, it is also canceled once the query has run for that long. The
+// returned function must be called once the statement's results have been read.
+func (stmt *doltStmt) statementContext(query string) (*gms.Context, context.CancelFunc, error) {
+	gmsCtx, end := stmt.multiQuery, func() {}
+	if gmsCtx == nil {
+		var err error
+		if gmsCtx, end, err = beginQuery(stmt.gmsCtx, query); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	limit := stmt.maxExecutionTime(query)
+	if limit <= 0 {
+		return gmsCtx, end, nil
+	}
+
+	ctx, cancel := context.WithTimeout(gmsCtx.Context, limit)
+	return gmsCtx.WithContext(ctx), func() {
+		cancel()
+		end()
+	}, nil
+}
@@ -0,0 +1,95 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessListAndKill(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := context.Background()
+	victim, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer victim.Close()
+	killer, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer killer.Close()
+
+	var victimID, killerID int64
+	require.NoError(t, victim.QueryRowContext(ctx, "select connection_id()").Scan(&victimID))
+	require.NoError(t, killer.QueryRowContext(ctx, "select connection_id()").Scan(&killerID))
+	require.NotEqual(t, victimID, killerID)
+
+	const runaway = "with recursive c(n) as (select 1 union all select n + 1 from c where n < 1000) select count(*) from c a, c b, c d"
+	runInBackground := func() <-chan error {
+		errs := make(chan error, 1)
+		go func() {
+			var count int
+			errs <- victim.QueryRowContext(ctx, runaway).Scan(&count)
+		}()
+		return errs
+	}
+	waitForQuery := func() {
+		require.Eventually(t, func() bool {
+			return processInfo(t, killer, victimID) == runaway
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// Both connections are listed, and KILL QUERY cancels the victim's running query
+	errs := runInBackground()
+	waitForQuery()
+	require.Equal(t, "show processlist", processInfo(t, killer, killerID))
+	_, err = killer.ExecContext(ctx, fmt.Sprintf("kill query %d", victimID))
+	require.NoError(t, err)
+	require.ErrorIs(t, <-errs, ErrQueryInterrupted)
+
+	// The victim's connection still works after KILL QUERY, but not after KILL CONNECTION
+	var one int
+	require.NoError(t, victim.QueryRowContext(ctx, "select 1").Scan(&one))
+	errs = runInBackground()
+	waitForQuery()
+	_, err = killer.ExecContext(ctx, fmt.Sprintf("kill %d", victimID))
+	require.NoError(t, err)
+	require.ErrorIs(t, <-errs, ErrQueryInterrupted)
+	require.ErrorIs(t, victim.QueryRowContext(ctx, "select 1").Scan(&one), driver.ErrBadConn)
+}
+
+// processInfo returns the statement that the connection with |id| is running according to SHOW PROCESSLIST, as seen
+// from |conn|.
+func processInfo(t *testing.T, conn *sql.Conn, id int64) string {
+	rows, err := conn.QueryContext(context.Background(), "show processlist")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	for rows.Next() {
+		var processID int64
+		var user, host, command, state string
+		var db, info sql.NullString
+		var seconds int64
+		require.NoError(t, rows.Scan(&processID, &user, &host, &db, &command, &seconds, &state, &info))
+		if processID == id {
+			return info.String
+		}
+	}
+	require.NoError(t, rows.Err())
+	return ""
+}
@@ -51,11 +51,18 @@ func (bs *RuneStack) Peek() rune {
 	return bs.chars[l-1]
 }
 
+// QuerySplitter splits a string of queries on the semicolons outside of quotes and parentheses.
+//
+// Deprecated: QuerySplitter doesn't recognize comments, conditional comments or BEGIN ... END blocks. Use
+// SplitStatements instead.
 type QuerySplitter struct {
 	queries string
 	pos     int
 }
 
+// NewQuerySplitter returns a QuerySplitter for the queries in |str|.
+//
+// Deprecated: use SplitStatements instead.
 func NewQuerySplitter(str string) *QuerySplitter {
 	return &QuerySplitter{
 		queries: str,
@@ -0,0 +1,45 @@
+package embedded
+
+import (
+	"context"
+	"strings"
+)
+
+type queryTagsKey struct{}
+
+// WithQueryTag returns a copy of |ctx| carrying |tag|, e.g. "job=nightly-sync". Statements executed with the returned
+// context are prefixed with a comment containing their tags, and their statistics are recorded separately from the
+// same statements executed with different tags, so load can be attributed to the application features causing it.
+// Tags accumulate: a context derived from a tagged context carries the tags of both.
+func WithQueryTag(ctx context.Context, tag string) context.Context {
+	tags := QueryTags(ctx)
+	newTags := make([]string, len(tags), len(tags)+1)
+	copy(newTags, tags)
+	return context.WithValue(ctx, queryTagsKey{}, append(newTags, tag))
+}
+
+// QueryTags returns the tags added to |ctx| with WithQueryTag, in the order they were added.
+func QueryTags(ctx context.Context) []string {
+	tags, _ := ctx.Value(queryTagsKey{}).([]string)
+	return tags
+}
+
+// queryTagComment returns the tags in |ctx| formatted as the body of a SQL comment, or the empty string if |ctx| has no
+// tags.
+func queryTagComment(ctx context.Context) string {
+	tags := QueryTags(ctx)
+	if len(tags) == 0 {
+		return ""
+	}
+
+	// A tag must not be able to terminate the comment it is written into
+	return strings.ReplaceAll(strings.Join(tags, " "), "*/", "* /")
+}
+
+// tagQuery prefixes |query| with a comment containing |tagComment|.
+func tagQuery(query, tagComment string) string {
+	if tagComment == "" {
+		return query
+	}
+	return "/* " + tagComment + " */ " + query
+}
@@ -0,0 +1,47 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTagComment(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, "", queryTagComment(ctx))
+	require.Equal(t, "select 1", tagQuery("select 1", queryTagComment(ctx)))
+
+	ctx = WithQueryTag(ctx, "job=nightly-sync")
+	tagged := WithQueryTag(ctx, "evil*/tag")
+	require.Equal(t, []string{"job=nightly-sync"}, QueryTags(ctx))
+	require.Equal(t, []string{"job=nightly-sync", "evil*/tag"}, QueryTags(tagged))
+	require.Equal(t, "/* job=nightly-sync evil* /tag */ select 1", tagQuery("select 1", queryTagComment(tagged)))
+}
+
+func TestQueryTagStats(t *testing.T) {
+	connector, db, cleanupFunc := initializeTestConnector(t)
+	defer cleanupFunc()
+
+	ctx := context.Background()
+	taggedCtx := WithQueryTag(ctx, "job=nightly-sync")
+
+	_, err := db.ExecContext(ctx, "create table t (id int primary key)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(taggedCtx, "insert into t values (1)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "insert into t values (2)")
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRowContext(taggedCtx, "select count(*) from t").Scan(&count))
+	require.Equal(t, 2, count)
+
+	tags := make(map[string]int64)
+	for _, stats := range connector.Stats() {
+		if stats.Digest == "insert into t values (:v1)" {
+			tags[stats.Tag] += stats.Count
+		}
+	}
+	require.Equal(t, map[string]int64{"": 1, "job=nightly-sync": 1}, tags)
+}
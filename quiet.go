@@ -0,0 +1,78 @@
+package embedded
+
+import (
+	"io"
+	"log"
+	"sync"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+)
+
+// quietOutput silences the engine's output to stdout and stderr while a connector with Config.Quiet is open. A nil
+// *quietOutput silences nothing.
+type quietOutput struct {
+	closeOnce sync.Once
+}
+
+// quietCli is the state of the silencing of the writers of Dolt's command line package, which parts of the engine,
+// such as pushes and fetches, write their progress and warnings to. They are replaced with io.Discard while
+// connectors with Config.Quiet are open, and restored once the last of them is closed.
+var quietCli struct {
+	mu    sync.Mutex
+	count int
+
+	out io.Writer
+	err io.Writer
+}
+
+// newQuietOutput silences the engine's output if Config.Quiet is set, and returns the quietOutput restoring it once
+// the connector is closed. It returns nil otherwise.
+func newQuietOutput(cfg Config) *quietOutput {
+	if !cfg.Quiet {
+		return nil
+	}
+
+	quietCli.mu.Lock()
+	if quietCli.count == 0 {
+		quietCli.out, quietCli.err = cli.CliOut, cli.CliErr
+		cli.CliOut, cli.CliErr = io.Discard, io.Discard
+	}
+	quietCli.count++
+	quietCli.mu.Unlock()
+
+	standardLogger.mu.Lock()
+	standardLogger.quiet++
+	redirectStandardLogger()
+	standardLogger.mu.Unlock()
+
+	return &quietOutput{}
+}
+
+// close restores the engine's output once no connector with Config.Quiet is left. A nil *quietOutput does nothing.
+func (q *quietOutput) close() {
+	if q == nil {
+		return
+	}
+	q.closeOnce.Do(func() {
+		standardLogger.mu.Lock()
+		standardLogger.quiet--
+		redirectStandardLogger()
+		standardLogger.mu.Unlock()
+
+		quietCli.mu.Lock()
+		quietCli.count--
+		if quietCli.count == 0 {
+			cli.CliOut, cli.CliErr = quietCli.out, quietCli.err
+			quietCli.out, quietCli.err = nil, nil
+		}
+		quietCli.mu.Unlock()
+	})
+}
+
+// printf logs a message of the driver itself with the standard library's logger, unless Config.Quiet is set.
+func (cfg Config) printf(format string, args ...any) {
+	if cfg.Quiet {
+		return
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,101 @@
+package embedded
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuiet(t *testing.T) {
+	ctx := context.Background()
+	tempDir := func() string {
+		dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		return dir
+	}
+
+	// Stand in for stdout and stderr, which the engine writes to by default
+	var stdout, stderr, logs bytes.Buffer
+	out, errOut := cli.CliOut, cli.CliErr
+	cli.CliOut, cli.CliErr = &stdout, &stderr
+	std := logrus.StandardLogger()
+	logOut, logLevel := std.Out, std.GetLevel()
+	std.SetOutput(&logs)
+	std.SetLevel(logrus.TraceLevel)
+	defer func() {
+		cli.CliOut, cli.CliErr = out, errOut
+		std.SetOutput(logOut)
+		std.SetLevel(logLevel)
+	}()
+
+	dir := tempDir()
+	remote := "file://" + filepath.ToSlash(filepath.Join(tempDir(), "jails"))
+	push := func(quiet bool) {
+		query := url.Values{
+			CommitNameParam:  []string{"Billy Batson"},
+			CommitEmailParam: []string{"shazam@gmail.com"},
+		}
+		if quiet {
+			query.Set(QuietParam, "true")
+		}
+		dsn := url.URL{Scheme: "file", Path: encodeDir(dir), RawQuery: query.Encode()}
+		db, err := sql.Open(DoltDriverName, dsn.String())
+		require.NoError(t, err)
+		defer db.Close()
+
+		conn, err := db.Conn(ctx)
+		require.NoError(t, err)
+		defer conn.Close()
+		for _, query := range []string{
+			"create database if not exists src",
+			"use src",
+			"create table if not exists inmates (id int primary key, name varchar(20))",
+			"replace into inmates values (1, 'Al')",
+			"call dolt_commit('-Am', 'first inmate', '--allow-empty')",
+			"call dolt_remote('add', 'origin', '" + remote + "')",
+			"call dolt_push('origin', 'main')",
+		} {
+			_, err := conn.ExecContext(ctx, query)
+			require.NoError(t, err, query)
+		}
+		other, err := db.Conn(ctx)
+		require.NoError(t, err)
+		defer other.Close()
+		_, err = other.ExecContext(ctx, "use src")
+		require.NoError(t, err)
+		mergeWorkingSets(t, conn, other, "inmates", [2]int{2, 3})
+		logrus.Warn("while open")
+		_, err = conn.ExecContext(ctx, "call dolt_remote('remove', 'origin')")
+		require.NoError(t, err)
+	}
+
+	// Pushes write their progress to stdout, and the engine logs with the standard logger
+	push(false)
+	require.NotEmpty(t, stdout.String())
+	require.Contains(t, logs.String(), "working set merge took")
+	require.Contains(t, logs.String(), "while open")
+
+	// but not with quiet, and neither does anything else, while the application's own messages are still logged
+	stdout.Reset()
+	logs.Reset()
+	push(true)
+	require.Empty(t, stdout.String())
+	require.Empty(t, stderr.String())
+	require.NotContains(t, logs.String(), "working set merge took")
+	require.Contains(t, logs.String(), "while open")
+
+	// The outputs are restored once the connector is closed
+	require.Same(t, &stdout, cli.CliOut)
+	require.Same(t, &stderr, cli.CliErr)
+	logrus.Warn("after close")
+	require.Contains(t, logs.String(), "after close")
+}
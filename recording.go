@@ -0,0 +1,341 @@
+package embedded
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RecordedStatement is a statement captured by recording mode, enabled with Config.RecordTo. Recordings are written
+// as one JSON object per line and can be re-executed with Replay.
+type RecordedStatement struct {
+	// Conn identifies the connection that executed the statement, so that replay can reproduce session state
+	Conn uint64 `json:"conn"`
+	// Operation is the driver call that executed the statement, OperationExec or OperationQuery
+	Operation Operation `json:"op"`
+	// Query is the statement that was executed, after any interceptors rewrote it
+	Query string `json:"query"`
+	// Args are the values bound to the statement's placeholders
+	Args []RecordedValue `json:"args,omitempty"`
+	// RowsAffected is the number of rows affected by an OperationExec statement
+	RowsAffected int64 `json:"rows_affected,omitempty"`
+	// Rows is the number of rows read from an OperationQuery statement's result set
+	Rows int64 `json:"rows,omitempty"`
+	// ResultDigest is a digest of the rows read from an OperationQuery statement's result set. It identifies the
+	// result without recording the data itself.
+	ResultDigest string `json:"result_digest,omitempty"`
+	// Err is the error returned by the statement, if any
+	Err string `json:"err,omitempty"`
+}
+
+// RecordedValue is a statement argument in a recording, tagged with its type so that it round-trips exactly.
+type RecordedValue struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+// recordValue converts the statement argument |v| to a RecordedValue.
+func recordValue(v driver.Value) RecordedValue {
+	switch v := v.(type) {
+	case nil:
+		return RecordedValue{Type: "null"}
+	case int64:
+		return RecordedValue{Type: "int", Value: strconv.FormatInt(v, 10)}
+	case float64:
+		return RecordedValue{Type: "float", Value: strconv.FormatFloat(v, 'g', -1, 64)}
+	case bool:
+		return RecordedValue{Type: "bool", Value: strconv.FormatBool(v)}
+	case []byte:
+		return RecordedValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(v)}
+	case string:
+		return RecordedValue{Type: "string", Value: v}
+	case time.Time:
+		return RecordedValue{Type: "time", Value: v.Format(time.RFC3339Nano)}
+	default:
+		return RecordedValue{Type: "string", Value: fmt.Sprint(v)}
+	}
+}
+
+// value returns the statement argument described by |v|.
+func (v RecordedValue) value() (driver.Value, error) {
+	switch v.Type {
+	case "null":
+		return nil, nil
+	case "int":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(v.Value, 64)
+	case "bool":
+		return strconv.ParseBool(v.Value)
+	case "bytes":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "string":
+		return v.Value, nil
+	case "time":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	default:
+		return nil, fmt.Errorf("unknown recorded value type '%s'", v.Type)
+	}
+}
+
+// recorder writes the statements executed by a Connector's connections to Config.RecordTo. Statements are written
+// when they finish, so the statements of concurrent connections are interleaved in completion order.
+type recorder struct {
+	mu       sync.Mutex
+	enc      *json.Encoder
+	lastConn atomic.Uint64
+}
+
+// newRecorder returns a recorder writing to |w|, or nil if |w| is nil.
+func newRecorder(w io.Writer) *recorder {
+	if w == nil {
+		return nil
+	}
+
+	return &recorder{enc: json.NewEncoder(w)}
+}
+
+// newConn returns a connRecorder for a new connection.
+func (r *recorder) newConn() *connRecorder {
+	if r == nil {
+		return nil
+	}
+
+	return &connRecorder{recorder: r, conn: r.lastConn.Add(1)}
+}
+
+// connRecorder records the statements of a single connection. A nil *connRecorder records nothing.
+type connRecorder struct {
+	recorder *recorder
+	conn     uint64
+}
+
+// record records |stmt| as executed by the connection. Recording is best effort, so errors writing the recording
+// don't affect the statement.
+func (r *connRecorder) record(stmt RecordedStatement) {
+	if r == nil {
+		return
+	}
+
+	stmt.Conn = r.conn
+	r.recorder.mu.Lock()
+	defer r.recorder.mu.Unlock()
+	_ = r.recorder.enc.Encode(stmt)
+}
+
+// recordExec records an OperationExec statement.
+func (r *connRecorder) recordExec(query string, args []driver.Value, affected int64, err error) {
+	if r == nil {
+		return
+	}
+
+	r.record(newRecordedStatement(OperationExec, query, args, affected, err))
+}
+
+// newQueryRecording returns a queryRecording for an OperationQuery statement, which records it once its result set is
+// closed.
+func (r *connRecorder) newQueryRecording(query string, args []driver.Value) *queryRecording {
+	if r == nil {
+		return nil
+	}
+
+	return &queryRecording{
+		recorder: r,
+		stmt:     newRecordedStatement(OperationQuery, query, args, 0, nil),
+		digest:   newResultDigest(),
+	}
+}
+
+// newRecordedStatement returns a RecordedStatement for |query| executed with |args|.
+func newRecordedStatement(op Operation, query string, args []driver.Value, affected int64, err error) RecordedStatement {
+	stmt := RecordedStatement{Operation: op, Query: query, RowsAffected: affected}
+	for _, arg := range args {
+		stmt.Args = append(stmt.Args, recordValue(arg))
+	}
+	if err != nil {
+		stmt.Err = err.Error()
+	}
+
+	return stmt
+}
+
+// queryRecording accumulates the result set of an OperationQuery statement as it is read. A nil *queryRecording
+// records nothing.
+type queryRecording struct {
+	recorder *connRecorder
+	stmt     RecordedStatement
+	digest   *resultDigest
+	done     bool
+}
+
+// row adds |row| to the recorded result set.
+func (q *queryRecording) row(row []driver.Value) {
+	if q == nil {
+		return
+	}
+
+	q.stmt.Rows++
+	q.digest.add(row)
+}
+
+// finish records the statement, with |err| as its error. Only the first call has any effect.
+func (q *queryRecording) finish(err error) {
+	if q == nil || q.done {
+		return
+	}
+
+	q.done = true
+	if err != nil {
+		q.stmt.Err = err.Error()
+	}
+	q.stmt.ResultDigest = q.digest.sum()
+	q.recorder.record(q.stmt)
+}
+
+// resultDigest computes a digest of a result set that identifies it without revealing its data. Each value is hashed
+// with its type, so that results returning the same text with different types don't match.
+type resultDigest struct {
+	h hash.Hash
+}
+
+func newResultDigest() *resultDigest {
+	return &resultDigest{h: sha256.New()}
+}
+
+// add adds |row| to the digest.
+func (d *resultDigest) add(row []driver.Value) {
+	for _, v := range row {
+		fmt.Fprintf(d.h, "%T:%v\x00", v, v)
+	}
+	d.h.Write([]byte{'\n'})
+}
+
+// sum returns the digest of the rows added so far.
+func (d *resultDigest) sum() string {
+	return hex.EncodeToString(d.h.Sum(nil))
+}
+
+// ReplayMismatch is a statement whose outcome during Replay differed from its recording.
+type ReplayMismatch struct {
+	// Recorded is the statement as it was recorded
+	Recorded RecordedStatement
+	// RowsAffected, Rows, ResultDigest and Err describe the outcome of the replayed statement, as in RecordedStatement
+	RowsAffected int64
+	Rows         int64
+	ResultDigest string
+	Err          string
+}
+
+// Replay re-executes the statements recorded in |r| against |db|, typically a fresh database opened with this driver,
+// and returns the statements whose outcome differed from their recording. Each recorded connection is replayed on its
+// own connection from |db|, so that session state like the current database and open transactions is reproduced.
+// Replay only returns an error if the recording can't be read or a connection can't be obtained.
+func Replay(ctx context.Context, db *sql.DB, r io.Reader) ([]ReplayMismatch, error) {
+	conns := make(map[uint64]*sql.Conn)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	var mismatches []ReplayMismatch
+	dec := json.NewDecoder(r)
+	for {
+		var stmt RecordedStatement
+		if err := dec.Decode(&stmt); err == io.EOF {
+			return mismatches, nil
+		} else if err != nil {
+			return mismatches, err
+		}
+
+		conn, ok := conns[stmt.Conn]
+		if !ok {
+			var err error
+			if conn, err = db.Conn(ctx); err != nil {
+				return mismatches, err
+			}
+			conns[stmt.Conn] = conn
+		}
+
+		replayed, err := replayStatement(ctx, conn, stmt)
+		if err != nil {
+			return mismatches, err
+		}
+
+		if replayed.RowsAffected != stmt.RowsAffected || replayed.Rows != stmt.Rows ||
+			replayed.ResultDigest != stmt.ResultDigest || (replayed.Err == "") != (stmt.Err == "") {
+			mismatches = append(mismatches, replayed)
+		}
+	}
+}
+
+// replayStatement executes the recorded |stmt| on |conn| and returns its outcome. It only returns an error if |stmt|
+// is malformed.
+func replayStatement(ctx context.Context, conn *sql.Conn, stmt RecordedStatement) (ReplayMismatch, error) {
+	replayed := ReplayMismatch{Recorded: stmt}
+	args := make([]any, len(stmt.Args))
+	for i, arg := range stmt.Args {
+		v, err := arg.value()
+		if err != nil {
+			return replayed, err
+		}
+		args[i] = v
+	}
+
+	switch stmt.Operation {
+	case OperationExec:
+		res, err := conn.ExecContext(ctx, stmt.Query, args...)
+		if err == nil {
+			replayed.RowsAffected, err = res.RowsAffected()
+		}
+		if err != nil {
+			replayed.Err = err.Error()
+		}
+	case OperationQuery:
+		digest := newResultDigest()
+		err := func() error {
+			rows, err := conn.QueryContext(ctx, stmt.Query, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			columns, err := rows.Columns()
+			if err != nil {
+				return err
+			}
+			row := make([]driver.Value, len(columns))
+			dest := make([]any, len(columns))
+			for rows.Next() {
+				for i := range dest {
+					dest[i] = &row[i]
+				}
+				if err := rows.Scan(dest...); err != nil {
+					return err
+				}
+				replayed.Rows++
+				digest.add(row)
+			}
+			return rows.Err()
+		}()
+		replayed.ResultDigest = digest.sum()
+		if err != nil {
+			replayed.Err = err.Error()
+		}
+	default:
+		return replayed, fmt.Errorf("unknown recorded operation %d", stmt.Operation)
+	}
+
+	return replayed, nil
+}
@@ -0,0 +1,97 @@
+package embedded
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordedValueRoundTrip(t *testing.T) {
+	now := time.Date(2023, 4, 5, 6, 7, 8, 9, time.UTC)
+	for _, v := range []any{nil, int64(-7), 1.5, true, []byte{0, 1, 2}, "text", now} {
+		rv := recordValue(v)
+		got, err := rv.value()
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var recording bytes.Buffer
+	db := openRecordingTestDB(t, &recording)
+
+	ctx := context.Background()
+	_, err := db.ExecContext(ctx, "create table t (id int primary key, name varchar(20))")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "insert into t values (?, ?), (?, ?)", 1, "one", 2, "two")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "insert into t values (1, 'duplicate')")
+	require.Error(t, err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "update t set name = 'uno' where id = ?", 1)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	rows, err := db.QueryContext(ctx, "select * from t order by id")
+	require.NoError(t, err)
+	for rows.Next() {
+	}
+	require.NoError(t, rows.Close())
+	require.NoError(t, db.Close())
+	require.Contains(t, recording.String(), `"query":"update t set name = 'uno' where id = ?"`)
+	require.Contains(t, recording.String(), `"rows":2,"result_digest"`)
+
+	replayDB := openRecordingTestDB(t, nil)
+	mismatches, err := Replay(ctx, replayDB, bytes.NewReader(recording.Bytes()))
+	require.NoError(t, err)
+	require.Empty(t, mismatches)
+
+	// Replaying against a database that already has the data changes the outcome of the inserts and the select
+	replayDB = openRecordingTestDB(t, nil)
+	_, err = replayDB.ExecContext(ctx, "create table t (id int primary key, name varchar(20))")
+	require.NoError(t, err)
+	_, err = replayDB.ExecContext(ctx, "insert into t values (3, 'three')")
+	require.NoError(t, err)
+	mismatches, err = Replay(ctx, replayDB, bytes.NewReader(recording.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, mismatches, 2)
+	require.Equal(t, "create table t (id int primary key, name varchar(20))", mismatches[0].Recorded.Query)
+	require.Equal(t, OperationQuery, mismatches[1].Recorded.Operation)
+	require.Equal(t, int64(3), mismatches[1].Rows)
+}
+
+// openRecordingTestDB opens a database with an empty "testdb" database selected, recording to |w| if it's not nil.
+func openRecordingTestDB(t *testing.T, w *bytes.Buffer) *sql.DB {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	cfg := Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	}
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	_, err = db.Exec("create database testdb")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	cfg.Database = "testdb"
+	if w != nil {
+		cfg.RecordTo = w
+	}
+	connector, err = NewConnector(cfg)
+	require.NoError(t, err)
+	db = sql.OpenDB(connector)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
@@ -0,0 +1,169 @@
+package embedded
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	"github.com/dolthub/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+)
+
+// sharedEngine is an engine shared by the connections of a Connector. When RefreshDatabases replaces it, it is
+// retired, and closed once the last connection using it is closed.
+type sharedEngine struct {
+	se      *engine.SqlEngine
+	slowLog *slowQueryLog
+
+	mu      sync.Mutex
+	conns   int
+	retired bool
+	// stores are the chunk stores of the databases the engine opened, by the directory of their files
+	stores map[string]*doltdb.DoltDB
+}
+
+func newSharedEngine(se *engine.SqlEngine, cfg Config) *sharedEngine {
+	e := &sharedEngine{se: se, slowLog: newSlowQueryLog(se, cfg)}
+	// Failing to list the chunk stores only keeps them open after a refresh replaces them or they are evicted
+	if stores, err := engineStores(context.Background(), se, cfg); err == nil {
+		e.stores = stores
+		acquireStores(e.stores)
+	}
+	return e
+}
+
+// openSharedEngine opens an engine for the databases in |fs| like openEngine, with the StatementsTableName table
+// reading |stats|, and returns it as a sharedEngine.
+func openSharedEngine(ctx context.Context, fs filesys.Filesys, cfg Config, stats *statsRegistry) (*sharedEngine, error) {
+	storeCloseMu.RLock()
+	defer storeCloseMu.RUnlock()
+
+	se, err := openEngine(ctx, fs, cfg)
+	if err != nil {
+		return nil, err
+	}
+	addStatementsTable(se, stats)
+	return newSharedEngine(se, cfg), nil
+}
+
+// acquire records a new connection using the engine.
+func (e *sharedEngine) acquire() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.conns++
+}
+
+// release records that a connection using the engine was closed, and closes a retired engine once it is unused. A nil
+// *sharedEngine does nothing.
+func (e *sharedEngine) release() {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.conns--
+	if e.retired && e.conns == 0 {
+		e.close()
+	}
+}
+
+// retire marks the engine as replaced, closing it right away if no connection uses it.
+func (e *sharedEngine) retire() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.retired = true
+	if e.conns == 0 {
+		e.close()
+	}
+}
+
+// close closes the engine, and the chunk stores it was the last user of that were replaced or evicted.
+func (e *sharedEngine) close() {
+	closeEngine(context.Background(), e.se)
+	releaseStores(e.stores)
+}
+
+// isRetired returns whether the engine has been replaced. A nil *sharedEngine is never retired.
+func (e *sharedEngine) isRetired() bool {
+	if e == nil {
+		return false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.retired
+}
+
+// RefreshDatabases makes databases created in the connector's directories since its engine was opened, for instance by
+// the dolt CLI in another process, visible to new connections, and returns their names. The engine can't load
+// databases while it is running, so when there are new databases a new engine is opened for new connections. Open
+// connections keep using the previous engine, which the connection pool stops reusing, and which is closed once they
+// are all closed. With Config.MaxOpenDatabases, databases are opened when statements use them, so there are no new
+// databases to make visible.
+func (c *Connector) RefreshDatabases(ctx context.Context) ([]string, error) {
+	if c.proxied() != nil {
+		return nil, ErrProxied
+	}
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.Lock()
+	current := c.engine
+	c.mu.Unlock()
+
+	cfg, err := c.engineConfig()
+	if err != nil {
+		return nil, err
+	}
+	added, err := newDatabases(ctx, current.se, c.cfg.directories(), cfg.Databases)
+	if err != nil || len(added) == 0 {
+		return nil, err
+	}
+
+	fs, err := filesys.LocalFS.WithWorkingDir(c.cfg.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	eng, err := openSharedEngine(ctx, fs, cfg, c.stats)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.engine = eng
+	c.mu.Unlock()
+	current.retire()
+
+	return added, nil
+}
+
+// newDatabases returns the names of the databases in |dirs| that |se| doesn't have and |dbs| doesn't skip, in sorted
+// order.
+func newDatabases(ctx context.Context, se *engine.SqlEngine, dirs []string, dbs map[string]DatabaseConfig) ([]string, error) {
+	dirs, err := databaseDirsIn(dirs, dbs)
+	if err != nil {
+		return nil, err
+	}
+
+	gmsCtx, err := se.NewLocalContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	catalog := se.GetUnderlyingEngine().Analyzer.Catalog
+
+	var added []string
+	for _, d := range dirs {
+		name := dbfactory.DirToDBName(d)
+		if !catalog.HasDatabase(gmsCtx, name) {
+			added = append(added, name)
+		}
+	}
+
+	sort.Strings(added)
+	return added, nil
+}
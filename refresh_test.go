@@ -0,0 +1,73 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshDatabases(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	cfg := Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	}
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	added, err := connector.RefreshDatabases(ctx)
+	require.NoError(t, err)
+	require.Empty(t, added)
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Another engine on the same directory stands in for another process creating a database
+	other, err := NewConnector(cfg)
+	require.NoError(t, err)
+	otherDB := sql.OpenDB(other)
+	_, err = otherDB.ExecContext(ctx, "create database newdb")
+	require.NoError(t, err)
+	require.NoError(t, otherDB.Close())
+
+	require.NotContains(t, showDatabases(t, db), "newdb")
+
+	added, err = connector.RefreshDatabases(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"newdb"}, added)
+	require.Contains(t, showDatabases(t, db), "newdb")
+
+	// Connections opened before the refresh keep working on the previous engine
+	var one int
+	require.NoError(t, conn.QueryRowContext(ctx, "select 1").Scan(&one))
+	require.NoError(t, conn.Raw(func(driverConn any) error {
+		require.False(t, driverConn.(*DoltConn).IsValid())
+		return nil
+	}))
+}
+
+func showDatabases(t *testing.T, db *sql.DB) []string {
+	rows, err := db.Query("show databases")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	return names
+}
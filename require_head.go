@@ -0,0 +1,96 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+type requireHeadKey struct{}
+
+// HeadMovedError is returned by the statements and transactions run with a context from RequireHead when the HEAD of
+// the branch moved since the required commit.
+type HeadMovedError struct {
+	Database string
+	Branch   string
+	// Expected is the commit hash given to RequireHead, and Actual the hash of the branch's HEAD
+	Expected string
+	Actual   string
+}
+
+func (e *HeadMovedError) Error() string {
+	return fmt.Sprintf("HEAD of branch '%s' of database '%s' moved from %s to %s", e.Branch, e.Database, e.Expected,
+		e.Actual)
+}
+
+// RequireHead returns a copy of |ctx| with which statements fail with a *HeadMovedError if the HEAD of the branch they
+// run against isn't the commit |hash| anymore, for compare-and-swap style writes: read the data and the hash of HEAD,
+// then write and commit with RequireHead, and start over from the new HEAD if the write fails. The HEAD is checked when
+// each statement starts, as well as when transactions begun with the context commit, in which case the transaction is
+// rolled back. The branch is the one the statement runs against, including one set with WithBranch, and the HEAD is its
+// latest commit, rather than the one the connection's transaction started from. Within a transaction, the commits the
+// transaction makes itself, with DOLT_COMMIT, don't fail the check.
+func RequireHead(ctx context.Context, hash string) context.Context {
+	return context.WithValue(ctx, requireHeadKey{}, hash)
+}
+
+// contextHead returns the commit hash set in |ctx| with RequireHead, or the empty string.
+func contextHead(ctx context.Context) string {
+	hash, _ := ctx.Value(requireHeadKey{}).(string)
+	return hash
+}
+
+// checkHead returns a *HeadMovedError if |hash| isn't empty and isn't the hash of the HEAD of the branch of the current
+// database of |gmsCtx|.
+func checkHead(gmsCtx *gms.Context, hash string) error {
+	if hash == "" {
+		return nil
+	}
+
+	dbName := gmsCtx.GetCurrentDatabase()
+	if dbName == "" {
+		return fmt.Errorf("no database selected to check the HEAD of")
+	}
+	sess := dsess.DSessFromSess(gmsCtx.Session)
+	headRef, err := sess.CWBHeadRef(gmsCtx, dbName)
+	if err != nil {
+		return translateError(err)
+	}
+	dbData, ok := sess.GetDbData(gmsCtx, dbName)
+	if !ok {
+		return translateError(gms.ErrDatabaseNotFound.New(dbName))
+	}
+	head, err := dbData.Ddb.ResolveCommitRef(gmsCtx, headRef)
+	if err != nil {
+		return translateError(err)
+	}
+	actual, err := head.HashOf()
+	if err != nil {
+		return translateError(err)
+	}
+
+	if strings.EqualFold(actual.String(), hash) {
+		return nil
+	}
+	// The HEAD of a transaction's branch also moves with the commits the transaction makes itself. Other commits
+	// don't change the HEAD the transaction sees.
+	if gmsCtx.GetTransaction() != nil {
+		own, err := sess.GetHeadCommit(gmsCtx, dbName)
+		if err != nil {
+			return translateError(err)
+		}
+		ownHash, err := own.HashOf()
+		if err != nil {
+			return translateError(err)
+		}
+		if ownHash == actual {
+			return nil
+		}
+	}
+
+	base, _ := dsess.SplitRevisionDbName(dbName)
+	return &HeadMovedError{Database: base, Branch: headRef.GetPath(), Expected: hash, Actual: actual.String()}
+}
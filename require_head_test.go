@@ -0,0 +1,93 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireHead(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	setup, err := db.Conn(ctx)
+	require.NoError(t, err)
+	for _, query := range []string{
+		"create database test",
+		"use test",
+		"create table t (id int primary key, v int)",
+		"insert into t values (1, 1)",
+		"call dolt_commit('-Am', 'create t')",
+		"call dolt_branch('other')",
+	} {
+		_, err = setup.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	require.NoError(t, setup.Close())
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	other, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer other.Close()
+	for _, c := range []*sql.Conn{conn, other} {
+		_, err = c.ExecContext(ctx, "use test")
+		require.NoError(t, err)
+	}
+	head := func() string {
+		var hash string
+		require.NoError(t, other.QueryRowContext(ctx, "select hashof('main')").Scan(&hash))
+		return hash
+	}
+
+	// A write at the current HEAD succeeds, and its commit moves HEAD
+	expected := head()
+	tx, err := conn.BeginTx(RequireHead(ctx, expected), nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(RequireHead(ctx, expected), "update t set v = 2 where id = 1")
+	require.NoError(t, err)
+	_, err = tx.ExecContext(RequireHead(ctx, expected), "call dolt_commit('-am', 'v = 2')")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	require.NotEqual(t, expected, head())
+
+	// Statements fail once HEAD moved
+	_, err = conn.ExecContext(RequireHead(ctx, expected), "update t set v = 3 where id = 1")
+	var moved *HeadMovedError
+	require.True(t, errors.As(err, &moved), "%v", err)
+	require.Equal(t, HeadMovedError{Database: "test", Branch: "main", Expected: expected, Actual: head()}, *moved)
+
+	// A transaction is rolled back if another connection moves HEAD before it commits
+	expected = head()
+	tx, err = conn.BeginTx(RequireHead(ctx, expected), nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "update t set v = 4 where id = 1")
+	require.NoError(t, err)
+	_, err = other.ExecContext(ctx, "call dolt_commit('--allow-empty', '-m', 'concurrent')")
+	require.NoError(t, err)
+	require.True(t, errors.As(tx.Commit(), &moved))
+	var v int
+	require.NoError(t, conn.QueryRowContext(ctx, "select v from t where id = 1").Scan(&v))
+	require.Equal(t, 2, v)
+
+	// The branch is the one the statement runs against
+	_, err = conn.ExecContext(RequireHead(WithBranch(ctx, "other"), head()), "insert into t values (2, 2)")
+	require.True(t, errors.As(err, &moved))
+	require.Equal(t, "other", moved.Branch)
+	var otherHead string
+	require.NoError(t, conn.QueryRowContext(ctx, "select hashof('other')").Scan(&otherHead))
+	_, err = conn.ExecContext(RequireHead(WithBranch(ctx, "other"), otherHead), "insert into t values (2, 2)")
+	require.NoError(t, err)
+}
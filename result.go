@@ -14,9 +14,13 @@ type doltResult struct {
 	affected int64
 	last     int64
 	err      error
+	warnings []Warning
 }
 
-func newResult(gmsCtx *gms.Context, sch gms.Schema, rowItr gms.RowIter) *doltResult {
+// newResult reads the rows of an executed statement into its result. |lastInsertID| is the session's LAST_INSERT_ID()
+// before the statement executed: if the statement changed it without inserting a row, as LAST_INSERT_ID(expr) does, the
+// result's LastInsertId is the new value, like the insert ID in MySQL's OK packet.
+func newResult(gmsCtx *gms.Context, sch gms.Schema, rowItr gms.RowIter, lastInsertID int64) *doltResult {
 	var resultErr error
 	var affected int64
 	var last int64
@@ -42,10 +46,17 @@ func newResult(gmsCtx *gms.Context, sch gms.Schema, rowItr gms.RowIter) *doltRes
 		return &doltResult{err: err}
 	}
 
+	if last == 0 {
+		if id := gmsCtx.Session.GetLastQueryInfoInt(gms.LastInsertId); id != lastInsertID {
+			last = id
+		}
+	}
+
 	return &doltResult{
 		affected: affected,
 		last:     last,
 		err:      resultErr,
+		warnings: sessionWarnings(gmsCtx),
 	}
 }
 
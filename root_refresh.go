@@ -0,0 +1,301 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	"github.com/dolthub/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+	"github.com/dolthub/dolt/go/store/chunks"
+)
+
+// rootRefresher makes a connector see the commits other processes, such as the dolt CLI, made to the databases it
+// opened read-only because those processes held their locks. The engine reads the root of such a database once, when
+// it opens it, so the refresher checks the database's files at most once per interval, and opens a new engine for new
+// connections when they changed, like RefreshDatabases does for new databases.
+type rootRefresher struct {
+	c        *Connector
+	interval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+	// files holds the fingerprints of the files of the databases opened read-only, by chunk store directory
+	files map[string]string
+}
+
+// newRootRefresher returns a rootRefresher for |c|, or nil if Config.RefreshInterval isn't set.
+func newRootRefresher(ctx context.Context, c *Connector) (*rootRefresher, error) {
+	if c.cfg.RefreshInterval <= 0 || c.engine == nil {
+		return nil, nil
+	}
+
+	stores, err := readOnlyStores(ctx, c.engine.se, c.cfg)
+	if err != nil {
+		return nil, err
+	}
+	files, err := storeFiles(stores)
+	if err != nil {
+		return nil, err
+	}
+	return &rootRefresher{c: c, interval: c.cfg.RefreshInterval, last: c.cfg.now(), files: files}, nil
+}
+
+// report logs |err|, which failed a refresh, with Config.Logger, or with the driver's own messages if it isn't set.
+func (r *rootRefresher) report(err error) {
+	if logger := r.c.cfg.Logger; logger != nil {
+		logger.Error("refreshing databases", "error", err)
+	} else {
+		r.c.cfg.printf("refreshing databases: %v", err)
+	}
+}
+
+// refresh opens a new engine for the connector if the refresher's interval has passed since the last check, and the
+// files of a database the current engine opened read-only changed since then. Connections using the previous engine
+// become invalid, so the connection pool replaces them as they are returned to it. A nil *rootRefresher does nothing.
+func (r *rootRefresher) refresh(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.c.cfg.now()
+	if now.Sub(r.last) < r.interval {
+		return nil
+	}
+	r.last = now
+
+	c := r.c
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.Lock()
+	current := c.engine
+	c.mu.Unlock()
+
+	stores, err := readOnlyStores(ctx, current.se, c.cfg)
+	if err != nil {
+		return err
+	}
+	files, err := storeFiles(stores)
+	if err != nil {
+		return err
+	}
+	var replaced []*doltdb.DoltDB
+	for dir, fingerprint := range files {
+		if r.files[dir] != fingerprint {
+			replaced = append(replaced, stores[dir])
+		}
+	}
+	if len(replaced) == 0 {
+		return nil
+	}
+	// Engines share the chunk store of a database with the engines that opened it before, so the changed databases
+	// need new chunk stores to read their files again
+	for dir, fingerprint := range files {
+		if r.files[dir] != fingerprint {
+			if err := dbfactory.DeleteFromSingletonCache(filepath.ToSlash(dir)); err != nil {
+				return err
+			}
+		}
+	}
+
+	fs, err := filesys.LocalFS.WithWorkingDir(c.cfg.Directory)
+	if err != nil {
+		return err
+	}
+	cfg, err := c.engineConfig()
+	if err != nil {
+		return err
+	}
+	eng, err := openSharedEngine(ctx, fs, cfg, c.stats)
+	if err != nil {
+		return err
+	}
+
+	replaceStores(replaced)
+	c.mu.Lock()
+	c.engine = eng
+	c.mu.Unlock()
+	current.retire()
+	r.files = files
+
+	return nil
+}
+
+// storeUse counts the engines using a chunk store.
+type storeUse struct {
+	engines int
+	// path is the directory of the store's files
+	path string
+	// replaced is set once a refresh opened a new chunk store for the database, so that the store is closed when
+	// the last engine using it is closed
+	replaced bool
+	// evicted is set once the connector's engine stopped loading the database to stay under
+	// Config.MaxOpenDatabases, so that the store is closed when the last engine using it is closed, unless a new engine
+	// loads the database again before then
+	evicted bool
+}
+
+var (
+	storeUsesMu sync.Mutex
+	// storeUses holds the uses of the chunk stores opened by the engines of all connectors. Engines opening the same
+	// database share its chunk store, which dolt caches for the process.
+	storeUses = make(map[*doltdb.DoltDB]*storeUse)
+	// storeCloseMu is held for reading while engines open, and for writing while chunk stores are closed, so that an
+	// engine doesn't load a store from dolt's cache as it is being closed
+	storeCloseMu sync.RWMutex
+)
+
+// acquireStores records that an engine uses |stores|, by directory.
+func acquireStores(stores map[string]*doltdb.DoltDB) {
+	storeUsesMu.Lock()
+	defer storeUsesMu.Unlock()
+	for path, ddb := range stores {
+		use, ok := storeUses[ddb]
+		if !ok {
+			use = &storeUse{path: path}
+			storeUses[ddb] = use
+		}
+		use.engines++
+		use.evicted = false
+	}
+}
+
+// releaseStores records that an engine using |stores| was closed, and closes the replaced and evicted stores no engine
+// uses.
+func releaseStores(stores map[string]*doltdb.DoltDB) {
+	storeCloseMu.Lock()
+	defer storeCloseMu.Unlock()
+	storeUsesMu.Lock()
+	defer storeUsesMu.Unlock()
+	for _, ddb := range stores {
+		use, ok := storeUses[ddb]
+		if !ok {
+			continue
+		}
+		use.engines--
+		if use.engines > 0 {
+			continue
+		}
+		delete(storeUses, ddb)
+		// A replaced store was already removed from dolt's cache, which holds its replacement
+		if use.evicted && !use.replaced {
+			dbfactory.DeleteFromSingletonCache(filepath.ToSlash(use.path))
+		}
+		if use.replaced || use.evicted {
+			ddb.Close()
+		}
+	}
+}
+
+// replaceStores records that new chunk stores were opened for the databases of |stores|.
+func replaceStores(stores []*doltdb.DoltDB) {
+	storeUsesMu.Lock()
+	defer storeUsesMu.Unlock()
+	for _, ddb := range stores {
+		if use, ok := storeUses[ddb]; ok {
+			use.replaced = true
+		}
+	}
+}
+
+// evictStores records that the connector's engine no longer loads the databases of |stores|.
+func evictStores(stores []*doltdb.DoltDB) {
+	storeUsesMu.Lock()
+	defer storeUsesMu.Unlock()
+	for _, ddb := range stores {
+		if use, ok := storeUses[ddb]; ok {
+			use.evicted = true
+		}
+	}
+}
+
+// engineStores returns the chunk stores of the databases |se| opened, by the directory of their files.
+func engineStores(ctx context.Context, se *engine.SqlEngine, cfg Config) (map[string]*doltdb.DoltDB, error) {
+	gmsCtx, err := se.NewLocalContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pro := dsess.DSessFromSess(gmsCtx.Session).Provider()
+	stores := make(map[string]*doltdb.DoltDB)
+	for _, dir := range cfg.directories() {
+		dbDirs, err := databaseDirs(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, dbDir := range dbDirs {
+			db, ok := pro.BaseDatabase(gmsCtx, dbfactory.DirToDBName(dbDir))
+			if !ok {
+				continue
+			}
+			ddb := db.DbData().Ddb
+			if ddb == nil {
+				continue
+			}
+
+			path, err := filepath.Abs(filepath.Join(dir, dbDir, dbfactory.DoltDataDir))
+			if err != nil {
+				return nil, err
+			}
+			stores[path] = ddb
+		}
+	}
+	return stores, nil
+}
+
+// readOnlyStores returns the chunk stores of the databases |se| opened read-only, by the directory of their files.
+func readOnlyStores(ctx context.Context, se *engine.SqlEngine, cfg Config) (map[string]*doltdb.DoltDB, error) {
+	stores, err := engineStores(ctx, se, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for path, ddb := range stores {
+		if ddb.AccessMode() != chunks.ExclusiveAccessMode_ReadOnly {
+			delete(stores, path)
+		}
+	}
+	return stores, nil
+}
+
+// storeFiles returns the fingerprints of the files of |stores|, by directory.
+func storeFiles(stores map[string]*doltdb.DoltDB) (map[string]string, error) {
+	files := make(map[string]string, len(stores))
+	for dir := range stores {
+		var err error
+		if files[dir], err = filesFingerprint(dir); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// filesFingerprint returns a string that changes when the files in |dir| are written to.
+func filesFingerprint(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "%s:%d:%d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,138 @@
+package embedded
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/stretchr/testify/require"
+)
+
+// refreshWriterDirEnv is set to the directory the writer process of TestRefreshInterval opens
+const refreshWriterDirEnv = "DOLT_DRIVER_TEST_REFRESH_WRITER_DIR"
+
+// TestRefreshIntervalWriter is the process that writes to the databases read by TestRefreshInterval. It executes the
+// queries it reads from stdin, one per line, and prints "ok" after each one.
+func TestRefreshIntervalWriter(t *testing.T) {
+	dir := os.Getenv(refreshWriterDirEnv)
+	if dir == "" {
+		t.Skip("only run by TestRefreshInterval")
+	}
+
+	ctx := context.Background()
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	fmt.Println("ok")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		_, err := conn.ExecContext(ctx, scanner.Text())
+		require.NoError(t, err)
+		fmt.Println("ok")
+	}
+}
+
+func TestRefreshInterval(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Another process creates the databases and holds their lock, so the connectors open them read-only
+	writer := exec.Command(os.Args[0], "-test.run=^TestRefreshIntervalWriter$")
+	writer.Env = append(os.Environ(), refreshWriterDirEnv+"="+dir)
+	stdin, err := writer.StdinPipe()
+	require.NoError(t, err)
+	stdout, err := writer.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, writer.Start())
+	defer writer.Wait()
+	defer stdin.Close()
+	output := bufio.NewScanner(stdout)
+	write := func(query string) {
+		if query != "" {
+			_, err := fmt.Fprintln(stdin, query)
+			require.NoError(t, err)
+		}
+		require.True(t, output.Scan(), "writer exited")
+		require.Equal(t, "ok", output.Text())
+	}
+	write("")
+	write("create database test")
+	write("use test")
+	write("create table t (v int primary key)")
+	write("call dolt_commit('-Am', 'create t')")
+
+	clock := &testClock{t: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)}
+	cfg := Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com", Database: "test", Now: clock.now}
+	stale, err := NewConnector(cfg)
+	require.NoError(t, err)
+	staleDB := sql.OpenDB(stale)
+	defer staleDB.Close()
+	cfg.RefreshInterval = time.Second
+	records := &logRecords{level: slog.LevelError}
+	cfg.Logger = slog.New(records)
+	refreshed, err := NewConnector(cfg)
+	require.NoError(t, err)
+	refreshedDB := sql.OpenDB(refreshed)
+	defer refreshedDB.Close()
+
+	count := func(db *sql.DB) int {
+		var n int
+		require.NoError(t, db.QueryRowContext(ctx, "select count(*) from t").Scan(&n))
+		return n
+	}
+	require.Equal(t, 0, count(staleDB))
+	require.Equal(t, 0, count(refreshedDB))
+
+	write("insert into t values (1), (2)")
+	write("call dolt_commit('-am', 'insert')")
+	clock.advance(time.Second)
+
+	require.Equal(t, 2, count(refreshedDB))
+	require.Equal(t, 0, count(staleDB))
+
+	// A transaction keeps a consistent view
+	tx, err := refreshedDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	write("insert into t values (3)")
+	clock.advance(time.Second)
+	var n int
+	require.NoError(t, tx.QueryRowContext(ctx, "select count(*) from t").Scan(&n))
+	require.Equal(t, 2, n)
+	require.NoError(t, tx.Commit())
+	clock.advance(time.Second)
+	require.Equal(t, 3, count(refreshedDB))
+
+	// The chunk store the refreshes replaced is still used by the other connector
+	require.Equal(t, 0, count(staleDB))
+
+	// A refresh that fails is logged, and the connection it failed for is replaced rather than used on the engine it
+	// didn't replace
+	if runtime.GOOS == "windows" {
+		return
+	}
+	noms := filepath.Join(dir, "test", dbfactory.DoltDataDir)
+	require.NoError(t, os.Rename(noms, noms+".moved"))
+	clock.advance(time.Second)
+	var one int
+	require.NoError(t, refreshedDB.QueryRowContext(ctx, "select 1").Scan(&one))
+	require.NoError(t, os.Rename(noms+".moved", noms))
+	_, attrs, ok := records.find("refreshing databases")
+	require.True(t, ok)
+	require.Contains(t, attrs, "error")
+}
@@ -0,0 +1,199 @@
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// RowVersion is a version of a row, as listed by Connector.RowHistory.
+type RowVersion struct {
+	// Values are the row's values in this version by column name, as the engine returns them
+	Values map[string]any
+	// Commit is the hash of the commit that introduced this version of the row
+	Commit    string
+	Committer string
+	Email     string
+	Time      time.Time
+	Message   string
+}
+
+// RowHistory returns the versions of the row of |table| in |database| whose primary key is |pk|, given in the order
+// of the primary key's columns, newest first. It reads the dolt_history_<table> system table of the branch new
+// connections use, and keeps a version for each commit that created or changed the row, in the order of dolt log, so
+// commits that didn't change it are left out. A row that never existed has no versions.
+func (c *Connector) RowHistory(ctx context.Context, database, table string, pk ...any) ([]RowVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	eng, gmsCtx, err := c.newDatabaseSession(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	defer eng.release()
+	gmsCtx.SetCurrentDatabase(database)
+
+	h, err := readTableHistory(gmsCtx, eng, database, table, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk the log from the oldest commit, keeping a version for each commit where the row appears or changes
+	var versions []RowVersion
+	var previous gms.Row
+	for i := len(h.commits) - 1; i >= 0; i-- {
+		commit := h.commits[i]
+		// The history only has the row of |pk|, so a commit has at most one row
+		var values gms.Row
+		for _, row := range h.rows[commit.Hash] {
+			values = row
+		}
+		if values == nil || (previous != nil && reflect.DeepEqual(values, previous)) {
+			previous = values
+			continue
+		}
+		previous = values
+
+		versions = append(versions, RowVersion{
+			Values:    h.values(values),
+			Commit:    commit.Hash,
+			Committer: commit.Author,
+			Email:     commit.Email,
+			Time:      commit.Time,
+			Message:   commit.Message,
+		})
+	}
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+
+	return versions, nil
+}
+
+// tableHistory holds the rows of a table at each commit of the log, read from its dolt_history_<table> system table.
+type tableHistory struct {
+	sch gms.Schema
+	// commits are the commits of the log, newest first, without their parents
+	commits []Commit
+	// rows holds the rows of the table by primary key, as returned by key, by commit hash
+	rows map[string]map[string]gms.Row
+	// order holds the primary keys of the rows of the newest commit, in the order the engine returned them
+	order []string
+}
+
+// readTableHistory reads the history of |table| in |database|, restricted to the row whose primary key is |pk| if it
+// isn't empty.
+func readTableHistory(gmsCtx *gms.Context, eng *sharedEngine, database, table string, pk []any) (*tableHistory, error) {
+	tbl, _, err := eng.se.GetUnderlyingEngine().Analyzer.Catalog.Table(gmsCtx, database, table)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	h := &tableHistory{sch: tbl.Schema(), rows: make(map[string]map[string]gms.Row)}
+
+	var columns, conditions []string
+	for _, col := range h.sch {
+		columns = append(columns, quoteIdentifier(col.Name))
+		if col.PrimaryKey {
+			conditions = append(conditions, fmt.Sprintf("%s = :v%d", quoteIdentifier(col.Name), len(conditions)+1))
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s, commit_hash FROM %s", strings.Join(columns, ", "),
+		quoteIdentifier("dolt_history_"+tbl.Name()))
+	var args []driver.Value
+	if len(pk) > 0 {
+		if len(pk) != len(conditions) {
+			return nil, fmt.Errorf("table '%s' has %d primary key columns, got %d values", table, len(conditions), len(pk))
+		}
+		query += " WHERE " + strings.Join(conditions, " AND ")
+		args = make([]driver.Value, len(pk))
+		for i, v := range pk {
+			args[i] = v
+		}
+	}
+	bindings, err := argsToBindings(args)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := queryRows(gmsCtx, eng.se, query, bindings)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	for _, row := range rows {
+		hash := fmt.Sprint(row[len(h.sch)])
+		if h.rows[hash] == nil {
+			h.rows[hash] = make(map[string]gms.Row)
+		}
+		values := row[:len(h.sch)]
+		h.rows[hash][h.key(h.primaryKey(values))] = values
+	}
+
+	log, err := queryRows(gmsCtx, eng.se, "SELECT commit_hash, committer, email, date, message FROM dolt_log", nil)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	for _, row := range log {
+		commit := Commit{
+			Hash:    fmt.Sprint(row[0]),
+			Author:  fmt.Sprint(row[1]),
+			Email:   fmt.Sprint(row[2]),
+			Message: fmt.Sprint(row[4]),
+		}
+		commit.Time, _ = row[3].(time.Time)
+		h.commits = append(h.commits, commit)
+	}
+
+	if len(h.commits) > 0 {
+		for _, row := range rows {
+			if fmt.Sprint(row[len(h.sch)]) == h.commits[0].Hash {
+				h.order = append(h.order, h.key(h.primaryKey(row[:len(h.sch)])))
+			}
+		}
+	}
+
+	return h, nil
+}
+
+// primaryKey returns the primary key values of |row|.
+func (h *tableHistory) primaryKey(row gms.Row) []any {
+	var pk []any
+	for i, col := range h.sch {
+		if col.PrimaryKey {
+			pk = append(pk, row[i])
+		}
+	}
+	return pk
+}
+
+// key returns the key of the row whose primary key is |pk| in tableHistory.rows.
+func (h *tableHistory) key(pk []any) string {
+	var sb strings.Builder
+	for i, v := range pk {
+		if i > 0 {
+			sb.WriteByte(0)
+		}
+		fmt.Fprint(&sb, v)
+	}
+	return sb.String()
+}
+
+// values returns the values of |row| by column name.
+func (h *tableHistory) values(row gms.Row) map[string]any {
+	values := make(map[string]any, len(h.sch))
+	for i, col := range h.sch {
+		values[col.Name] = row[i]
+	}
+	return values
+}
+
+// quoteIdentifier returns |name| quoted as a SQL identifier.
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,66 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowHistory(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, query := range []string{
+		"create database test",
+		"use test",
+		"create table `order items` (`order` int, item varchar(10), qty int, primary key (`order`, item))",
+		"insert into `order items` values (1, 'apple', 1), (1, 'pear', 1)",
+		"call dolt_commit('-Am', 'create')",
+		"update `order items` set qty = 5 where item = 'pear'",
+		"call dolt_commit('-am', 'unrelated')",
+		"update `order items` set qty = 2 where item = 'apple'",
+		"call dolt_commit('-am', 'update')",
+		"delete from `order items` where item = 'apple'",
+		"call dolt_commit('-am', 'delete')",
+		"insert into `order items` values (1, 'apple', 2)",
+		"call dolt_commit('-am', 'recreate')",
+	} {
+		_, err = conn.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+
+	versions, err := connector.RowHistory(ctx, "test", "order items", 1, "apple")
+	require.NoError(t, err)
+	var messages []string
+	for _, v := range versions {
+		messages = append(messages, v.Message)
+		require.Equal(t, "root", v.Committer)
+		require.NotEmpty(t, v.Commit)
+		require.False(t, v.Time.IsZero())
+	}
+	require.Equal(t, []string{"recreate", "update", "create"}, messages)
+	require.Equal(t, map[string]any{"order": int32(1), "item": "apple", "qty": int32(2)}, versions[0].Values)
+	require.Equal(t, int32(1), versions[2].Values["qty"])
+
+	versions, err = connector.RowHistory(ctx, "test", "order items", 2, "apple")
+	require.NoError(t, err)
+	require.Empty(t, versions)
+
+	_, err = connector.RowHistory(ctx, "test", "order items", 1)
+	require.ErrorContains(t, err, "2 primary key columns")
+	_, err = connector.RowHistory(ctx, "test", "missing", 1)
+	require.Error(t, err)
+}
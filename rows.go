@@ -1,6 +1,7 @@
 package embedded
 
 import (
+	"context"
 	"database/sql/driver"
 	"errors"
 	"fmt"
@@ -15,6 +16,8 @@ import (
 type doltMultiRows struct {
 	rowSets       []*doltRows
 	currentRowSet int
+	// end ends the multi-statement query in the process list once the rows are closed
+	end func()
 }
 
 var _ driver.RowsNextResultSet = (*doltMultiRows)(nil)
@@ -38,6 +41,9 @@ func (d *doltMultiRows) Close() error {
 			retErr = err
 		}
 	}
+	if d.end != nil {
+		d.end()
+	}
 	return retErr
 }
 
@@ -74,15 +80,39 @@ func (d *doltMultiRows) NextResultSet() error {
 }
 
 type doltRows struct {
-	sch     gms.Schema
-	rowIter gms.RowIter
-	gmsCtx  *gms.Context
+	sch          gms.Schema
+	rowIter      gms.RowIter
+	gmsCtx       *gms.Context
+	sessionStats *sessionStats
+
+	// geometryFormat is the format geometry values are returned in
+	geometryFormat GeometryFormat
+
+	// rowLimit limits the number of rows returned, which |returned| counts
+	rowLimit rowLimit
+	returned int64
+
+	// warnings holds the statement's warnings once its rows have been read
+	warnings *[]Warning
+
+	// recording records the result set in recording mode
+	recording *queryRecording
+
+	// guard recovers the engine's panics while reading rows
+	guard *panicGuard
+
+	// cancel releases the statement's context once the rows are closed
+	cancel context.CancelFunc
 
 	columns []string
 
 	// err holds any error encountered while trying to retrieve this result set
 	err error
 
+	// intercepted holds the rows supplied by an Interceptor in place of executing the query. When it is set, the
+	// other fields describing the result set are unused and calls are delegated to it.
+	intercepted driver.Rows
+
 	// isQueryResultSet indicates if this result set was generated by a statement that doesn't produce a result set. For
 	// example, an INSERT or DML statement doesn't return a result set, but we still keep track of a doltRows
 	// instance for their results in case an error was returned. This field is also used to skip over doltRows
@@ -95,6 +125,10 @@ var _ driver.Rows = (*doltRows)(nil)
 // Columns returns the names of the columns. The number of columns of the result is inferred from the length of the
 // slice. If a particular column name isn't known, an empty string should be returned for that entry.
 func (rows *doltRows) Columns() []string {
+	if rows.intercepted != nil {
+		return rows.intercepted.Columns()
+	}
+
 	if rows.columns == nil {
 		rows.columns = make([]string, len(rows.sch))
 		for i, col := range rows.sch {
@@ -107,37 +141,73 @@ func (rows *doltRows) Columns() []string {
 
 // Close closes the rows iterator.
 func (rows *doltRows) Close() error {
-	if rows.rowIter == nil {
+	if rows.intercepted != nil {
+		return rows.intercepted.Close()
+	} else if rows.rowIter == nil {
 		return nil
 	}
 
-	return translateError(rows.rowIter.Close(rows.gmsCtx))
+	err := translateError(rows.rowIter.Close(rows.gmsCtx))
+	rows.saveWarnings()
+	if rows.cancel != nil {
+		rows.cancel()
+	}
+	rows.recording.finish(err)
+	return err
 }
 
 // Next is called to populate the next row of data into the provided slice. The provided slice will be the same size as
 // the Columns() are wide. Next returns io.EOF when there are no more rows.
-func (rows *doltRows) Next(dest []driver.Value) error {
+func (rows *doltRows) Next(dest []driver.Value) (err error) {
+	if rows.intercepted != nil {
+		return rows.intercepted.Next(dest)
+	}
+	defer rows.guard.recover(&err)
+
+	if rows.isQueryResultSet && rows.rowLimit.reached(rows.returned) {
+		return rows.truncate()
+	}
+
 	nextRow, err := rows.rowIter.Next(rows.gmsCtx)
 	if err != nil {
 		if err == io.EOF {
+			rows.saveWarnings()
 			return io.EOF
 		}
-		return translateError(err)
+		err = translateError(interruptError(rows.gmsCtx, err))
+		rows.recording.finish(err)
+		return err
 	}
 
 	if len(dest) != len(nextRow) {
 		return errors.New("mismatch between expected column count and actual column count")
 	}
 
+	if rows.isQueryResultSet {
+		rows.sessionStats.recordRowsRead(1)
+		rows.returned++
+	}
+
 	for i := range nextRow {
-		if v, ok := nextRow[i].(driver.Valuer); ok {
+		if nextRow[i] == nil {
+			// NULL is nil for every type, rather than the zero value of the type's conversion
+			dest[i] = nil
+		} else if v, ok := nextRow[i].(driver.Valuer); ok {
 			dest[i], err = v.Value()
 
 			if err != nil {
 				return fmt.Errorf("error processing column %d: %w", i, err)
 			}
 		} else if geomValue, ok := nextRow[i].(types.GeometryValue); ok {
-			dest[i] = geomValue.Serialize()
+			if dest[i], err = rows.geometryFormat.convertGeometry(rows.gmsCtx, geomValue); err != nil {
+				return fmt.Errorf("could not convert geometry for column %d: %w", i, err)
+			}
+		} else if bitType, ok := rows.sch[i].Type.(types.BitType); ok {
+			if v, _, err := bitType.Convert(nextRow[i]); err != nil {
+				return fmt.Errorf("could not convert to expected bit type for column %d: %w", i, err)
+			} else {
+				dest[i] = bitBytes(v.(uint64), bitType.NumberOfBits())
+			}
 		} else if enumType, ok := rows.sch[i].Type.(gms.EnumType); ok {
 			if v, _, err := enumType.Convert(nextRow[i]); err != nil {
 				return fmt.Errorf("could not convert to expected enum type for column %d: %w", i, err)
@@ -155,27 +225,71 @@ func (rows *doltRows) Next(dest []driver.Value) error {
 				dest[i] = setStr
 			}
 		} else {
-			dest[i] = nextRow[i]
+			dest[i] = integerValue(nextRow[i])
 		}
 	}
+	rows.recording.row(dest)
 
 	return nil
 }
 
+// bitBytes returns the value of a BIT(|numBits|) column as MySQL sends it: the bits as a big-endian byte slice, as
+// long as needed to hold |numBits| bits.
+func bitBytes(v uint64, numBits uint8) []byte {
+	b := make([]byte, (int(numBits)+7)/8)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return b
+}
+
+// integerValue returns |v| as an int64 if it is an integer that fits in one, like the MySQL driver returns the values
+// of integer columns, including YEAR. Unsigned BIGINT values are returned as uint64, so that values above MaxInt64
+// don't overflow. Other values are returned unchanged.
+func integerValue(v any) any {
+	switch v := v.(type) {
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	default:
+		return v
+	}
+}
+
 // peekableRowIter wrap another gms.RowIter and allows the caller to peek at results, without disturbing the order
 // that results are returned from the Next() method.
 type peekableRowIter struct {
 	iter  gms.RowIter
 	peeks []gms.Row
+	// err is the error returned by the last Peek, which Next returns after the peeked rows
+	err error
 }
 
 var _ gms.RowIter = (*peekableRowIter)(nil)
 
 // Peek returns the next row from this row iterator, without causing that row to be skipped from future calls
-// to Next(). There is no limit on how many rows can be peeked.
+// to Next(). There is no limit on how many rows can be peeked. An error, including io.EOF, is returned again by Next
+// once the peeked rows have been returned.
 func (p *peekableRowIter) Peek(ctx *gms.Context) (gms.Row, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
 	next, err := p.iter.Next(ctx)
 	if err != nil {
+		p.err = err
 		return nil, err
 	}
 	p.peeks = append(p.peeks, next)
@@ -189,6 +303,8 @@ func (p *peekableRowIter) Next(ctx *gms.Context) (gms.Row, error) {
 		peek := p.peeks[0]
 		p.peeks = p.peeks[1:]
 		return peek, nil
+	} else if p.err != nil {
+		return nil, p.err
 	}
 
 	return p.iter.Next(ctx)
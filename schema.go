@@ -0,0 +1,248 @@
+package embedded
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// Schema is the schema of the tables of a database at a revision, as returned by Connector.Schema.
+type Schema struct {
+	Database string
+	// Ref is the revision the schema was read at, or empty for the working set
+	Ref string
+	// Tables holds the schemas of the tables by name
+	Tables map[string]TableSchema
+}
+
+// TableSchema is the schema of a table.
+type TableSchema struct {
+	Name    string
+	Columns []ColumnSchema
+	// PrimaryKey are the names of the primary key's columns, in order
+	PrimaryKey []string
+	// Indexes are the secondary indexes of the table, sorted by name
+	Indexes []IndexSchema
+}
+
+// ColumnSchema is the schema of a column.
+type ColumnSchema struct {
+	Name string
+	// Type is the SQL type of the column, such as "varchar(20)" or "int"
+	Type     string
+	Nullable bool
+	// Default is the SQL expression of the column's default value, or empty if it has none
+	Default       string
+	AutoIncrement bool
+	Comment       string
+}
+
+// IndexSchema is the schema of a secondary index.
+type IndexSchema struct {
+	Name string
+	// Columns are the names of the indexed columns, in order
+	Columns []string
+	Unique  bool
+}
+
+// Column returns the column of the table named |name|, compared case-insensitively like MySQL does.
+func (t TableSchema) Column(name string) (ColumnSchema, bool) {
+	for _, col := range t.Columns {
+		if strings.EqualFold(col.Name, name) {
+			return col, true
+		}
+	}
+	return ColumnSchema{}, false
+}
+
+// Schema returns the schema of the tables of |database| at |ref|, a commit spec such as a branch, a tag, a commit
+// hash or HEAD~3 resolved like AsOf does, or of the working set of the branch new connections use if |ref| is empty.
+// It reads the schemas from the engine's catalog, so migration tooling doesn't need to parse SHOW CREATE TABLE.
+func (c *Connector) Schema(ctx context.Context, database, ref string) (Schema, error) {
+	if err := ctx.Err(); err != nil {
+		return Schema{}, err
+	}
+
+	eng, gmsCtx, err := c.newDatabaseSession(ctx, database)
+	if err != nil {
+		return Schema{}, err
+	}
+	defer eng.release()
+
+	dbName := database
+	if ref != "" {
+		commit, err := resolveAsOf(gmsCtx, database, ref)
+		if err != nil {
+			return Schema{}, translateError(err)
+		}
+		dbName = database + dsess.DbRevisionDelimiter + commit
+	}
+	gmsCtx.SetCurrentDatabase(dbName)
+
+	tx, err := gmsCtx.Session.(gms.TransactionSession).StartTransaction(gmsCtx, gms.ReadOnly)
+	if err != nil {
+		return Schema{}, translateError(err)
+	}
+	gmsCtx.SetTransaction(tx)
+	defer gmsCtx.Session.(gms.TransactionSession).Rollback(gmsCtx, tx)
+
+	db, err := eng.se.GetUnderlyingEngine().Analyzer.Catalog.Database(gmsCtx, dbName)
+	if err != nil {
+		return Schema{}, translateError(err)
+	}
+	names, err := db.GetTableNames(gmsCtx)
+	if err != nil {
+		return Schema{}, translateError(err)
+	}
+
+	schema := Schema{Database: database, Ref: ref, Tables: make(map[string]TableSchema, len(names))}
+	for _, name := range names {
+		tbl, ok, err := db.GetTableInsensitive(gmsCtx, name)
+		if err != nil {
+			return Schema{}, translateError(err)
+		} else if !ok {
+			continue
+		}
+		table, err := tableSchema(gmsCtx, tbl)
+		if err != nil {
+			return Schema{}, translateError(err)
+		}
+		schema.Tables[table.Name] = table
+	}
+
+	return schema, nil
+}
+
+// tableSchema returns the schema of |tbl|.
+func tableSchema(gmsCtx *gms.Context, tbl gms.Table) (TableSchema, error) {
+	table := TableSchema{Name: tbl.Name()}
+	for _, col := range tbl.Schema() {
+		column := ColumnSchema{
+			Name:          col.Name,
+			Type:          strings.ToLower(col.Type.String()),
+			Nullable:      col.Nullable,
+			AutoIncrement: col.AutoIncrement,
+			Comment:       col.Comment,
+		}
+		if col.Default != nil {
+			column.Default = col.Default.String()
+		}
+		table.Columns = append(table.Columns, column)
+		if col.PrimaryKey {
+			table.PrimaryKey = append(table.PrimaryKey, col.Name)
+		}
+	}
+
+	indexed, ok := tbl.(gms.IndexAddressable)
+	if !ok {
+		return table, nil
+	}
+	indexes, err := indexed.GetIndexes(gmsCtx)
+	if err != nil {
+		return TableSchema{}, err
+	}
+	for _, idx := range indexes {
+		if strings.EqualFold(idx.ID(), "PRIMARY") {
+			continue
+		}
+		index := IndexSchema{Name: idx.ID(), Unique: idx.IsUnique()}
+		for _, expr := range idx.Expressions() {
+			// Expressions are qualified with the table name
+			index.Columns = append(index.Columns, expr[strings.LastIndex(expr, ".")+1:])
+		}
+		table.Indexes = append(table.Indexes, index)
+	}
+	sort.Slice(table.Indexes, func(i, j int) bool { return table.Indexes[i].Name < table.Indexes[j].Name })
+
+	return table, nil
+}
+
+// TableDiff is the difference between the schemas of a table, as computed by SchemaDiff.
+type TableDiff struct {
+	Table string
+	// Added is true if the table only exists in the newer schema, whose columns are then all in AddedColumns
+	Added bool
+	// Removed is true if the table only exists in the older schema, whose columns are then all in RemovedColumns
+	Removed        bool
+	AddedColumns   []ColumnSchema
+	RemovedColumns []ColumnSchema
+	ChangedColumns []ColumnChange
+	AddedIndexes   []IndexSchema
+	RemovedIndexes []IndexSchema
+	// PrimaryKeyChanged is true if the columns of the primary key changed
+	PrimaryKeyChanged bool
+}
+
+// ColumnChange is a column whose definition changed between two schemas.
+type ColumnChange struct {
+	From ColumnSchema
+	To   ColumnSchema
+}
+
+// SchemaDiff returns the differences between the schemas |from| and |to|, by table name, for the tables that
+// differ. Columns are matched by name, so a renamed column is reported as removed and added. Indexes are matched by
+// name and definition, so a changed index is reported as removed and added.
+func SchemaDiff(from, to Schema) []TableDiff {
+	names := make(map[string]bool)
+	for name := range from.Tables {
+		names[name] = true
+	}
+	for name := range to.Tables {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var diffs []TableDiff
+	for _, name := range sorted {
+		fromTable, inFrom := from.Tables[name]
+		toTable, inTo := to.Tables[name]
+		diff := TableDiff{Table: name, Added: !inFrom, Removed: !inTo}
+
+		for _, col := range fromTable.Columns {
+			if toCol, ok := toTable.Column(col.Name); !ok {
+				diff.RemovedColumns = append(diff.RemovedColumns, col)
+			} else if col != toCol {
+				diff.ChangedColumns = append(diff.ChangedColumns, ColumnChange{From: col, To: toCol})
+			}
+		}
+		for _, col := range toTable.Columns {
+			if _, ok := fromTable.Column(col.Name); !ok {
+				diff.AddedColumns = append(diff.AddedColumns, col)
+			}
+		}
+		diff.RemovedIndexes = missingIndexes(fromTable.Indexes, toTable.Indexes)
+		diff.AddedIndexes = missingIndexes(toTable.Indexes, fromTable.Indexes)
+		diff.PrimaryKeyChanged = inFrom && inTo && !reflect.DeepEqual(fromTable.PrimaryKey, toTable.PrimaryKey)
+
+		if diff.Added || diff.Removed || len(diff.AddedColumns) > 0 || len(diff.RemovedColumns) > 0 ||
+			len(diff.ChangedColumns) > 0 || len(diff.AddedIndexes) > 0 || len(diff.RemovedIndexes) > 0 ||
+			diff.PrimaryKeyChanged {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs
+}
+
+// missingIndexes returns the indexes of |indexes| that aren't in |others| with the same definition.
+func missingIndexes(indexes, others []IndexSchema) []IndexSchema {
+	var missing []IndexSchema
+	for _, idx := range indexes {
+		found := false
+		for _, other := range others {
+			found = found || reflect.DeepEqual(idx, other)
+		}
+		if !found {
+			missing = append(missing, idx)
+		}
+	}
+	return missing
+}
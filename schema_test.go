@@ -0,0 +1,92 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	exec := func(queries ...string) {
+		for _, query := range queries {
+			_, err := conn.ExecContext(ctx, query)
+			require.NoError(t, err, query)
+		}
+	}
+	exec(
+		"create database test",
+		"use test",
+		"create table people (id int primary key auto_increment, name varchar(20) not null default 'anon', city varchar(20), index city_idx (city))",
+		"create table gone (id int primary key)",
+		"call dolt_commit('-Am', 'v1')",
+		"call dolt_tag('v1')",
+		"alter table people modify name varchar(40) not null default 'anon'",
+		"alter table people drop column city",
+		"alter table people add column email varchar(50) comment 'contact'",
+		"create unique index email_idx on people (email)",
+		"drop table gone",
+		"create table added (a int, b int, primary key (a, b))",
+	)
+
+	v1, err := connector.Schema(ctx, "test", "v1")
+	require.NoError(t, err)
+	require.Equal(t, "v1", v1.Ref)
+	require.Len(t, v1.Tables, 2)
+	people := v1.Tables["people"]
+	require.Equal(t, []string{"id"}, people.PrimaryKey)
+	require.Equal(t, []ColumnSchema{
+		{Name: "id", Type: "int", AutoIncrement: true},
+		{Name: "name", Type: "varchar(20)", Default: "('anon')"},
+		{Name: "city", Type: "varchar(20)", Nullable: true},
+	}, people.Columns)
+	require.Equal(t, []IndexSchema{{Name: "city_idx", Columns: []string{"city"}}}, people.Indexes)
+
+	working, err := connector.Schema(ctx, "test", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, working.Tables["added"].PrimaryKey)
+
+	diffs := SchemaDiff(v1, working)
+	require.Equal(t, []TableDiff{
+		{
+			Table:        "added",
+			Added:        true,
+			AddedColumns: []ColumnSchema{{Name: "a", Type: "int"}, {Name: "b", Type: "int"}},
+		},
+		{
+			Table:          "gone",
+			Removed:        true,
+			RemovedColumns: []ColumnSchema{{Name: "id", Type: "int"}},
+		},
+		{
+			Table:          "people",
+			AddedColumns:   []ColumnSchema{{Name: "email", Type: "varchar(50)", Nullable: true, Comment: "contact"}},
+			RemovedColumns: []ColumnSchema{{Name: "city", Type: "varchar(20)", Nullable: true}},
+			ChangedColumns: []ColumnChange{{
+				From: ColumnSchema{Name: "name", Type: "varchar(20)", Default: "('anon')"},
+				To:   ColumnSchema{Name: "name", Type: "varchar(40)", Default: "('anon')"},
+			}},
+			AddedIndexes:   []IndexSchema{{Name: "email_idx", Columns: []string{"email"}, Unique: true}},
+			RemovedIndexes: []IndexSchema{{Name: "city_idx", Columns: []string{"city"}}},
+		},
+	}, diffs)
+	require.Empty(t, SchemaDiff(working, working))
+
+	_, err = connector.Schema(ctx, "test", "missing")
+	require.Error(t, err)
+}
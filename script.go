@@ -0,0 +1,209 @@
+package embedded
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"strings"
+)
+
+// delimiterCommand is the client command that changes the delimiter of the statements that follow it in a script.
+const delimiterCommand = "DELIMITER"
+
+// ScriptOptions configures ExecScript.
+type ScriptOptions struct {
+	// Delimiter is the delimiter the script's statements are terminated by until a DELIMITER command changes it. It is
+	// ";" by default.
+	Delimiter string
+	// OnStatement, if set, is called after each statement of the script executes, with its position in the script
+	// starting at 1, to report the progress of long scripts.
+	OnStatement func(index int, query string)
+}
+
+// ExecScript executes the statements of the SQL script read from |r| on |conn|, one at a time, as they are read, so
+// that scripts such as multi-gigabyte dump files don't need to be held in memory. Statements are terminated by the
+// delimiter, outside of quotes and comments, and DELIMITER commands change the delimiter, as in the mysql client, so
+// that stored routines and triggers containing semicolons can be created with, e.g., DELIMITER ;;.
+//
+// ExecScript stops at the first statement that fails, and returns a *StatementError giving its position and line in
+// the script. The statements before it have been executed.
+func ExecScript(ctx context.Context, conn *sql.Conn, r io.Reader, opts ScriptOptions) error {
+	scanner := newScriptScanner(r, opts.Delimiter)
+	for index := 1; ; index++ {
+		query, line, err := scanner.next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return &StatementError{Index: index, Line: line, Query: query, Err: err}
+		}
+		if opts.OnStatement != nil {
+			opts.OnStatement(index, query)
+		}
+	}
+}
+
+// scriptScanner reads the statements of a SQL script from a reader.
+type scriptScanner struct {
+	r         *bufio.Reader
+	delimiter string
+	// line is the line of the script being read, starting at 1
+	line int
+	stmt bytes.Buffer
+}
+
+func newScriptScanner(r io.Reader, delimiter string) *scriptScanner {
+	if delimiter == "" {
+		delimiter = ";"
+	}
+	return &scriptScanner{r: bufio.NewReader(r), delimiter: delimiter, line: 1}
+}
+
+// next returns the next statement of the script, without its delimiter, and the line it starts on. It returns io.EOF
+// once the script has been read. Empty statements and DELIMITER commands are skipped.
+func (s *scriptScanner) next() (string, int, error) {
+	for {
+		if err := s.skipSpace(); err != nil {
+			return "", 0, err
+		}
+
+		if ok, err := s.readDelimiterCommand(); err != nil {
+			return "", 0, err
+		} else if ok {
+			continue
+		}
+
+		line := s.line
+		query, err := s.readStatement()
+		if err != nil && err != io.EOF {
+			return "", 0, err
+		}
+		if query = strings.TrimSpace(query); query != "" {
+			return query, line, nil
+		} else if err == io.EOF {
+			return "", 0, io.EOF
+		}
+	}
+}
+
+// readByte reads the next byte of the script, counting its lines.
+func (s *scriptScanner) readByte() (byte, error) {
+	b, err := s.r.ReadByte()
+	if b == '\n' {
+		s.line++
+	}
+	return b, err
+}
+
+// peekByte returns the byte following the next |n| bytes of the script, or 0 if there is none.
+func (s *scriptScanner) peekByte(n int) byte {
+	peek, _ := s.r.Peek(n + 1)
+	if len(peek) <= n {
+		return 0
+	}
+	return peek[n]
+}
+
+// skipSpace skips the whitespace preceding the next statement.
+func (s *scriptScanner) skipSpace() error {
+	for {
+		b, err := s.readByte()
+		if err != nil {
+			return err
+		}
+		if !isSpace(b) {
+			return s.r.UnreadByte()
+		}
+	}
+}
+
+// readDelimiterCommand reads a DELIMITER command, which takes the rest of its line, if the script continues with one,
+// and changes the delimiter to its argument.
+func (s *scriptScanner) readDelimiterCommand() (bool, error) {
+	peek, _ := s.r.Peek(len(delimiterCommand) + 1)
+	if len(peek) <= len(delimiterCommand) || !strings.EqualFold(string(peek[:len(delimiterCommand)]), delimiterCommand) ||
+		!isSpace(peek[len(delimiterCommand)]) {
+		return false, nil
+	}
+
+	line, err := s.r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	if strings.HasSuffix(line, "\n") {
+		s.line++
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return false, errors.New("DELIMITER must be followed by a delimiter")
+	}
+	s.delimiter = fields[1]
+	return true, nil
+}
+
+// readStatement reads the script up to the next delimiter outside of quotes and comments, and returns the statement
+// preceding it. It returns io.EOF with the rest of the script if it isn't terminated by a delimiter.
+func (s *scriptScanner) readStatement() (string, error) {
+	s.stmt.Reset()
+
+	var quote byte
+	var lineComment, blockComment bool
+	for {
+		b, err := s.readByte()
+		if err != nil {
+			return s.stmt.String(), err
+		}
+		s.stmt.WriteByte(b)
+
+		switch {
+		case lineComment:
+			lineComment = b != '\n'
+		case blockComment:
+			blockComment = !(b == '*' && s.peekByte(0) == '/')
+			if !blockComment {
+				s.stmt.WriteByte(s.mustReadByte())
+			}
+		case quote != 0:
+			if b == '\\' && quote != '`' {
+				if next, err := s.readByte(); err == nil {
+					s.stmt.WriteByte(next)
+				}
+			} else if b == quote {
+				if s.peekByte(0) == quote {
+					// a doubled quote character is an escaped quote
+					s.stmt.WriteByte(s.mustReadByte())
+				} else {
+					quote = 0
+				}
+			}
+		case b == '\'' || b == '"' || b == '`':
+			quote = b
+		case b == '#':
+			lineComment = true
+		case b == '-' && s.peekByte(0) == '-' && (isSpace(s.peekByte(1)) || s.peekByte(1) == 0):
+			lineComment = true
+		case b == '/' && s.peekByte(0) == '*':
+			blockComment = true
+			s.stmt.WriteByte(s.mustReadByte())
+		case bytes.HasSuffix(s.stmt.Bytes(), []byte(s.delimiter)):
+			return string(s.stmt.Bytes()[:s.stmt.Len()-len(s.delimiter)]), nil
+		}
+	}
+}
+
+// mustReadByte reads a byte that has already been peeked.
+func (s *scriptScanner) mustReadByte() byte {
+	b, _ := s.readByte()
+	return b
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == '\v'
+}
@@ -0,0 +1,79 @@
+package embedded
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptScanner(t *testing.T) {
+	script := `-- a dump; with comments
+CREATE TABLE t (id int primary key, name varchar(20)); # create; it
+INSERT INTO t VALUES (1, 'a;b'), (2, "it""s;"), (3, 'don\'t;');;
+/* block; comment */ INSERT INTO t VALUES (4, ` + "`x;`" + `);
+DELIMITER ;;
+CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW BEGIN SET NEW.name = concat(NEW.name, ';'); END;;
+delimiter //
+SELECT 1//
+DELIMITER ;
+SELECT 2`
+
+	type statement struct {
+		query string
+		line  int
+	}
+	var stmts []statement
+	scanner := newScriptScanner(strings.NewReader(script), "")
+	for {
+		query, line, err := scanner.next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		stmts = append(stmts, statement{query, line})
+	}
+
+	require.Equal(t, []statement{
+		{"-- a dump; with comments\nCREATE TABLE t (id int primary key, name varchar(20))", 1},
+		{"# create; it\nINSERT INTO t VALUES (1, 'a;b'), (2, \"it\"\"s;\"), (3, 'don\\'t;')", 2},
+		{"/* block; comment */ INSERT INTO t VALUES (4, `x;`)", 4},
+		{"CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW BEGIN SET NEW.name = concat(NEW.name, ';'); END", 6},
+		{"SELECT 1", 8},
+		{"SELECT 2", 10},
+	}, stmts)
+}
+
+func TestExecScript(t *testing.T) {
+	conn, cleanupFunc := initializeTestDatabaseConnection(t, false)
+	defer cleanupFunc()
+
+	ctx := context.Background()
+	script := `CREATE TABLE t (id int primary key, name varchar(20));
+DELIMITER ;;
+CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW BEGIN SET NEW.name = concat(NEW.name, '!'); END;;
+DELIMITER ;
+INSERT INTO t VALUES (1, 'a;b');
+INSERT INTO t VALUES (2, 'c');
+`
+	var executed []int
+	err := ExecScript(ctx, conn, strings.NewReader(script), ScriptOptions{
+		OnStatement: func(index int, query string) {
+			executed = append(executed, index)
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3, 4}, executed)
+	requireResults(t, conn, "SELECT * FROM t ORDER BY id", [][]any{{1, "a;b!"}, {2, "c!"}})
+
+	// The script stops at the first failed statement, which the error locates
+	err = ExecScript(ctx, conn, strings.NewReader("INSERT INTO t VALUES (3, 'd');\n\nINSERT INTO t VALUES (4);\nINSERT INTO t VALUES (5, 'e');"), ScriptOptions{})
+	var stmtErr *StatementError
+	require.ErrorAs(t, err, &stmtErr)
+	require.Equal(t, 2, stmtErr.Index)
+	require.Equal(t, 3, stmtErr.Line)
+	require.Equal(t, "INSERT INTO t VALUES (4)", stmtErr.Query)
+	requireResults(t, conn, "SELECT * FROM t ORDER BY id", [][]any{{1, "a;b!"}, {2, "c!"}, {3, "d!"}})
+}
@@ -0,0 +1,96 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/server"
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/mysql"
+)
+
+// ErrUnauthenticatedServe is returned by ServeMySQL for a TCP address other than a loopback one when Config.Users
+// isn't set, since any client that can reach the listener would then have the root user's access to the databases.
+var ErrUnauthenticatedServe = errors.New("serving over TCP without Config.Users is only allowed on a loopback address")
+
+// ServeMySQL serves the connector's engine over the MySQL wire protocol on |addr| until |ctx| is done, so that tools
+// like the dolt CLI, MySQL clients and drivers in other languages can inspect the live databases this process has
+// open, which they couldn't open themselves while this process holds them. |addr| is a host:port to listen on over
+// TCP, or the path of a unix socket if it contains a '/'. When Config.Users is set, clients must authenticate as one
+// of the users, and are subject to its grants. Otherwise, any client is root without a password, so |addr| must be a
+// unix socket or a loopback address, such as 127.0.0.1 or localhost, or ErrUnauthenticatedServe is returned.
+// ServeMySQL blocks until |ctx| is done and the listener is closed,
+// returning nil, or returns the error that prevented it from listening.
+func (c *Connector) ServeMySQL(ctx context.Context, addr string) error {
+	if c.proxied() != nil {
+		return ErrProxied
+	}
+
+	network := "tcp"
+	if strings.ContainsRune(addr, '/') {
+		network = "unix"
+	} else if len(c.cfg.Users) == 0 && !loopbackAddr(addr) {
+		return ErrUnauthenticatedServe
+	}
+
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+
+	return c.serveMySQL(ctx, l)
+}
+
+// loopbackAddr returns whether the host of the host:port |addr| is a loopback address. An empty host listens on all
+// addresses, so it isn't.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	} else if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// serveMySQL serves the connector's current engine on |l| until |ctx| is done. Clients connected over the wire keep
+// using the engine that was current when ServeMySQL was called, even if RefreshDatabases replaces it.
+func (c *Connector) serveMySQL(ctx context.Context, l net.Listener) error {
+	c.mu.Lock()
+	eng := c.engine
+	eng.acquire()
+	c.mu.Unlock()
+	defer eng.release()
+
+	sessionBuilder := func(ctx context.Context, conn *mysql.Conn, addr string) (gms.Session, error) {
+		baseSession, err := gms.BaseSessionFromConnection(ctx, conn, addr)
+		if err != nil {
+			return nil, err
+		}
+		return eng.se.NewDoltSession(ctx, baseSession)
+	}
+
+	srv, err := server.NewServer(server.Config{
+		Protocol: l.Addr().Network(),
+		Address:  l.Addr().String(),
+		Listener: l,
+	}, eng.se.GetUnderlyingEngine(), sessionBuilder, nil)
+	if err != nil {
+		l.Close()
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.Start()
+	}()
+
+	<-ctx.Done()
+	srv.Close()
+	<-done
+	return nil
+}
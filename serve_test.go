@@ -0,0 +1,55 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeMySQL(t *testing.T) {
+	connector, db, cleanUp := initializeTestConnector(t)
+	defer cleanUp()
+
+	ctx := context.Background()
+	_, err := db.ExecContext(ctx, "create table t (pk int primary key)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "insert into t values (1), (2)")
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	serveCtx, stop := context.WithCancel(ctx)
+	served := make(chan error)
+	go func() {
+		served <- connector.serveMySQL(serveCtx, l)
+	}()
+
+	client, err := sql.Open("mysql", "root@tcp("+l.Addr().String()+")/testdb")
+	require.NoError(t, err)
+	defer client.Close()
+
+	var count int
+	require.NoError(t, client.QueryRowContext(ctx, "select count(*) from t").Scan(&count))
+	require.Equal(t, 2, count)
+
+	// Writes over the wire are seen by the embedded connections
+	_, err = client.ExecContext(ctx, "insert into t values (3)")
+	require.NoError(t, err)
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from t").Scan(&count))
+	require.Equal(t, 3, count)
+
+	stop()
+	require.NoError(t, <-served)
+
+	// Without users, clients are root, so the connector only serves them on a loopback address
+	for _, addr := range []string{":0", "0.0.0.0:0", "[::]:0", "192.0.2.1:0", "example.com:0"} {
+		require.ErrorIs(t, connector.ServeMySQL(ctx, addr), ErrUnauthenticatedServe, addr)
+	}
+	serveCtx, stop = context.WithCancel(ctx)
+	stop()
+	require.NoError(t, connector.ServeMySQL(serveCtx, "localhost:0"))
+}
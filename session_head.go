@@ -0,0 +1,126 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// Head describes the version of the data seen by a connection in its current database.
+type Head struct {
+	// Database is the connection's current database
+	Database string
+	// Branch is the branch checked out by the connection, or empty if it is on a detached head
+	Branch string
+	// Hash is the hash of the HEAD commit
+	Hash string
+	// WorkingHash is the hash of the working set, which includes changes that haven't been committed
+	WorkingHash string
+	// Dirty reports whether the working set differs from HEAD
+	Dirty bool
+}
+
+// Head returns the HEAD commit and working set status of this connection's current database. It reads them from
+// storage without running any SQL, so it is cheap enough to build cache keys from. Inside an explicit transaction, it
+// reports the transaction's view of the data, including its uncommitted changes.
+func (d *DoltConn) Head() (Head, error) {
+	dbName := d.gmsCtx.GetCurrentDatabase()
+	if dbName == "" {
+		return Head{}, fmt.Errorf("no database selected")
+	}
+
+	head, _, err := d.head(dbName)
+	return head, err
+}
+
+// head returns the Head of |dbName| as seen by this connection, and its HEAD commit.
+func (d *DoltConn) head(dbName string) (Head, *doltdb.Commit, error) {
+	sess := dsess.DSessFromSess(d.gmsCtx.Session)
+	head := Head{Database: dbName}
+
+	var commit *doltdb.Commit
+	var working doltdb.RootValue
+	branchRef, err := sess.CWBHeadRef(d.gmsCtx, dbName)
+	if err == nil {
+		head.Branch = branchRef.GetPath()
+	}
+
+	if err == nil && d.gmsCtx.GetTransaction() == nil {
+		// The session's state is only refreshed when a transaction starts, so it can miss the last statement's
+		// commits. Read the branch from storage instead.
+		dbData, ok := sess.GetDbData(d.gmsCtx, dbName)
+		if !ok {
+			return Head{}, nil, translateError(gms.ErrDatabaseNotFound.New(dbName))
+		}
+		if commit, err = dbData.Ddb.ResolveCommitRef(d.gmsCtx, branchRef); err != nil {
+			return Head{}, nil, err
+		}
+		wsRef, err := ref.WorkingSetRefForHead(branchRef)
+		if err != nil {
+			return Head{}, nil, err
+		}
+		ws, err := dbData.Ddb.ResolveWorkingSet(d.gmsCtx, wsRef)
+		if err != nil {
+			return Head{}, nil, err
+		}
+		working = ws.WorkingRoot()
+	} else {
+		roots, ok := sess.GetRoots(d.gmsCtx, dbName)
+		if !ok {
+			return Head{}, nil, translateError(gms.ErrDatabaseNotFound.New(dbName))
+		}
+		if commit, err = sess.GetHeadCommit(d.gmsCtx, dbName); err != nil {
+			return Head{}, nil, translateError(err)
+		}
+		working = roots.Working
+	}
+
+	commitHash, err := commit.HashOf()
+	if err != nil {
+		return Head{}, nil, err
+	}
+	head.Hash = commitHash.String()
+
+	headRoot, err := commit.GetRootValue(d.gmsCtx)
+	if err != nil {
+		return Head{}, nil, err
+	}
+	headHash, err := headRoot.HashOf()
+	if err != nil {
+		return Head{}, nil, err
+	}
+	workingHash, err := working.HashOf()
+	if err != nil {
+		return Head{}, nil, err
+	}
+	head.WorkingHash = workingHash.String()
+	head.Dirty = workingHash != headHash
+
+	return head, commit, nil
+}
+
+// SessionHead returns the Head of |conn|, which must be a connection opened with the dolt driver.
+func SessionHead(ctx context.Context, conn *sql.Conn) (Head, error) {
+	if err := ctx.Err(); err != nil {
+		return Head{}, err
+	}
+
+	var head Head
+	err := conn.Raw(func(driverConn any) error {
+		doltConn, ok := driverConn.(*DoltConn)
+		if !ok {
+			return fmt.Errorf("not a dolt connection: %T", driverConn)
+		}
+
+		var err error
+		head, err = doltConn.Head()
+		return err
+	})
+
+	return head, err
+}
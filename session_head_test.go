@@ -0,0 +1,56 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionHead(t *testing.T) {
+	_, db, cleanUpFunc := initializeTestConnector(t)
+	defer cleanUpFunc()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	initial, err := SessionHead(ctx, conn)
+	require.NoError(t, err)
+	require.Equal(t, "testdb", initial.Database)
+	require.Equal(t, "main", initial.Branch)
+	require.NotEmpty(t, initial.Hash)
+	require.False(t, initial.Dirty)
+
+	_, err = conn.ExecContext(ctx, "create table t (id int primary key)")
+	require.NoError(t, err)
+	dirty, err := SessionHead(ctx, conn)
+	require.NoError(t, err)
+	require.True(t, dirty.Dirty)
+	require.Equal(t, initial.Hash, dirty.Hash)
+	require.NotEqual(t, initial.WorkingHash, dirty.WorkingHash)
+
+	_, err = conn.ExecContext(ctx, "call dolt_commit('-Am', 'create t')")
+	require.NoError(t, err)
+	committed, err := SessionHead(ctx, conn)
+	require.NoError(t, err)
+	require.False(t, committed.Dirty)
+	require.NotEqual(t, initial.Hash, committed.Hash)
+	require.Equal(t, dirty.WorkingHash, committed.WorkingHash)
+
+	var hash string
+	require.NoError(t, conn.QueryRowContext(ctx, "select hashof('HEAD')").Scan(&hash))
+	require.Equal(t, hash, committed.Hash)
+
+	// Inside a transaction, uncommitted changes are visible to the connection only
+	tx, err := conn.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "insert into t values (1)")
+	require.NoError(t, err)
+	inTx, err := SessionHead(ctx, conn)
+	require.NoError(t, err)
+	require.True(t, inTx.Dirty)
+	require.Equal(t, committed.Hash, inTx.Hash)
+	require.NoError(t, tx.Rollback())
+}
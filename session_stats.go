@@ -0,0 +1,92 @@
+package embedded
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+)
+
+// SessionStats holds counters for the statements executed on a single connection since it was opened. They are counted
+// by the driver as statements execute, so they include statements that failed partway through or were executed again
+// by the caller, which client-side accounting tends to miss.
+type SessionStats struct {
+	// Statements is the number of statements executed
+	Statements int64
+	// Errors is the number of statements that returned an error
+	Errors int64
+	// RowsRead is the number of rows returned to the caller by queries
+	RowsRead int64
+	// RowsWritten is the number of rows affected by statements that modify data
+	RowsWritten int64
+}
+
+// sessionStats accumulates the SessionStats of a DoltConn. The counters may be read from other goroutines while the
+// connection is in use, so they are updated atomically. A nil *sessionStats discards everything recorded in it.
+type sessionStats struct {
+	statements  atomic.Int64
+	errors      atomic.Int64
+	rowsRead    atomic.Int64
+	rowsWritten atomic.Int64
+}
+
+// recordStatement counts a statement that returned |err|.
+func (s *sessionStats) recordStatement(err error) {
+	if s == nil {
+		return
+	}
+
+	s.statements.Add(1)
+	if err != nil {
+		s.errors.Add(1)
+	}
+}
+
+// recordRowsRead counts |n| rows returned by a query.
+func (s *sessionStats) recordRowsRead(n int64) {
+	if s == nil {
+		return
+	}
+	s.rowsRead.Add(n)
+}
+
+// recordRowsWritten counts |n| rows affected by a statement.
+func (s *sessionStats) recordRowsWritten(n int64) {
+	if s == nil {
+		return
+	}
+	s.rowsWritten.Add(n)
+}
+
+func (s *sessionStats) snapshot() SessionStats {
+	if s == nil {
+		return SessionStats{}
+	}
+
+	return SessionStats{
+		Statements:  s.statements.Load(),
+		Errors:      s.errors.Load(),
+		RowsRead:    s.rowsRead.Load(),
+		RowsWritten: s.rowsWritten.Load(),
+	}
+}
+
+// SessionStats returns the statistics of the statements executed on this connection.
+func (d *DoltConn) SessionStats() SessionStats {
+	return d.sessionStats.snapshot()
+}
+
+// GetSessionStats returns the SessionStats of |conn|, which must be a connection opened with the dolt driver.
+func GetSessionStats(conn *sql.Conn) (SessionStats, error) {
+	var stats SessionStats
+	err := conn.Raw(func(driverConn any) error {
+		doltConn, ok := driverConn.(*DoltConn)
+		if !ok {
+			return fmt.Errorf("not a dolt connection: %T", driverConn)
+		}
+
+		stats = doltConn.SessionStats()
+		return nil
+	})
+
+	return stats, err
+}
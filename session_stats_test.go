@@ -0,0 +1,46 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStats(t *testing.T) {
+	conn, cleanupFunc := initializeTestDatabaseConnection(t, false)
+	defer cleanupFunc()
+
+	ctx := context.Background()
+	before, err := GetSessionStats(conn)
+	require.NoError(t, err)
+
+	_, err = conn.ExecContext(ctx, "create table t (id int primary key)")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "insert into t values (1), (2), (3)")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "insert into t values (1)")
+	require.Error(t, err)
+
+	// Inserts run through Query are counted as writes, not reads
+	rows, err := conn.QueryContext(ctx, "insert into t values (4); select * from t where id > 1")
+	require.NoError(t, err)
+	for rows.Next() {
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+
+	after, err := GetSessionStats(conn)
+	require.NoError(t, err)
+	require.Equal(t, SessionStats{
+		Statements:  5,
+		Errors:      1,
+		RowsRead:    3,
+		RowsWritten: 4,
+	}, SessionStats{
+		Statements:  after.Statements - before.Statements,
+		Errors:      after.Errors - before.Errors,
+		RowsRead:    after.RowsRead - before.RowsRead,
+		RowsWritten: after.RowsWritten - before.RowsWritten,
+	})
+}
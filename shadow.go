@@ -0,0 +1,170 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// ShadowDivergence describes a statement whose outcome on the shadow MySQL server configured with Config.ShadowDSN
+// differed from its outcome on the embedded Dolt database.
+type ShadowDivergence struct {
+	// Query is the statement that was mirrored
+	Query string
+	// Args are the arguments bound to the statement's placeholders
+	Args []driver.Value
+	// RowsAffected is the number of rows the statement affected in Dolt
+	RowsAffected int64
+	// Err is the error the statement returned in Dolt
+	Err error
+	// ShadowRowsAffected is the number of rows the statement affected on the shadow server
+	ShadowRowsAffected int64
+	// ShadowErr is the error the statement returned on the shadow server, or the error that prevented it from being
+	// mirrored
+	ShadowErr error
+}
+
+// String returns a description of the divergence suitable for logging.
+func (d ShadowDivergence) String() string {
+	return fmt.Sprintf("shadow divergence for '%s' %v: dolt affected %d rows (err: %v), shadow affected %d rows (err: %v)",
+		d.Query, d.Args, d.RowsAffected, d.Err, d.ShadowRowsAffected, d.ShadowErr)
+}
+
+// diverged returns whether the outcomes recorded in |d| differ. Error messages differ between the two engines, so two
+// failed statements are considered to agree.
+func (d ShadowDivergence) diverged() bool {
+	if (d.Err == nil) != (d.ShadowErr == nil) {
+		return true
+	}
+
+	return d.Err == nil && d.RowsAffected != d.ShadowRowsAffected
+}
+
+// shadowDB is the MySQL server that writes are mirrored to in dual-write mode.
+type shadowDB struct {
+	db     *sql.DB
+	report func(ShadowDivergence)
+}
+
+// openShadowDB opens the shadow server configured in |cfg|, and returns nil if dual-write mode isn't enabled.
+func openShadowDB(ctx context.Context, cfg Config) (*shadowDB, error) {
+	if cfg.ShadowDSN == "" {
+		return nil, nil
+	}
+
+	db, err := sql.Open("mysql", cfg.ShadowDSN)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to connect to shadow database: %w", err)
+	}
+
+	report := cfg.OnShadowDivergence
+	if report == nil {
+		report = func(d ShadowDivergence) {
+			cfg.printf("%s", d.String())
+		}
+	}
+
+	return &shadowDB{db: db, report: report}, nil
+}
+
+// newConn returns a shadowConn mirroring the statements of one connection. Each connection gets its own session on
+// the shadow server so that session state, like the current database and open transactions, matches.
+func (s *shadowDB) newConn() *shadowConn {
+	if s == nil {
+		return nil
+	}
+
+	return &shadowConn{shadow: s}
+}
+
+// Close closes the connection pool to the shadow server.
+func (s *shadowDB) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	return s.db.Close()
+}
+
+// shadowConn mirrors the statements of a single DoltConn to a session on the shadow server. The session is opened on
+// the first mirrored statement. A nil *shadowConn mirrors nothing.
+type shadowConn struct {
+	shadow *shadowDB
+	conn   *sql.Conn
+}
+
+// mirror executes |query| with |args| on the shadow server if it could modify data or session state, and reports a
+// divergence if its outcome differs from the |affected| rows and |err| the statement had in Dolt. Divergences are
+// reported rather than returned, so the shadow server never affects the application.
+func (s *shadowConn) mirror(ctx context.Context, query string, args []driver.Value, affected int64, err error) {
+	if s == nil || !shouldMirror(query) {
+		return
+	}
+
+	d := ShadowDivergence{Query: query, Args: args, RowsAffected: affected, Err: err}
+	d.ShadowRowsAffected, d.ShadowErr = s.exec(ctx, query, args)
+	if d.diverged() {
+		s.shadow.report(d)
+	}
+}
+
+// exec executes |query| with |args| on the shadow session and returns the number of affected rows.
+func (s *shadowConn) exec(ctx context.Context, query string, args []driver.Value) (int64, error) {
+	if s.conn == nil {
+		conn, err := s.shadow.db.Conn(ctx)
+		if err != nil {
+			return 0, err
+		}
+		s.conn = conn
+	}
+
+	values := make([]any, len(args))
+	for i := range args {
+		values[i] = args[i]
+	}
+
+	res, err := s.conn.ExecContext(ctx, query, values...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Close closes the shadow session.
+func (s *shadowConn) Close() error {
+	if s == nil || s.conn == nil {
+		return nil
+	}
+
+	return s.conn.Close()
+}
+
+// shouldMirror returns whether |query| should be mirrored to the shadow server. Reads are not mirrored, since only the
+// effects of writes are compared, and neither are calls to Dolt's stored procedures, which MySQL doesn't have.
+// Statements that don't parse are mirrored, so that the shadow server's verdict on them is compared too.
+func shouldMirror(query string) bool {
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return true
+	}
+
+	switch stmt := stmt.(type) {
+	case *sqlparser.Select, *sqlparser.SetOp, *sqlparser.ParenSelect, *sqlparser.Show, *sqlparser.OtherRead,
+		*sqlparser.Explain:
+		return false
+	case *sqlparser.Call:
+		return !strings.HasPrefix(strings.ToLower(stmt.ProcName.Name.String()), "dolt_")
+	default:
+		return true
+	}
+}
@@ -0,0 +1,59 @@
+package embedded
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldMirror(t *testing.T) {
+	tests := []struct {
+		query  string
+		mirror bool
+	}{
+		{"select * from t", false},
+		{"select 1 union select 2", false},
+		{"show tables", false},
+		{"explain insert into t values (1)", false},
+		{"insert into t values (?)", true},
+		{"update t set a = 1", true},
+		{"create table t (id int primary key)", true},
+		{"use mydb", true},
+		{"set @a = 1", true},
+		{"call my_proc()", true},
+		{"call dolt_commit('-am', 'message')", false},
+		{"CALL DOLT_ADD('.')", false},
+		{"not sql at all", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			require.Equal(t, test.mirror, shouldMirror(test.query))
+		})
+	}
+}
+
+func TestShadowDivergence(t *testing.T) {
+	err := errors.New("duplicate key")
+	require.False(t, ShadowDivergence{RowsAffected: 2, ShadowRowsAffected: 2}.diverged())
+	require.True(t, ShadowDivergence{RowsAffected: 2, ShadowRowsAffected: 1}.diverged())
+	require.False(t, ShadowDivergence{Err: err, ShadowErr: errors.New("Duplicate entry")}.diverged())
+	require.True(t, ShadowDivergence{Err: err}.diverged())
+	require.True(t, ShadowDivergence{ShadowErr: err}.diverged())
+}
+
+func TestShadowDSNUnreachable(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		ShadowDSN:   "root@tcp(127.0.0.1:1)/",
+	})
+	require.ErrorContains(t, err, "shadow database")
+}
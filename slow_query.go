@@ -0,0 +1,145 @@
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// defaultSlowQueryExplainInterval is the minimum time between two EXPLAIN captures when
+// Config.SlowQueryExplainInterval isn't set.
+const defaultSlowQueryExplainInterval = time.Minute
+
+// SlowQuery describes a statement that took longer than Config.SlowQueryThreshold to execute.
+type SlowQuery struct {
+	// Query is the statement that was executed
+	Query string
+	// Tag holds the query tags the statement was executed with, see WithQueryTag
+	Tag string
+	// Latency is how long the statement took to execute
+	Latency time.Duration
+	// Err is the error the statement returned, if any
+	Err error
+	// Explain is the EXPLAIN output for the statement, captured when Config.ExplainSlowQueries is set. It's empty if
+	// no plan was captured, because the statement can't be explained or another plan was captured too recently.
+	Explain string
+}
+
+// slowQueryLog reports the statements exceeding a latency threshold to a hook. It is shared by all the connections of
+// a Connector, so it is safe for concurrent use. A nil *slowQueryLog reports nothing.
+type slowQueryLog struct {
+	se              *engine.SqlEngine
+	threshold       time.Duration
+	hook            func(SlowQuery)
+	explain         bool
+	explainInterval time.Duration
+
+	// now is the connector's clock, which spaces EXPLAIN captures
+	now func() time.Time
+
+	mu          sync.Mutex
+	lastExplain time.Time
+}
+
+// newSlowQueryLog returns the slowQueryLog configured by |cfg|, or nil if slow query reporting isn't enabled.
+func newSlowQueryLog(se *engine.SqlEngine, cfg Config) *slowQueryLog {
+	if cfg.SlowQueryThreshold <= 0 || cfg.OnSlowQuery == nil {
+		return nil
+	}
+
+	explainInterval := cfg.SlowQueryExplainInterval
+	if explainInterval <= 0 {
+		explainInterval = defaultSlowQueryExplainInterval
+	}
+
+	return &slowQueryLog{
+		se:              se,
+		threshold:       cfg.SlowQueryThreshold,
+		hook:            cfg.OnSlowQuery,
+		explain:         cfg.ExplainSlowQueries,
+		explainInterval: explainInterval,
+		now:             cfg.now,
+	}
+}
+
+// observe reports an execution of |query| with |args| to the hook if its |latency| exceeds the threshold. |gmsCtx| is
+// the session the query ran in.
+func (l *slowQueryLog) observe(gmsCtx *gms.Context, query, tag string, args []driver.Value, latency time.Duration, err error) {
+	if l == nil || latency < l.threshold {
+		return
+	}
+
+	slow := SlowQuery{
+		Query:   query,
+		Tag:     tag,
+		Latency: latency,
+		Err:     err,
+	}
+	if l.explain && l.reserveExplain() {
+		slow.Explain, _ = l.explainQuery(gmsCtx, query, args)
+	}
+
+	l.hook(slow)
+}
+
+// reserveExplain returns whether enough time has passed since the last EXPLAIN capture to capture another one, and
+// if so records that one is being captured now.
+func (l *slowQueryLog) reserveExplain() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.lastExplain.IsZero() && now.Sub(l.lastExplain) < l.explainInterval {
+		return false
+	}
+
+	l.lastExplain = now
+	return true
+}
+
+// explainQuery returns the EXPLAIN output for |query|. The plan is computed in a new session, using the current
+// database of |gmsCtx|, so it can't interfere with results that are still being read from |gmsCtx|.
+func (l *slowQueryLog) explainQuery(gmsCtx *gms.Context, query string, args []driver.Value) (string, error) {
+	explainCtx, err := l.se.NewLocalContext(context.Background())
+	if err != nil {
+		return "", err
+	}
+	explainCtx.SetCurrentDatabase(gmsCtx.GetCurrentDatabase())
+
+	var bindings map[string]sqlparser.Expr
+	if len(args) != 0 {
+		bindings, err = argsToBindings(args)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	_, itr, _, err := l.se.GetUnderlyingEngine().QueryWithBindings(explainCtx, "EXPLAIN "+query, nil, bindings, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var plan []string
+	for {
+		row, err := itr.Next(explainCtx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			itr.Close(explainCtx)
+			return "", err
+		}
+		if len(row) > 0 {
+			plan = append(plan, fmt.Sprint(row[0]))
+		}
+	}
+
+	return strings.Join(plan, "\n"), itr.Close(explainCtx)
+}
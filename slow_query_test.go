@@ -0,0 +1,67 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowQueryExplain(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var slowQueries []SlowQuery
+	connector, err := NewConnector(Config{
+		Directory:          dir,
+		CommitName:         "Billy Batson",
+		CommitEmail:        "shazam@gmail.com",
+		Database:           "testdb",
+		SlowQueryThreshold: time.Nanosecond,
+		OnSlowQuery: func(slow SlowQuery) {
+			mu.Lock()
+			defer mu.Unlock()
+			slowQueries = append(slowQueries, slow)
+		},
+		ExplainSlowQueries:       true,
+		SlowQueryExplainInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := WithQueryTag(context.Background(), "job=report")
+	_, err = db.ExecContext(context.Background(), "create database testdb")
+	require.NoError(t, err)
+	_, err = db.ExecContext(context.Background(), "create table t (id int primary key, v int)")
+	require.NoError(t, err)
+
+	// Only one plan is captured per interval, so discard the ones reported so far
+	mu.Lock()
+	slowQueries = nil
+	connector.engine.slowLog.lastExplain = time.Time{}
+	mu.Unlock()
+
+	rows, err := db.QueryContext(ctx, "select * from t where id = ?", 1)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+	rows, err = db.QueryContext(ctx, "select * from t where v = 2")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, slowQueries, 2)
+	require.Equal(t, "select * from t where id = ?", slowQueries[0].Query)
+	require.Equal(t, "job=report", slowQueries[0].Tag)
+	require.True(t, slowQueries[0].Latency > 0)
+	require.Contains(t, slowQueries[0].Explain, "IndexedTableAccess(t)")
+	require.Equal(t, "select * from t where v = 2", slowQueries[1].Query)
+	require.Empty(t, slowQueries[1].Explain)
+}
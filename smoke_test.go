@@ -3,6 +3,8 @@ package embedded
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"math"
 	"net/url"
 	"os"
 	"strings"
@@ -151,7 +153,11 @@ func TestMultiStatementsExecContext(t *testing.T) {
 		"INSERT into example_table VALUES (1, 2, 'too many'); SET @allStatementsExecuted=1;")
 	require.NotNil(t, err)
 	if !runTestsAgainstMySQL {
-		require.Equal(t, "Error 1105: number of values does not match number of columns provided", err.Error())
+		require.Equal(t, "statement 2: INSERT into example_table VALUES (1, 2, 'too many'): "+
+			"Error 1105: number of values does not match number of columns provided", err.Error())
+		var stmtErr *StatementError
+		require.ErrorAs(t, err, &stmtErr)
+		require.Equal(t, 2, stmtErr.Index)
 	} else {
 		require.Equal(t, "Error 1136 (21S01): Column count doesn't match value count at row 1", err.Error())
 	}
@@ -464,28 +470,46 @@ func TestClientFoundRows(t *testing.T) {
 // TestQueryContextInitialization asserts that the context is correctly initialized for each query, including
 // setting the current time at query execution start.
 func TestQueryContextInitialization(t *testing.T) {
-	conn, cleanupFunc := initializeTestDatabaseConnection(t, false)
-	defer cleanupFunc()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Each query takes the time of the connector's clock when it starts
+	clock := &testClock{t: time.Date(2021, 6, 1, 12, 0, 0, 0, time.Local)}
+	connector, err := NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		Now:         clock.now,
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
 
 	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
 	rows, err := conn.QueryContext(ctx, "select NOW()")
 	require.NoError(t, err)
 	require.True(t, rows.Next())
-	var s1, s2 string
+	var s1, s2 time.Time
 	err = rows.Scan(&s1)
 	require.NoError(t, err)
 	require.False(t, rows.Next())
 	require.NoError(t, rows.Err())
 	require.NoError(t, rows.Close())
 
-	// Pause for 1s, then select NOW() and assert that the two times are different
-	time.Sleep(1 * time.Second)
+	// Advance the clock by 1s, then select NOW() and assert that the two times are different
+	clock.advance(time.Second)
 	rows, err = conn.QueryContext(ctx, "SELECT NOW()")
 	require.NoError(t, err)
 	require.True(t, rows.Next())
 	err = rows.Scan(&s2)
 	require.NoError(t, err)
 	assert.NotEqual(t, s1, s2)
+	require.Equal(t, "2021-06-01 12:00:00", s1.Format(time.DateTime))
+	require.Equal(t, "2021-06-01 12:00:01", s2.Format(time.DateTime))
 	require.False(t, rows.Next())
 	require.NoError(t, rows.Err())
 	require.NoError(t, rows.Close())
@@ -530,6 +554,47 @@ insert into testtable values ('b', 'a,c', '{"key": 42}', 'data', 'text', Point(5
 	require.IsType(t, time.Time{}, vals[6])
 }
 
+// TestIntegerAndBitTypes asserts that integer, YEAR and BIT columns are returned as the same Go types as the MySQL
+// driver returns them: int64 for integers, uint64 for unsigned BIGINT, and big-endian bytes for BIT.
+func TestIntegerAndBitTypes(t *testing.T) {
+	conn, cleanupFunc := initializeTestDatabaseConnection(t, false)
+	defer cleanupFunc()
+
+	tests := []struct {
+		typ   string
+		value string
+		want  any
+	}{
+		{"TINYINT", "-128", int64(-128)},
+		{"TINYINT UNSIGNED", "255", int64(255)},
+		{"SMALLINT", "-32768", int64(-32768)},
+		{"MEDIUMINT UNSIGNED", "16777215", int64(16777215)},
+		{"INT", "-2147483648", int64(-2147483648)},
+		{"INT UNSIGNED", "4294967295", int64(4294967295)},
+		{"BIGINT", "-9223372036854775808", int64(math.MinInt64)},
+		{"BIGINT UNSIGNED", "18446744073709551615", uint64(math.MaxUint64)},
+		{"YEAR", "2024", int64(2024)},
+		{"BIT(1)", "b'1'", []byte{0x01}},
+		{"BIT(12)", "b'100000000011'", []byte{0x08, 0x03}},
+		{"BIT(64)", "18446744073709551615", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+
+	ctx := context.Background()
+	for i, test := range tests {
+		t.Run(test.typ, func(t *testing.T) {
+			table := fmt.Sprintf("types%d", i)
+			_, err := conn.ExecContext(ctx, fmt.Sprintf("create table %s (col %s)", table, test.typ))
+			require.NoError(t, err)
+			_, err = conn.ExecContext(ctx, fmt.Sprintf("insert into %s values (%s)", table, test.value))
+			require.NoError(t, err)
+
+			var got any
+			require.NoError(t, conn.QueryRowContext(ctx, "select col from "+table).Scan(&got))
+			require.Equal(t, test.want, got)
+		})
+	}
+}
+
 // initializeTestDatabaseConnection create a test database called testdb and initialize a database/sql connection
 // using the Dolt driver. The connection, |conn|, is returned, and |cleanupFunc| is a function that the test function
 // should defer in order to properly dispose of test resources.
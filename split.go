@@ -0,0 +1,67 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// SplitStatement is a statement of a SQL script split by SplitStatements.
+type SplitStatement struct {
+	// Query is the SQL of the statement, without surrounding whitespace and its terminating semicolon
+	Query string
+	// Start is the byte offset of the statement in the script
+	Start int
+	// End is the byte offset just past the end of the statement's SQL in the script, before its terminating semicolon
+	End int
+}
+
+// SplitStatements splits |script| into its statements, using the engine's parser, so that semicolons in string
+// literals, identifiers, comments, conditional comments and the BEGIN ... END blocks of stored routines and triggers
+// don't end a statement. Empty statements are skipped. A statement that doesn't parse returns an error, with the offset
+// it starts at.
+func SplitStatements(script string) ([]SplitStatement, error) {
+	var stmts []SplitStatement
+	for offset := 0; offset < len(script); {
+		remainder := script[offset:]
+		n, err := parseOne(remainder)
+		if err != nil && err != sqlparser.ErrEmpty {
+			start := len(remainder) - len(strings.TrimLeftFunc(remainder, unicode.IsSpace))
+			return nil, fmt.Errorf("statement at offset %d: %w", offset+start, translateError(err))
+		}
+		if n <= 0 || n > len(remainder) {
+			n = len(remainder)
+		}
+		if err == sqlparser.ErrEmpty {
+			offset += n
+			continue
+		}
+
+		piece := remainder[:n]
+		start := len(piece) - len(strings.TrimLeftFunc(piece, unicode.IsSpace))
+		end := len(strings.TrimRightFunc(piece, func(r rune) bool {
+			return r == ';' || unicode.IsSpace(r)
+		}))
+		if start < end {
+			stmts = append(stmts, SplitStatement{
+				Query: piece[start:end],
+				Start: offset + start,
+				End:   offset + end,
+			})
+		}
+		offset += n
+	}
+
+	return stmts, nil
+}
+
+// parseOne returns the length of the first statement of |script|, like sqlparser.ParseOne. The parser panics on some
+// malformed input, such as an empty conditional comment, which is returned as a *PanicError.
+func parseOne(script string) (n int, err error) {
+	defer (*panicGuard)(nil).recover(&err)
+	_, n, err = sqlparser.ParseOne(context.Background(), script)
+	return n, err
+}
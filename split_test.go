@@ -0,0 +1,75 @@
+package embedded
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		queries []string
+	}{
+		{
+			name:    "empty",
+			script:  "  \n",
+			queries: nil,
+		},
+		{
+			name:    "single_statement",
+			script:  "SHOW TABLES",
+			queries: []string{"SHOW TABLES"},
+		},
+		{
+			name:    "quoted_semicolons",
+			script:  "INSERT INTO t VALUES ('a;b', \"c;d\");\nSELECT `e;f` FROM t;",
+			queries: []string{"INSERT INTO t VALUES ('a;b', \"c;d\")", "SELECT `e;f` FROM t"},
+		},
+		{
+			name:    "comments",
+			script:  "-- it's a comment\nSELECT 1; /* don't; split */ SELECT 2; # the end",
+			queries: []string{"-- it's a comment\nSELECT 1", "/* don't; split */ SELECT 2"},
+		},
+		{
+			name:    "conditional_comments",
+			script:  "/*!40101 SET NAMES utf8mb4 */; SELECT 1",
+			queries: []string{"/*!40101 SET NAMES utf8mb4 */", "SELECT 1"},
+		},
+		{
+			name:    "begin_end",
+			script:  "CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END; CALL p();",
+			queries: []string{"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END", "CALL p()"},
+		},
+		{
+			name:    "empty_statements",
+			script:  "SELECT 1;; ;\nSELECT 2;",
+			queries: []string{"SELECT 1", "SELECT 2"},
+		},
+		{
+			name:    "multibyte",
+			script:  "SELECT 'é;ü'; SELECT 2",
+			queries: []string{"SELECT 'é;ü'", "SELECT 2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmts, err := SplitStatements(test.script)
+			require.NoError(t, err)
+
+			var queries []string
+			for _, stmt := range stmts {
+				require.Equal(t, stmt.Query, test.script[stmt.Start:stmt.End])
+				queries = append(queries, stmt.Query)
+			}
+			require.Equal(t, test.queries, queries)
+		})
+	}
+
+	t.Run("syntax_error", func(t *testing.T) {
+		_, err := SplitStatements("SELECT 1; SELEC 2; SELECT 3")
+		require.ErrorContains(t, err, "statement at offset 10")
+	})
+}
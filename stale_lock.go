@@ -0,0 +1,224 @@
+package embedded
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/gofrs/flock"
+)
+
+const (
+	// lockFile is the file dolt locks in the directory of a database's chunk store while it can write to it
+	lockFile = "LOCK"
+	// lockOwnerFile is the file next to lockFile recording the process holding the lock, written by the connectors
+	// with Config.BreakStaleLocks
+	lockOwnerFile = "LOCK.owner"
+)
+
+// lockOwner is the contents of a lockOwnerFile.
+type lockOwner struct {
+	PID  int    `json:"pid"`
+	Host string `json:"host"`
+}
+
+// StaleLock is the lock of a database held by a process that is gone, which a connector with Config.BreakStaleLocks
+// broke when it opened, as passed to Config.OnStaleLock.
+type StaleLock struct {
+	Database string
+	// Path is the lock file
+	Path string
+	// PID and Host identify the process that took the lock
+	PID  int
+	Host string
+}
+
+// breakStaleLocks breaks the locks of the databases of |cfg| that are held on behalf of a process that is gone, so
+// that the engine can open them for writing rather than read only. A lock is only broken if the OS reports that the
+// process that took it is no longer running, and that process recorded itself as its owner.
+func breakStaleLocks(cfg Config) error {
+	if !cfg.BreakStaleLocks {
+		return nil
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range cfg.directories() {
+		dbDirs, err := databaseDirs(dir)
+		if err != nil {
+			return err
+		}
+		for _, dbDir := range dbDirs {
+			if skipped(cfg.Databases, dbDir) {
+				continue
+			}
+			path := filepath.Join(dir, dbDir, dbfactory.DoltDataDir, lockFile)
+			stale, err := staleLock(path, host)
+			if err != nil {
+				return fmt.Errorf("checking the lock of database '%s': %w", dbDir, err)
+			} else if stale == nil {
+				continue
+			}
+
+			// The lock is held on the file, so a new lock file can be locked by the engine
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("breaking the stale lock of database '%s': %w", dbDir, err)
+			}
+			os.Remove(filepath.Join(filepath.Dir(path), lockOwnerFile))
+			if cfg.OnStaleLock != nil {
+				stale.Database = dbfactory.DirToDBName(dbDir)
+				cfg.OnStaleLock(*stale)
+			}
+		}
+	}
+	return nil
+}
+
+// staleLock returns the StaleLock of the lock file |path| if it's locked by a process that is gone, or nil if it isn't
+// locked or the process holding it may still be running. The lock is held by a running process unless the OS reports
+// otherwise: the record of the lock's owner may be left over from a process that crashed before another one took the
+// lock, so it's only trusted when the process the OS reports as the lock's holder is the one it records.
+func staleLock(path, host string) (*StaleLock, error) {
+	ownerPath := filepath.Join(filepath.Dir(path), lockOwnerFile)
+	data, err := os.ReadFile(ownerPath)
+	if errors.Is(err, os.ErrNotExist) {
+		// Without a record of its owner, the lock is never broken
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var owner lockOwner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return nil, nil
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	lock := flock.New(path)
+	locked, err := lock.TryLock()
+	if err != nil {
+		return nil, err
+	} else if locked {
+		return nil, lock.Unlock()
+	}
+
+	if owner.Host != host {
+		return nil, nil
+	}
+	holder, known := lockHolder(path)
+	if !known || holder != owner.PID {
+		return nil, nil
+	}
+	if alive, known := processAlive(holder); !known || alive {
+		return nil, nil
+	}
+	return &StaleLock{Path: path, PID: owner.PID, Host: owner.Host}, nil
+}
+
+// recordLockOwners records the process as the owner of the locks of |stores| it holds, by the directory of their files.
+// Failing to record it only keeps the locks from being broken.
+func recordLockOwners(stores map[string]*doltdb.DoltDB) {
+	host, err := os.Hostname()
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(lockOwner{PID: os.Getpid(), Host: host})
+	if err != nil {
+		return
+	}
+	for path, ddb := range stores {
+		if ddb.AccessMode() == chunks.ExclusiveAccessMode_ReadOnly {
+			continue
+		}
+		os.WriteFile(filepath.Join(path, lockOwnerFile), data, 0644)
+	}
+}
+
+// removeLockOwners removes the records of the process owning the locks of |stores|, so that the locks are never
+// broken once the connector no longer renews them.
+func removeLockOwners(stores map[string]*doltdb.DoltDB) {
+	for path, ddb := range stores {
+		if ddb.AccessMode() != chunks.ExclusiveAccessMode_ReadOnly {
+			os.Remove(filepath.Join(path, lockOwnerFile))
+		}
+	}
+}
+
+// lockRenewInterval is how often a connector with Config.BreakStaleLocks records itself as the owner of the locks it
+// holds, so that the locks of the databases created since are recorded.
+const lockRenewInterval = time.Minute
+
+// lockRenewer records a connector with Config.BreakStaleLocks as the owner of the database locks it holds when it
+// opens, and every lockRenewInterval after that. It removes the records when the connector closes.
+type lockRenewer struct {
+	c    *Connector
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// newLockRenewer returns a lockRenewer for |c|, or nil if Config.BreakStaleLocks isn't set.
+func newLockRenewer(c *Connector) *lockRenewer {
+	if !c.cfg.BreakStaleLocks {
+		return nil
+	}
+	r := &lockRenewer{c: c}
+	if stores := r.stores(); stores != nil {
+		recordLockOwners(stores)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	r.stop, r.done = stop, make(chan struct{})
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(lockRenewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if stores := r.stores(); stores != nil {
+					recordLockOwners(stores)
+				}
+			}
+		}
+	}()
+	return r
+}
+
+// stores returns the chunk stores of the databases of the connector's current engine, including those created since
+// it opened, or nil if the connector proxies to another process.
+func (r *lockRenewer) stores() map[string]*doltdb.DoltDB {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	if r.c.engine == nil {
+		return nil
+	}
+	stores, err := engineStores(context.Background(), r.c.engine.se, r.c.cfg)
+	if err != nil {
+		return nil
+	}
+	return stores
+}
+
+// close stops renewing the records and removes them. A nil *lockRenewer does nothing.
+func (r *lockRenewer) close() {
+	if r == nil {
+		return
+	}
+	r.stop()
+	<-r.done
+	if stores := r.stores(); stores != nil {
+		removeLockOwners(stores)
+	}
+}
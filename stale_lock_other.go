@@ -0,0 +1,8 @@
+//go:build !unix
+
+package embedded
+
+// processAlive can't tell whether a process is running on this platform, so no lock is considered stale.
+func processAlive(pid int) (alive bool, known bool) {
+	return false, false
+}
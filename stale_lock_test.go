@@ -0,0 +1,193 @@
+package embedded
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/gofrs/flock"
+	"github.com/stretchr/testify/require"
+)
+
+// staleLockOwnerDirEnv is set to the directory the owner process of TestBreakStaleLocks opens
+const staleLockOwnerDirEnv = "DOLT_DRIVER_TEST_STALE_LOCK_OWNER_DIR"
+
+// TestBreakStaleLocksOwner is the process that creates the database of TestBreakStaleLocks and holds its lock until
+// it is killed, after starting a process that inherits the lock and outlives it, whose PID it prints.
+func TestBreakStaleLocksOwner(t *testing.T) {
+	dir := os.Getenv(staleLockOwnerDirEnv)
+	if dir == "" {
+		t.Skip("only run by TestBreakStaleLocks")
+	}
+
+	cfg := Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"}
+	setup, err := NewConnector(cfg)
+	require.NoError(t, err)
+	_, err = sql.OpenDB(setup).Exec("create database testdb")
+	require.NoError(t, err)
+	require.NoError(t, setup.Close())
+
+	// The connector records itself as the owner of the database's lock when it opens
+	cfg.BreakStaleLocks = true
+	connector, err := NewConnector(cfg)
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	path := filepath.Join(dir, "testdb", dbfactory.DoltDataDir, lockFile)
+	require.FileExists(t, filepath.Join(filepath.Dir(path), lockOwnerFile))
+
+	// The engine's descriptor of the lock file, which holds the lock, is passed to the worker
+	fds, err := os.ReadDir("/proc/self/fd")
+	require.NoError(t, err)
+	var lock *os.File
+	for _, fd := range fds {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); err == nil && target == path {
+			n, err := strconv.Atoi(fd.Name())
+			require.NoError(t, err)
+			lock = os.NewFile(uintptr(n), path)
+			break
+		}
+	}
+	require.NotNil(t, lock)
+	worker := exec.Command("sleep", "60")
+	worker.ExtraFiles = []*os.File{lock}
+	require.NoError(t, worker.Start())
+
+	fmt.Println(worker.Process.Pid)
+	io.Copy(io.Discard, os.Stdin)
+}
+
+func TestBreakStaleLocks(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the holders of locks are only known on linux")
+	}
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	// The owner of the database crashes, but a worker it started inherited the lock file, and holds the lock
+	owner := exec.Command(os.Args[0], "-test.run=^TestBreakStaleLocksOwner$")
+	owner.Env = append(os.Environ(), staleLockOwnerDirEnv+"="+dir)
+	stdin, err := owner.StdinPipe()
+	require.NoError(t, err)
+	defer stdin.Close()
+	stdout, err := owner.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, owner.Start())
+	output := bufio.NewScanner(stdout)
+	require.True(t, output.Scan(), "owner exited")
+	workerPID, err := strconv.Atoi(output.Text())
+	require.NoError(t, err)
+	worker, err := os.FindProcess(workerPID)
+	require.NoError(t, err)
+	defer worker.Kill()
+	require.NoError(t, owner.Process.Kill())
+	owner.Wait()
+
+	noms := filepath.Join(dir, "testdb", dbfactory.DoltDataDir)
+	locked, err := flock.New(filepath.Join(noms, lockFile)).TryLock()
+	require.NoError(t, err)
+	require.False(t, locked)
+	pid, known := lockHolder(filepath.Join(noms, lockFile))
+	require.True(t, known)
+	require.Equal(t, owner.Process.Pid, pid)
+
+	// The lock is broken, so the database can be written to
+	var broken []StaleLock
+	connector, err := NewConnector(Config{
+		Directory:       dir,
+		CommitName:      "Billy Batson",
+		CommitEmail:     "shazam@gmail.com",
+		BreakStaleLocks: true,
+		OnStaleLock:     func(lock StaleLock) { broken = append(broken, lock) },
+	})
+	require.NoError(t, err)
+	defer connector.Close()
+	db := sql.OpenDB(connectorOnly{connector})
+	defer db.Close()
+
+	host, err := os.Hostname()
+	require.NoError(t, err)
+	require.Len(t, broken, 1)
+	require.Equal(t, "testdb", broken[0].Database)
+	require.Equal(t, owner.Process.Pid, broken[0].PID)
+	require.Equal(t, host, broken[0].Host)
+	health := Health(ctx, db)
+	require.Len(t, health.Databases, 1)
+	require.Equal(t, LockExclusive, health.Databases[0].Lock)
+	_, err = db.ExecContext(ctx, "create table testdb.t (id int primary key)")
+	require.NoError(t, err)
+
+	// The connector records itself as the owner of the lock while it holds it
+	data, err := os.ReadFile(filepath.Join(noms, lockOwnerFile))
+	require.NoError(t, err)
+	var recorded lockOwner
+	require.NoError(t, json.Unmarshal(data, &recorded))
+	require.Equal(t, lockOwner{PID: os.Getpid(), Host: host}, recorded)
+	require.NoError(t, connector.Close())
+	require.NoFileExists(t, filepath.Join(noms, lockOwnerFile))
+}
+
+func TestStaleLock(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, lockFile)
+	lock := flock.New(path)
+	writeOwner := func(owner lockOwner) {
+		data, err := json.Marshal(owner)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, lockOwnerFile), data, 0644))
+	}
+
+	// Locks without an owner aren't broken
+	locked, err := lock.TryLock()
+	require.NoError(t, err)
+	require.True(t, locked)
+	stale, err := staleLock(path, "host")
+	require.NoError(t, err)
+	require.Nil(t, stale)
+	if runtime.GOOS == "linux" {
+		pid, known := lockHolder(path)
+		require.True(t, known)
+		require.Equal(t, os.Getpid(), pid)
+	}
+
+	// nor are the locks of running processes
+	writeOwner(lockOwner{PID: os.Getpid(), Host: "host"})
+	stale, err = staleLock(path, "host")
+	require.NoError(t, err)
+	require.Nil(t, stale)
+
+	// even if the record of their owner is left over from a process that is gone
+	writeOwner(lockOwner{PID: 1 << 30, Host: "host"})
+	stale, err = staleLock(path, "host")
+	require.NoError(t, err)
+	require.Nil(t, stale)
+
+	// The owners of other hosts can't be checked
+	writeOwner(lockOwner{PID: 1 << 30, Host: "other"})
+	stale, err = staleLock(path, "host")
+	require.NoError(t, err)
+	require.Nil(t, stale)
+
+	// and locks that aren't held aren't broken
+	require.NoError(t, lock.Unlock())
+	stale, err = staleLock(path, "other")
+	require.NoError(t, err)
+	require.Nil(t, stale)
+}
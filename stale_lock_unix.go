@@ -0,0 +1,18 @@
+//go:build unix
+
+package embedded
+
+import "golang.org/x/sys/unix"
+
+// processAlive returns whether the process |pid| of this host is running, and whether that could be determined.
+func processAlive(pid int) (alive bool, known bool) {
+	if pid <= 0 {
+		return false, false
+	}
+	err := unix.Kill(pid, 0)
+	if err == unix.ESRCH {
+		return false, true
+	}
+	// EPERM means the process exists, but belongs to another user
+	return err == nil || err == unix.EPERM, err == nil || err == unix.EPERM
+}
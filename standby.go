@@ -0,0 +1,101 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+)
+
+// StandbyError is an error pushing the commits of a database to its standby, as passed to Config.OnStandbyError.
+type StandbyError struct {
+	Database string
+	Err      error
+}
+
+func (e *StandbyError) Error() string {
+	return fmt.Sprintf("replicating database '%s' to its standby: %s", e.Database, e.Err)
+}
+
+func (e *StandbyError) Unwrap() error {
+	return e.Err
+}
+
+// standbyRemote is the name of the remote of each database's standby.
+const standbyRemote = "standby"
+
+// standbyHook is a commit hook pushing the commits of a database to its standby, which reports errors to
+// Config.OnStandbyError.
+type standbyHook struct {
+	doltdb.CommitHook
+	database string
+	onError  func(*StandbyError)
+}
+
+// HandleError implements doltdb.CommitHook.
+func (h *standbyHook) HandleError(ctx context.Context, err error) error {
+	if h.onError != nil {
+		h.onError(&StandbyError{Database: h.database, Err: err})
+	}
+	return nil
+}
+
+// Write reports the errors the engine logs when pushing asynchronously.
+func (h *standbyHook) Write(p []byte) (int, error) {
+	if h.onError != nil {
+		h.onError(&StandbyError{Database: h.database, Err: errors.New(strings.TrimSpace(string(p)))})
+	}
+	return len(p), nil
+}
+
+// addStandbyHooks makes the databases of |mrEnv| push their commits to their standby under Config.Standby, as they are
+// made. A database whose standby can't be opened isn't replicated, and the error is reported to Config.OnStandbyError,
+// so that an unavailable standby doesn't keep the application from opening its databases.
+func addStandbyHooks(ctx context.Context, se *engine.SqlEngine, mrEnv *env.MultiRepoEnv, cfg Config) error {
+	if cfg.Standby == "" {
+		return nil
+	}
+
+	onError := cfg.OnStandbyError
+	return mrEnv.Iter(func(name string, dEnv *env.DoltEnv) (bool, error) {
+		if dEnv.DoltDB == nil {
+			return false, nil
+		}
+		hook := &standbyHook{database: name, onError: onError}
+		fail := func(err error) (bool, error) {
+			hook.HandleError(ctx, err)
+			return false, nil
+		}
+
+		remote := env.NewRemote(standbyRemote, strings.TrimRight(cfg.Standby, "/")+"/"+name, nil)
+		if strings.HasPrefix(remote.Url, fileUrlPrefix) {
+			// Unlike the databases of a sql-server, file remotes have to be created before they can be pushed to
+			if err := remote.Prepare(ctx, dEnv.DoltDB.Format(), dEnv); err != nil {
+				return fail(err)
+			}
+		}
+		standby, err := remote.GetRemoteDB(ctx, dEnv.DoltDB.Format(), dEnv)
+		if err != nil {
+			return fail(err)
+		}
+		tmpDir, err := dEnv.TempTableFilesDir()
+		if err != nil {
+			return fail(err)
+		}
+
+		if cfg.StandbyAsync {
+			bThreads := se.GetUnderlyingEngine().BackgroundThreads
+			if hook.CommitHook, err = doltdb.NewAsyncPushOnWriteHook(bThreads, standby, tmpDir, hook); err != nil {
+				return fail(err)
+			}
+		} else {
+			hook.CommitHook = doltdb.NewPushOnWriteHook(standby, tmpDir)
+		}
+		dEnv.DoltDB.PrependCommitHook(ctx, hook)
+		return false, nil
+	})
+}
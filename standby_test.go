@@ -0,0 +1,104 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandby(t *testing.T) {
+	ctx := context.Background()
+	tempDir := func(t *testing.T) string {
+		dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		return dir
+	}
+	exec := func(t *testing.T, db *sql.DB, queries ...string) {
+		for _, query := range queries {
+			_, err := db.ExecContext(ctx, query)
+			require.NoError(t, err, query)
+		}
+	}
+	// standbyRows clones the standby of the database orders and returns its number of rows
+	standbyRows := func(t *testing.T, standby string) int {
+		connector, err := NewConnector(Config{Directory: tempDir(t), CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+		require.NoError(t, err)
+		defer connector.Close()
+		db := sql.OpenDB(connectorOnly{connector})
+		defer db.Close()
+		exec(t, db, "call dolt_clone('"+standby+"/orders', 'replica')")
+		var count int
+		require.NoError(t, db.QueryRowContext(ctx, "select count(*) from replica.t").Scan(&count))
+		return count
+	}
+
+	for _, async := range []bool{false, true} {
+		name := "sync"
+		if async {
+			name = "async"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := tempDir(t)
+			standby := "file://" + filepath.ToSlash(tempDir(t))
+			cfg := Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com", Database: "orders"}
+			connector, err := NewConnector(cfg)
+			require.NoError(t, err)
+			db := sql.OpenDB(connector)
+			exec(t, db, "create database orders", "use orders", "create table t (id int primary key)",
+				"call dolt_commit('-Am', 'create t')")
+			require.NoError(t, db.Close())
+
+			// Commits are pushed to the standby as they are made
+			var errs []*StandbyError
+			cfg.Standby = standby
+			cfg.StandbyAsync = async
+			cfg.OnStandbyError = func(err *StandbyError) { errs = append(errs, err) }
+			connector, err = NewConnector(cfg)
+			require.NoError(t, err)
+			db = sql.OpenDB(connector)
+			exec(t, db, "insert into t values (1), (2)", "call dolt_commit('-Am', 'two rows')")
+			if !async {
+				require.Equal(t, 2, standbyRows(t, standby))
+			}
+			exec(t, db, "insert into t values (3)", "call dolt_commit('-Am', 'three rows')")
+
+			// Asynchronous pushes are flushed when the connector is closed
+			require.NoError(t, db.Close())
+			require.Equal(t, 3, standbyRows(t, standby))
+			require.Empty(t, errs)
+		})
+	}
+
+	// A standby that can't be opened is reported, and doesn't keep the database from opening
+	var errs []*StandbyError
+	connector, err := NewConnector(Config{
+		Directory:      tempDir(t),
+		CommitName:     "Billy Batson",
+		CommitEmail:    "shazam@gmail.com",
+		Standby:        "nosuchscheme://standby",
+		OnStandbyError: func(err *StandbyError) { errs = append(errs, err) },
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	exec(t, db, "create database orders")
+	require.NoError(t, db.Close())
+
+	connector, err = NewConnector(Config{
+		Directory:      connector.cfg.Directory,
+		CommitName:     "Billy Batson",
+		CommitEmail:    "shazam@gmail.com",
+		Standby:        "nosuchscheme://standby",
+		OnStandbyError: func(err *StandbyError) { errs = append(errs, err) },
+	})
+	require.NoError(t, err)
+	require.NoError(t, connector.Close())
+	require.Len(t, errs, 1)
+	require.Equal(t, "orders", errs[0].Database)
+	require.ErrorContains(t, errs[0], "replicating database 'orders' to its standby")
+}
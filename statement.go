@@ -1,9 +1,15 @@
 package embedded
 
 import (
+	"context"
 	"database/sql/driver"
+	"errors"
+	"fmt"
 	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"io"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
 	gms "github.com/dolthub/go-mysql-server/sql"
@@ -11,13 +17,51 @@ import (
 	"github.com/dolthub/vitess/go/sqltypes"
 )
 
+// statementSnippetLength is the length the SQL of a failed statement is truncated to in a StatementError's message.
+const statementSnippetLength = 80
+
+// StatementError is returned by the Exec of a multi-statement query, or by ExecScript, when one of its statements
+// fails, to tell which one did. The statements before it were executed, and the statements after it weren't. It wraps
+// the statement's error, so errors.Is and errors.As match MySQL errors as they do for single statements.
+type StatementError struct {
+	// Index is the position of the failed statement in the query, starting at 1
+	Index int
+	// Line is the line the failed statement starts on in the script run by ExecScript, or 0 for a multi-statement query
+	Line int
+	// Query is the SQL of the failed statement
+	Query string
+	// Err is the error the statement failed with
+	Err error
+}
+
+func (e *StatementError) Error() string {
+	snippet := strings.Join(strings.Fields(e.Query), " ")
+	if len(snippet) > statementSnippetLength {
+		snippet = snippet[:statementSnippetLength] + "..."
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("statement %d (line %d): %s: %v", e.Index, e.Line, snippet, e.Err)
+	}
+	return fmt.Sprintf("statement %d: %s: %v", e.Index, snippet, e.Err)
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
 // doltMultiStmt represents a collection of statements to be executed against a
 // Dolt database.
 type doltMultiStmt struct {
+	query string
 	stmts []*doltStmt
+
+	// parallel executes independent INSERT statements concurrently, with Config.ParallelScript
+	parallel *parallelScript
 }
 
 var _ driver.Stmt = (*doltMultiStmt)(nil)
+var _ driver.StmtExecContext = (*doltMultiStmt)(nil)
+var _ driver.StmtQueryContext = (*doltMultiStmt)(nil)
 
 func (d doltMultiStmt) Close() error {
 	var retErr error
@@ -34,12 +78,35 @@ func (d doltMultiStmt) NumInput() int {
 	return -1
 }
 
-func (d doltMultiStmt) Exec(args []driver.Value) (result driver.Result, err error) {
-	for _, stmt := range d.stmts {
-		result, err = stmt.Exec(args)
+func (d doltMultiStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return d.execContext(context.Background(), args)
+}
+
+func (d doltMultiStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.execContext(ctx, values)
+}
+
+func (d doltMultiStmt) execContext(ctx context.Context, args []driver.Value) (result driver.Result, err error) {
+	end, err := d.beginQuery()
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+
+	if result, ok, err := d.parallel.exec(ctx, d, args); ok || err != nil {
+		return result, err
+	}
+
+	for i, stmt := range d.stmts {
+		result, err = stmt.execContext(ctx, args)
 		if err != nil {
 			// If any error occurs, return the error and don't execute any more statements
-			return nil, err
+			return nil, &StatementError{Index: i + 1, Query: stmt.query, Err: err}
 		}
 	}
 
@@ -48,9 +115,27 @@ func (d doltMultiStmt) Exec(args []driver.Value) (result driver.Result, err erro
 }
 
 func (d doltMultiStmt) Query(args []driver.Value) (driver.Rows, error) {
-	var multiResultSet doltMultiRows
+	return d.queryContext(context.Background(), args)
+}
+
+func (d doltMultiStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.queryContext(ctx, values)
+}
+
+func (d doltMultiStmt) queryContext(ctx context.Context, args []driver.Value) (driver.Rows, error) {
+	end, err := d.beginQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	multiResultSet := doltMultiRows{end: end}
 	for _, stmt := range d.stmts {
-		rows, err := stmt.Query(args)
+		rows, err := stmt.queryContext(ctx, args)
 		if err != nil {
 			// If an error occurs, we don't execute any more statements in the multistatement query. Instead, we
 			// capture the error in a doltRows instance, so that rows.NextResultSet() will return the error when
@@ -74,20 +159,64 @@ func (d doltMultiStmt) Query(args []driver.Value) (driver.Rows, error) {
 	// If an error occurred before any query result set, go ahead and return the error, without any result set.
 	if multiResultSet.currentRowSet < len(multiResultSet.rowSets) &&
 		multiResultSet.rowSets[multiResultSet.currentRowSet].err != nil {
+		multiResultSet.Close()
 		return nil, multiResultSet.rowSets[multiResultSet.currentRowSet].err
 	} else {
 		return &multiResultSet, nil
 	}
 }
 
+// beginQuery registers the whole multi-statement query as the session's running query in the process list, and its
+// statements run as part of it. The returned function ends the query.
+func (d doltMultiStmt) beginQuery() (func(), error) {
+	if len(d.stmts) == 0 {
+		return func() {}, nil
+	}
+
+	gmsCtx, end, err := beginQuery(d.stmts[0].gmsCtx, d.query)
+	if err != nil {
+		return nil, err
+	}
+	gmsCtx.ApplyOpts(gms.WithProcessList(multiStatementProcessList{gmsCtx.ProcessList}))
+	for _, stmt := range d.stmts {
+		stmt.multiQuery = gmsCtx
+	}
+
+	return end, nil
+}
+
 // doltStmt represents a single statement to be executed against a Dolt database.
 type doltStmt struct {
-	se     *engine.SqlEngine
-	gmsCtx *gms.Context
-	query  string
+	se             *engine.SqlEngine
+	gmsCtx         *gms.Context
+	stats          *statsRegistry
+	sessionStats   *sessionStats
+	interceptors   []Interceptor
+	slowLog        *slowQueryLog
+	denyWrites     bool
+	diskGuard      *diskGuard
+	stmtCache      *stmtCache
+	commitMessages *commitMessages
+	geometryFormat GeometryFormat
+	rowLimit       rowLimit
+	shadow         *shadowConn
+	recorder       *connRecorder
+	guard          *panicGuard
+	query          string
+
+	// engine is the connector engine |se| belongs to, and databases opens the databases the engine doesn't load
+	engine    *sharedEngine
+	databases *openDatabases
+	// clock measures the statement's latency
+	clock clock
+
+	// multiQuery is the context of the multi-statement query the statement is part of, if any
+	multiQuery *gms.Context
 }
 
 var _ driver.Stmt = (*doltStmt)(nil)
+var _ driver.StmtExecContext = (*doltStmt)(nil)
+var _ driver.StmtQueryContext = (*doltStmt)(nil)
 
 // Close closes the statement.
 func (stmt *doltStmt) Close() error {
@@ -99,6 +228,24 @@ func (stmt *doltStmt) NumInput() int {
 	return -1
 }
 
+// namedValuesToValues converts |args| to positional values. Named parameters are not supported, matching the MySQL
+// driver.
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("dolt: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+
+	return values, nil
+}
+
 func argsToBindings(args []driver.Value) (map[string]sqlparser.Expr, error) {
 	bindings := make(map[string]sqlparser.Expr)
 	for i := range args {
@@ -122,12 +269,67 @@ func argsToBindings(args []driver.Value) (map[string]sqlparser.Expr, error) {
 
 // Exec executes a query that doesn't return rows, such as an INSERT or UPDATE.
 func (stmt *doltStmt) Exec(args []driver.Value) (driver.Result, error) {
-	sch, itr, err := stmt.execWithArgs(args)
+	return stmt.execContext(context.Background(), args)
+}
+
+// ExecContext executes a query that doesn't return rows, such as an INSERT or UPDATE. The query is tagged with any
+// query tags in |ctx|.
+func (stmt *doltStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.execContext(ctx, values)
+}
+
+func (stmt *doltStmt) execContext(ctx context.Context, args []driver.Value) (_ driver.Result, err error) {
+	defer stmt.guard.recover(&err)
+
+	call, err := stmt.intercept(ctx, OperationExec, args)
+	if err != nil {
+		return nil, err
+	} else if call.Result != nil {
+		return call.Result, nil
+	}
+
+	tagComment := queryTagComment(ctx)
+	start := stmt.clock.now()
+	defer func() {
+		stmt.recordExecution(call, tagComment, stmt.clock.since(start), err)
+	}()
+
+	gmsCtx, cancel, err := stmt.statementContext(call.Query)
+	if err != nil {
+		return nil, err
+	}
+	if cancel, err = useContextRevision(ctx, gmsCtx, cancel); err != nil {
+		return nil, err
+	}
+	defer cancel()
+	if err = checkHead(gmsCtx, contextHead(ctx)); err != nil {
+		return nil, err
+	}
+	if err = stmt.commitMessages.apply(ctx, gmsCtx, call.Query); err != nil {
+		return nil, err
+	}
+	clearWarnings(gmsCtx, call.Query)
+
+	lastInsertID := gmsCtx.Session.GetLastQueryInfoInt(gms.LastInsertId)
+	sch, itr, err := stmt.execute(gmsCtx, tagQuery(call.Query, tagComment), call.Args)
 	if err != nil {
-		return nil, translateError(err)
+		err = interruptError(gmsCtx, err)
+		stmt.shadow.mirror(ctx, call.Query, call.Args, 0, err)
+		stmt.recorder.recordExec(call.Query, call.Args, 0, err)
+		return nil, stmt.openMissingDatabase(ctx, gmsCtx, err)
 	}
+	stmt.databases.used(gmsCtx.GetCurrentDatabase())
 
-	res := newResult(stmt.gmsCtx, sch, itr)
+	res := newResult(gmsCtx, sch, itr, lastInsertID)
+	res.err = interruptError(gmsCtx, res.err)
+	stmt.sessionStats.recordRowsWritten(res.affected)
+	stmt.shadow.mirror(ctx, call.Query, call.Args, res.affected, res.err)
+	stmt.recorder.recordExec(call.Query, call.Args, res.affected, res.err)
 	if res.err != nil {
 		return nil, res.err
 	}
@@ -135,13 +337,55 @@ func (stmt *doltStmt) Exec(args []driver.Value) (driver.Result, error) {
 	return res, nil
 }
 
-func (stmt *doltStmt) execWithArgs(args []driver.Value) (gms.Schema, gms.RowIter, error) {
-	bindings, err := argsToBindings(args)
-	if err != nil {
-		return nil, nil, err
+// intercept runs the statement's interceptors for |op| with |args|, and returns the call they produced. The call holds
+// the query and arguments to execute, or the result to return in their place. Queries that the connection isn't
+// allowed to execute are rejected here, after interceptors have had a chance to rewrite them.
+func (stmt *doltStmt) intercept(ctx context.Context, op Operation, args []driver.Value) (*InterceptedCall, error) {
+	call := &InterceptedCall{Operation: op, Query: stmt.query, Args: args}
+	if err := intercept(ctx, stmt.interceptors, call); err != nil {
+		return nil, err
+	}
+
+	if stmt.denyWrites && !call.shortCircuited() {
+		if err := checkWritesDenied(ctx, call.Query, gms.LoadSqlMode(stmt.gmsCtx).ParserOptions()); err != nil {
+			return nil, err
+		}
+	}
+	if !call.shortCircuited() {
+		if err := stmt.diskGuard.check(call.Query); err != nil {
+			return nil, err
+		}
+	}
+
+	return call, nil
+}
+
+// recordExecution records an execution of |call| that took |latency| and returned |err| in the statement's statistics,
+// and reports it if it was slow.
+func (stmt *doltStmt) recordExecution(call *InterceptedCall, tagComment string, latency time.Duration, err error) {
+	stmt.stats.record(call.Query, tagComment, stmt.gmsCtx.GetCurrentDatabase(), latency, err)
+	stmt.sessionStats.recordStatement(err)
+	stmt.slowLog.observe(stmt.gmsCtx, call.Query, tagComment, call.Args, latency, err)
+}
+
+// execute runs |query| with |args| bound to its placeholders in |gmsCtx|. Both Exec and Query go through here.
+// Statements without arguments skip building bind variables entirely, since that is the common case for simple
+// queries, and the ones counting the rows of a table are answered from its row count directly.
+func (stmt *doltStmt) execute(gmsCtx *gms.Context, query string, args []driver.Value) (gms.Schema, gms.RowIter, error) {
+	if sch, itr, ok := stmt.countStar(gmsCtx, query, args); ok {
+		return sch, itr, nil
+	}
+	var bindings map[string]sqlparser.Expr
+	if len(args) != 0 {
+		var err error
+		bindings, err = argsToBindings(args)
+		if err != nil {
+			return nil, nil, err
+		}
+		stmt.stmtCache.use(query)
 	}
 
-	sch, itr, _, err := stmt.se.GetUnderlyingEngine().QueryWithBindings(stmt.gmsCtx, stmt.query, nil, bindings, nil)
+	sch, itr, _, err := stmt.se.GetUnderlyingEngine().QueryWithBindings(gmsCtx, query, nil, bindings, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -150,18 +394,65 @@ func (stmt *doltStmt) execWithArgs(args []driver.Value) (gms.Schema, gms.RowIter
 
 // Query executes a query that may return rows, such as a SELECT
 func (stmt *doltStmt) Query(args []driver.Value) (driver.Rows, error) {
-	var sch gms.Schema
-	var rowIter gms.RowIter
-	var err error
+	return stmt.queryContext(context.Background(), args)
+}
 
-	if len(args) != 0 {
-		sch, rowIter, err = stmt.execWithArgs(args)
-	} else {
-		sch, rowIter, _, err = stmt.se.Query(stmt.gmsCtx, stmt.query)
+// QueryContext executes a query that may return rows, such as a SELECT. The query is tagged with any query tags in
+// |ctx|.
+func (stmt *doltStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.queryContext(ctx, values)
+}
+
+func (stmt *doltStmt) queryContext(ctx context.Context, args []driver.Value) (_ driver.Rows, err error) {
+	defer stmt.guard.recover(&err)
+
+	call, err := stmt.intercept(ctx, OperationQuery, args)
+	if err != nil {
+		return nil, err
+	} else if call.Rows != nil {
+		return &doltRows{intercepted: call.Rows, isQueryResultSet: true}, nil
+	}
+
+	tagComment := queryTagComment(ctx)
+	start := stmt.clock.now()
+	defer func() {
+		stmt.recordExecution(call, tagComment, stmt.clock.since(start), err)
+	}()
+
+	recording := stmt.recorder.newQueryRecording(call.Query, call.Args)
+	gmsCtx, cancel, err := stmt.statementContext(call.Query)
+	if err == nil {
+		cancel, err = useContextRevision(ctx, gmsCtx, cancel)
+	}
+	if err == nil {
+		if err = checkHead(gmsCtx, contextHead(ctx)); err != nil {
+			cancel()
+		}
+	}
+	if err == nil {
+		if err = stmt.commitMessages.apply(ctx, gmsCtx, call.Query); err != nil {
+			cancel()
+		}
+	}
+	if err != nil {
+		recording.finish(err)
+		return nil, err
 	}
+	clearWarnings(gmsCtx, call.Query)
+	sch, rowIter, err := stmt.execute(gmsCtx, tagQuery(call.Query, tagComment), call.Args)
 	if err != nil {
-		return nil, translateError(err)
+		err = interruptError(gmsCtx, err)
+		cancel()
+		stmt.shadow.mirror(ctx, call.Query, call.Args, 0, err)
+		recording.finish(err)
+		return nil, stmt.openMissingDatabase(ctx, gmsCtx, err)
 	}
+	stmt.databases.used(gmsCtx.GetCurrentDatabase())
 
 	// Wrap the result iterator in a peekableRowIter and call Peek() to read the first row from the result iterator.
 	// This is necessary for insert operations, since the insert happens inside the result iterator logic. Without
@@ -169,13 +460,32 @@ func (stmt *doltStmt) Query(args []driver.Value) (driver.Rows, error) {
 	// and future statements in a multi-statement query that depend on those results would fail.
 	// If an error does occur, we want that error to be returned in the Next() codepath, not here.
 	peekIter := peekableRowIter{iter: rowIter}
-	row, _ := peekIter.Peek(stmt.gmsCtx)
+	row, peekErr := peekIter.Peek(gmsCtx)
+
+	isQuery := isQueryResultSet(row)
+	var affected int64
+	if !isQuery && len(row) == 1 {
+		affected = int64(row[0].(types.OkResult).RowsAffected)
+		stmt.sessionStats.recordRowsWritten(affected)
+	}
+	if peekErr == io.EOF {
+		peekErr = nil
+	}
+	if !isQuery || peekErr != nil {
+		stmt.shadow.mirror(ctx, call.Query, call.Args, affected, peekErr)
+	}
 
 	return &doltRows{
 		sch:              sch,
 		rowIter:          &peekIter,
-		gmsCtx:           stmt.gmsCtx,
-		isQueryResultSet: isQueryResultSet(row),
+		gmsCtx:           gmsCtx,
+		cancel:           cancel,
+		sessionStats:     stmt.sessionStats,
+		geometryFormat:   stmt.geometryFormat,
+		rowLimit:         stmt.rowLimit,
+		recording:        recording,
+		guard:            stmt.guard,
+		isQueryResultSet: isQuery,
 	}, nil
 }
 
@@ -0,0 +1,188 @@
+package embedded
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	querypb "github.com/dolthub/vitess/go/vt/proto/query"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// StatementStats summarizes every execution of the statements sharing a normalized digest and query tags, similar to MySQL's
+// performance_schema.events_statements_summary_by_digest table.
+type StatementStats struct {
+	// Digest is the normalized text of the statement, with literals replaced by bind variables
+	Digest string
+	// Tag holds the query tags the statement was executed with, see WithQueryTag
+	Tag string
+	// Database is the current database of the connection that last executed the statement
+	Database string
+	// Count is the number of times the statement was executed
+	Count int64
+	// Errors is the number of executions that returned an error
+	Errors int64
+	// TotalLatency is the sum of the latencies of all executions. The latency of a query covers executing it and
+	// reading its first row, but not the time the caller spends iterating over the rest of its rows.
+	TotalLatency time.Duration
+	// MaxLatency is the latency of the slowest execution
+	MaxLatency time.Duration
+}
+
+// MeanLatency returns the average latency of the statement's executions.
+func (s StatementStats) MeanLatency() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Count)
+}
+
+// statsRegistry accumulates StatementStats by digest and tag. It is shared by all the connections of a Connector, so it is
+// safe for concurrent use. Recording an execution doesn't take a lock, so that connections executing statements
+// concurrently don't wait on each other. A nil *statsRegistry discards everything recorded in it.
+type statsRegistry struct {
+	// byDigest holds the *statementCounters of the statements by statsKey. It is replaced by reset.
+	byDigest atomic.Pointer[sync.Map]
+	// digests caches the digests of the queries recorded, since computing one parses the query
+	digests digestCache
+}
+
+type statsKey struct {
+	digest string
+	tag    string
+}
+
+// statementCounters accumulates the StatementStats of a statsKey.
+type statementCounters struct {
+	count        atomic.Int64
+	errors       atomic.Int64
+	totalLatency atomic.Int64
+	maxLatency   atomic.Int64
+	database     atomic.Pointer[string]
+}
+
+func newStatsRegistry() *statsRegistry {
+	r := &statsRegistry{}
+	r.byDigest.Store(&sync.Map{})
+	return r
+}
+
+// record adds an execution of |query| tagged with |tag| in |database| that took |latency| and returned |err| to the
+// registry.
+func (r *statsRegistry) record(query, tag, database string, latency time.Duration, err error) {
+	if r == nil {
+		return
+	}
+
+	key := statsKey{digest: r.digests.digest(query), tag: tag}
+	byDigest := r.byDigest.Load()
+	value, ok := byDigest.Load(key)
+	if !ok {
+		value, _ = byDigest.LoadOrStore(key, &statementCounters{})
+	}
+	counters := value.(*statementCounters)
+
+	counters.count.Add(1)
+	counters.totalLatency.Add(int64(latency))
+	for max := counters.maxLatency.Load(); int64(latency) > max; max = counters.maxLatency.Load() {
+		if counters.maxLatency.CompareAndSwap(max, int64(latency)) {
+			break
+		}
+	}
+	if err != nil {
+		counters.errors.Add(1)
+	}
+	if last := counters.database.Load(); last == nil || *last != database {
+		counters.database.Store(&database)
+	}
+}
+
+// snapshot returns a copy of the registry's stats, ordered by total latency, highest first. Executions recorded while
+// the snapshot is taken may be partially included.
+func (r *statsRegistry) snapshot() []StatementStats {
+	if r == nil {
+		return nil
+	}
+
+	stats := []StatementStats{}
+	r.byDigest.Load().Range(func(k, v any) bool {
+		key, counters := k.(statsKey), v.(*statementCounters)
+		var database string
+		if last := counters.database.Load(); last != nil {
+			database = *last
+		}
+		stats = append(stats, StatementStats{
+			Digest:       key.digest,
+			Tag:          key.tag,
+			Database:     database,
+			Count:        counters.count.Load(),
+			Errors:       counters.errors.Load(),
+			TotalLatency: time.Duration(counters.totalLatency.Load()),
+			MaxLatency:   time.Duration(counters.maxLatency.Load()),
+		})
+		return true
+	})
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalLatency != stats[j].TotalLatency {
+			return stats[i].TotalLatency > stats[j].TotalLatency
+		}
+		if stats[i].Digest != stats[j].Digest {
+			return stats[i].Digest < stats[j].Digest
+		}
+		return stats[i].Tag < stats[j].Tag
+	})
+
+	return stats
+}
+
+// reset discards everything recorded in the registry.
+func (r *statsRegistry) reset() {
+	if r == nil {
+		return
+	}
+
+	r.byDigest.Store(&sync.Map{})
+}
+
+// maxCachedDigests bounds the number of queries whose digest is cached, so that applications building queries with
+// literals don't grow the cache without limit. The digests of other queries are computed every time.
+const maxCachedDigests = 4096
+
+// digestCache caches the digests of queries by query text. It is safe for concurrent use without locking.
+type digestCache struct {
+	digests sync.Map
+	size    atomic.Int64
+}
+
+// digest returns the digest of |query|, as computed by normalizeQuery.
+func (c *digestCache) digest(query string) string {
+	if digest, ok := c.digests.Load(query); ok {
+		return digest.(string)
+	}
+
+	digest := normalizeQuery(query)
+	if c.size.Load() < maxCachedDigests {
+		if _, loaded := c.digests.LoadOrStore(query, digest); !loaded {
+			c.size.Add(1)
+		}
+	}
+	return digest
+}
+
+// normalizeQuery returns the digest of |query|: its canonical formatting with comments removed and literals replaced
+// by bind variables. Queries that can't be parsed are only trimmed of surrounding whitespace and comments.
+func normalizeQuery(query string) string {
+	stripped, _ := sqlparser.SplitMarginComments(query)
+	stripped = strings.TrimSuffix(strings.TrimSpace(stripped), ";")
+
+	stmt, err := sqlparser.Parse(stripped)
+	if err != nil {
+		return stripped
+	}
+
+	sqlparser.Normalize(stmt, map[string]*querypb.BindVariable{}, "v")
+	return sqlparser.String(stmt)
+}
@@ -0,0 +1,85 @@
+package embedded
+
+import (
+	"strings"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/information_schema"
+	"github.com/dolthub/go-mysql-server/sql/types"
+)
+
+// StatementsTableName is the table of information_schema holding the connector's statement statistics, one row per
+// StatementStats, like Connector.Stats returns them. Its columns are named like those of MySQL's
+// performance_schema.events_statements_summary_by_digest table, with latencies in picoseconds.
+const StatementsTableName = "dolt_driver_statements"
+
+// statementsSchema is the schema of the StatementsTableName table.
+var statementsSchema = gms.Schema{
+	{Name: "DIGEST_TEXT", Type: types.LongText, Source: StatementsTableName},
+	{Name: "QUERY_TAG", Type: types.LongText, Source: StatementsTableName},
+	{Name: "SCHEMA_NAME", Type: types.LongText, Source: StatementsTableName},
+	{Name: "COUNT_STAR", Type: types.Uint64, Source: StatementsTableName},
+	{Name: "SUM_ERRORS", Type: types.Uint64, Source: StatementsTableName},
+	{Name: "SUM_TIMER_WAIT", Type: types.Uint64, Source: StatementsTableName},
+	{Name: "AVG_TIMER_WAIT", Type: types.Uint64, Source: StatementsTableName},
+	{Name: "MAX_TIMER_WAIT", Type: types.Uint64, Source: StatementsTableName},
+}
+
+// addStatementsTable adds the StatementsTableName table reading |stats| to the information_schema database of |se|.
+// A nil *statsRegistry adds nothing.
+func addStatementsTable(se *engine.SqlEngine, stats *statsRegistry) {
+	if stats == nil {
+		return
+	}
+	catalog := se.GetUnderlyingEngine().Analyzer.Catalog
+	table := &information_schema.InformationSchemaTable{
+		TableName:   StatementsTableName,
+		TableSchema: statementsSchema,
+		Reader: func(*gms.Context, gms.Catalog) (gms.RowIter, error) {
+			var rows []gms.Row
+			for _, s := range stats.snapshot() {
+				rows = append(rows, gms.NewRow(s.Digest, s.Tag, s.Database, uint64(s.Count), uint64(s.Errors),
+					picoseconds(s.TotalLatency.Nanoseconds()), picoseconds(s.MeanLatency().Nanoseconds()),
+					picoseconds(s.MaxLatency.Nanoseconds())))
+			}
+			return gms.RowsToRowIter(rows...), nil
+		},
+	}
+	catalog.InfoSchema = infoSchema{
+		Database: catalog.InfoSchema,
+		tables:   map[string]gms.Table{StatementsTableName: table.AssignCatalog(catalog)},
+	}
+}
+
+// picoseconds returns the picoseconds of |ns| nanoseconds, the unit of the latencies of performance_schema.
+func picoseconds(ns int64) uint64 {
+	return uint64(ns) * 1000
+}
+
+// infoSchema is the information_schema database of an engine, with the driver's own tables added to it.
+type infoSchema struct {
+	gms.Database
+	// tables holds the driver's tables, by lower case name
+	tables map[string]gms.Table
+}
+
+// GetTableInsensitive returns the driver's table |name| if there is one, or the engine's.
+func (db infoSchema) GetTableInsensitive(ctx *gms.Context, name string) (gms.Table, bool, error) {
+	if table, ok := db.tables[strings.ToLower(name)]; ok {
+		return table, true, nil
+	}
+	return db.Database.GetTableInsensitive(ctx, name)
+}
+
+// GetTableNames returns the names of the engine's tables and the driver's.
+func (db infoSchema) GetTableNames(ctx *gms.Context) ([]string, error) {
+	names, err := db.Database.GetTableNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for name := range db.tables {
+		names = append(names, name)
+	}
+	return names, nil
+}
@@ -0,0 +1,113 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+)
+
+// Status is the state of the working set of a database, like dolt status reports it.
+type Status struct {
+	Database string
+	// Branch is the branch whose working set is described
+	Branch string
+	// Staged are the changes staged to be committed
+	Staged []TableStatus
+	// Unstaged are the changes of the working set that aren't staged
+	Unstaged []TableStatus
+	// Conflicts are the tables with merge conflicts
+	Conflicts []TableConflicts
+	// Merging is true while a merge is in progress, until it is committed or aborted
+	Merging bool
+	// MergeSource is the branch or commit being merged while Merging is true
+	MergeSource string
+}
+
+// TableStatus is a change to a table, as listed by dolt status.
+type TableStatus struct {
+	Table string
+	// Status is the kind of change, such as "new table", "modified", "deleted", "renamed", "conflict" or
+	// "constraint violation"
+	Status string
+}
+
+// TableConflicts is a table with merge conflicts.
+type TableConflicts struct {
+	Table string
+	// Conflicts is the number of conflicting rows
+	Conflicts uint64
+}
+
+// Clean returns whether the working set has no staged or unstaged changes, and no merge in progress.
+func (s Status) Clean() bool {
+	return len(s.Staged) == 0 && len(s.Unstaged) == 0 && !s.Merging
+}
+
+// Status returns the status of the working set of |database| on the branch new connections use, from the dolt_status,
+// dolt_conflicts and dolt_merge_status system tables, so that applications can show uncommitted changes without
+// querying them.
+func (c *Connector) Status(ctx context.Context, database string) (Status, error) {
+	if err := ctx.Err(); err != nil {
+		return Status{}, err
+	}
+
+	eng, gmsCtx, err := c.newDatabaseSession(ctx, database)
+	if err != nil {
+		return Status{}, err
+	}
+	defer eng.release()
+	gmsCtx.SetCurrentDatabase(database)
+
+	status := Status{Database: database}
+	if status.Branch, err = queryString(gmsCtx, eng.se, "SELECT active_branch()", nil); err != nil {
+		return Status{}, translateError(err)
+	}
+
+	rows, err := queryRows(gmsCtx, eng.se, "SELECT table_name, staged, status FROM dolt_status", nil)
+	if err != nil {
+		return Status{}, translateError(err)
+	}
+	for _, row := range rows {
+		table := TableStatus{Table: fmt.Sprint(row[0]), Status: fmt.Sprint(row[2])}
+		if isTrue(row[1]) {
+			status.Staged = append(status.Staged, table)
+		} else {
+			status.Unstaged = append(status.Unstaged, table)
+		}
+	}
+
+	rows, err = queryRows(gmsCtx, eng.se, "SELECT `table`, num_conflicts FROM dolt_conflicts", nil)
+	if err != nil {
+		return Status{}, translateError(err)
+	}
+	for _, row := range rows {
+		conflicts := TableConflicts{Table: fmt.Sprint(row[0])}
+		if _, err := fmt.Sscan(fmt.Sprint(row[1]), &conflicts.Conflicts); err != nil {
+			return Status{}, err
+		}
+		status.Conflicts = append(status.Conflicts, conflicts)
+	}
+
+	rows, err = queryRows(gmsCtx, eng.se, "SELECT is_merging, source FROM dolt_merge_status", nil)
+	if err != nil {
+		return Status{}, translateError(err)
+	}
+	if len(rows) > 0 && isTrue(rows[0][0]) {
+		status.Merging = true
+		status.MergeSource = fmt.Sprint(rows[0][1])
+	}
+
+	return status, nil
+}
+
+// isTrue returns whether |v|, a boolean column value, is true. The engine returns booleans as integers or bools
+// depending on the table.
+func isTrue(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case nil:
+		return false
+	default:
+		return fmt.Sprint(v) != "0"
+	}
+}
@@ -0,0 +1,82 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com"})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	exec := func(queries ...string) {
+		for _, query := range queries {
+			_, err := conn.ExecContext(ctx, query)
+			require.NoError(t, err, query)
+		}
+	}
+	exec(
+		"create database test",
+		"use test",
+		"create table t (id int primary key, v int)",
+		"create table u (id int primary key)",
+		"insert into t values (1, 1)",
+		"call dolt_commit('-Am', 'create tables')",
+	)
+
+	status, err := connector.Status(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, Status{Database: "test", Branch: "main"}, status)
+	require.True(t, status.Clean())
+
+	exec(
+		"insert into t values (2, 2)",
+		"create table w (id int primary key)",
+		"call dolt_add('w')",
+		"drop table u",
+	)
+	status, err = connector.Status(ctx, "test")
+	require.NoError(t, err)
+	require.False(t, status.Clean())
+	require.Equal(t, []TableStatus{{Table: "w", Status: "new table"}}, status.Staged)
+	require.ElementsMatch(t, []TableStatus{{Table: "t", Status: "modified"}, {Table: "u", Status: "deleted"}}, status.Unstaged)
+	require.Empty(t, status.Conflicts)
+
+	// A merge with conflicts
+	exec(
+		"call dolt_commit('-Am', 'changes')",
+		"call dolt_checkout('-b', 'other')",
+		"update t set v = 10 where id = 1",
+		"call dolt_commit('-am', 'other')",
+		"call dolt_checkout('main')",
+		"update t set v = 20 where id = 1",
+		"call dolt_commit('-am', 'main')",
+		"set @@dolt_allow_commit_conflicts = 1",
+	)
+	_, err = conn.ExecContext(ctx, "call dolt_merge('other')")
+	require.NoError(t, err)
+
+	status, err = connector.Status(ctx, "test")
+	require.NoError(t, err)
+	require.True(t, status.Merging)
+	require.Equal(t, "other", status.MergeSource)
+	require.Equal(t, []TableConflicts{{Table: "t", Conflicts: 1}}, status.Conflicts)
+	require.Contains(t, status.Unstaged, TableStatus{Table: "t", Status: "conflict"})
+
+	_, err = connector.Status(ctx, "missing")
+	require.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package embedded
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	sqle "github.com/dolthub/go-mysql-server"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// defaultStatementCacheSize is the number of statements each connection caches when Config.StatementCacheSize isn't set.
+const defaultStatementCacheSize = 256
+
+// StatementCacheStats are the counters of the statement caches of a connector's connections, as returned by
+// Connector.StatementCacheStats.
+type StatementCacheStats struct {
+	// Hits is the number of executions of statements with arguments whose parsed statement was cached
+	Hits uint64
+	// Misses is the number of executions of statements with arguments that had to be parsed
+	Misses uint64
+	// Evictions is the number of statements removed from the caches to stay under Config.StatementCacheSize
+	Evictions uint64
+	// Statements is the number of statements cached by the open connections
+	Statements int64
+}
+
+// stmtCacheCounters are the counters of the statement caches of a connector's connections.
+type stmtCacheCounters struct {
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+	evictions  atomic.Uint64
+	statements atomic.Int64
+}
+
+// stmtCache bounds the engine's cache of the parsed statements of a connection's session. The engine parses each
+// statement executed with arguments once and caches it for the session by query text, without ever evicting it, so
+// applications building queries with literals would grow it without limit. stmtCache tracks the statements the session
+// cached, and removes the least recently used one from the engine's cache once there are more than its size.
+type stmtCache struct {
+	cache    *sqle.PreparedDataCache
+	session  uint32
+	size     int
+	counters *stmtCacheCounters
+
+	mu sync.Mutex
+	// lru holds the cached queries, most recently used first
+	lru     *list.List
+	queries map[string]*list.Element
+}
+
+// newStmtCache returns a stmtCache for the session |session| of |se|, holding up to Config.StatementCacheSize
+// statements.
+func newStmtCache(se *engine.SqlEngine, session uint32, cfg Config, counters *stmtCacheCounters) *stmtCache {
+	size := int(cfg.StatementCacheSize)
+	if size <= 0 {
+		size = defaultStatementCacheSize
+	}
+	return &stmtCache{
+		cache:    se.GetUnderlyingEngine().PreparedDataCache,
+		session:  session,
+		size:     size,
+		counters: counters,
+		lru:      list.New(),
+		queries:  make(map[string]*list.Element),
+	}
+}
+
+// use records that |query| is about to be executed with arguments, which makes the engine cache it. A nil *stmtCache
+// does nothing.
+func (c *stmtCache) use(query string) {
+	if c == nil {
+		return
+	}
+	// The engine caches statements by the query without its trailing delimiter
+	query = gms.RemoveSpaceAndDelimiter(query, ';')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.cache.GetCachedStmt(c.session, query); ok {
+		c.counters.hits.Add(1)
+	} else {
+		c.counters.misses.Add(1)
+	}
+
+	if elem, ok := c.queries[query]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.queries[query] = c.lru.PushFront(query)
+	c.counters.statements.Add(1)
+
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Remove(c.lru.Back()).(string)
+		delete(c.queries, oldest)
+		c.cache.UncacheStmt(c.session, oldest)
+		c.counters.statements.Add(-1)
+		c.counters.evictions.Add(1)
+	}
+}
+
+// close removes the session's statements from the engine's cache, once the connection is closed. A nil *stmtCache
+// does nothing.
+func (c *stmtCache) close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache.DeleteSessionData(c.session)
+	c.counters.statements.Add(-int64(c.lru.Len()))
+	c.lru.Init()
+	clear(c.queries)
+}
+
+// StatementCacheStats returns the counters of the statement caches of the connector's connections, which hold the
+// parsed statements of the queries executed with arguments, up to Config.StatementCacheSize per connection.
+func (c *Connector) StatementCacheStats() StatementCacheStats {
+	return StatementCacheStats{
+		Hits:       c.stmtCacheCounters.hits.Load(),
+		Misses:     c.stmtCacheCounters.misses.Load(),
+		Evictions:  c.stmtCacheCounters.evictions.Load(),
+		Statements: c.stmtCacheCounters.statements.Load(),
+	}
+}
@@ -0,0 +1,58 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementCache(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{
+		Directory:          dir,
+		CommitName:         "Billy Batson",
+		CommitEmail:        "shazam@gmail.com",
+		StatementCacheSize: 2,
+	})
+	require.NoError(t, err)
+	defer connector.Close()
+	db := sql.OpenDB(connectorOnly{connector})
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	query := func(query string, arg int) {
+		var n int
+		require.NoError(t, db.QueryRowContext(ctx, query, arg).Scan(&n))
+		require.Equal(t, arg, n)
+	}
+
+	query("select ?", 1)
+	query("select ?", 2)
+	require.Equal(t, StatementCacheStats{Hits: 1, Misses: 1, Statements: 1}, connector.StatementCacheStats())
+
+	// Statements without arguments aren't cached
+	_, err = db.ExecContext(ctx, "select 1")
+	require.NoError(t, err)
+	require.Equal(t, StatementCacheStats{Hits: 1, Misses: 1, Statements: 1}, connector.StatementCacheStats())
+
+	// The least recently used statement is evicted
+	query("select ? + 0", 3)
+	query("select ?", 4)
+	query("select ? + 1 - 1", 5)
+	require.Equal(t, StatementCacheStats{Hits: 2, Misses: 3, Evictions: 1, Statements: 2}, connector.StatementCacheStats())
+	query("select ? + 0", 6)
+	require.Equal(t, StatementCacheStats{Hits: 2, Misses: 4, Evictions: 2, Statements: 2}, connector.StatementCacheStats())
+	query("select ? + 1 - 1", 7)
+	require.Equal(t, StatementCacheStats{Hits: 3, Misses: 4, Evictions: 2, Statements: 2}, connector.StatementCacheStats())
+
+	// Closing the connection empties its cache
+	require.NoError(t, db.Close())
+	require.Zero(t, connector.StatementCacheStats().Statements)
+}
@@ -0,0 +1,450 @@
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// SyncMode is the direction in which the connector syncs its databases with their remote.
+type SyncMode string
+
+const (
+	// SyncPull merges the commits of the remote's branch into the local branch. It is the default.
+	SyncPull SyncMode = "pull"
+	// SyncPush pushes the local branch to the remote's branch
+	SyncPush SyncMode = "push"
+	// SyncBoth pulls, then pushes the result, so that the local and remote branches converge
+	SyncBoth SyncMode = "both"
+)
+
+// validate returns an error if |m| isn't one of the sync modes. The empty mode is SyncPull.
+func (m SyncMode) validate() error {
+	switch SyncMode(strings.ToLower(string(m))) {
+	case "", SyncPull, SyncPush, SyncBoth:
+		return nil
+	default:
+		return fmt.Errorf("unknown sync mode '%s'", m)
+	}
+}
+
+// pulls returns whether |m| pulls from the remote.
+func (m SyncMode) pulls() bool {
+	mode := SyncMode(strings.ToLower(string(m)))
+	return mode != SyncPush
+}
+
+// pushes returns whether |m| pushes to the remote.
+func (m SyncMode) pushes() bool {
+	mode := SyncMode(strings.ToLower(string(m)))
+	return mode == SyncPush || mode == SyncBoth
+}
+
+// SyncConflicts is the policy for the conflicts a pull runs into, when the local and remote branches changed the same
+// rows.
+type SyncConflicts string
+
+const (
+	// SyncConflictsFail rolls the merge back and reports ErrSyncConflicts, so the conflicts can be resolved by hand. It
+	// is the default.
+	SyncConflictsFail SyncConflicts = "fail"
+	// SyncConflictsOurs resolves conflicts by keeping the local rows
+	SyncConflictsOurs SyncConflicts = "ours"
+	// SyncConflictsTheirs resolves conflicts by taking the remote's rows
+	SyncConflictsTheirs SyncConflicts = "theirs"
+)
+
+// validate returns an error if |p| isn't one of the conflict policies. The empty policy is SyncConflictsFail.
+func (p SyncConflicts) validate() error {
+	switch SyncConflicts(strings.ToLower(string(p))) {
+	case "", SyncConflictsFail, SyncConflictsOurs, SyncConflictsTheirs:
+		return nil
+	default:
+		return fmt.Errorf("unknown sync conflict policy '%s'", p)
+	}
+}
+
+// SyncConflict describes the conflicts in a table that a pull ran into, as passed to Config.ResolveSyncConflict.
+type SyncConflict struct {
+	Database string
+	// Branch is the local branch, and Upstream the branch of the remote merged into it
+	Branch   string
+	Remote   string
+	Upstream string
+	Table    string
+	// Rows is the number of rows with conflicts
+	Rows int64
+}
+
+// ErrSyncConflicts is reported in the SyncStatus of a database whose pull ran into conflicts with the
+// SyncConflictsFail policy. The merge is rolled back, so the database is left as it was before the sync.
+var ErrSyncConflicts = errors.New("syncing ran into conflicts")
+
+// defaultSyncRemote is the remote databases are synced with when Config.SyncRemote isn't set.
+const defaultSyncRemote = "origin"
+
+// SyncStatus describes the last sync of a database with its remote, as returned by Connector.SyncStatus.
+type SyncStatus struct {
+	Database string
+	// Branch is the local branch, and Upstream the branch of the remote it is synced with
+	Branch   string
+	Remote   string
+	Upstream string
+	// Time is when the last sync finished, and LastSuccess when the last sync without error finished
+	Time        time.Time
+	LastSuccess time.Time
+	// Pulled reports whether the last sync merged new commits of the remote
+	Pulled bool
+	// Pending is the number of commits of the local branch that the remote doesn't have yet, as of the last sync: the
+	// depth of the queue of commits made while the remote couldn't be reached
+	Pending int64
+	// Conflicts are the tables with conflicts the last pull ran into, which were resolved by Config.SyncConflicts or
+	// Config.ResolveSyncConflict, or rolled back with ErrSyncConflicts
+	Conflicts []string
+	// Err is the error of the last sync, or nil if it succeeded
+	Err error
+}
+
+// syncer syncs the databases of a connector with their remote in the background, every Config.SyncInterval.
+type syncer struct {
+	c    *Connector
+	stop context.CancelFunc
+	done chan struct{}
+
+	// running serializes syncs
+	running sync.Mutex
+	// mu guards statuses
+	mu       sync.Mutex
+	statuses map[string]SyncStatus
+}
+
+// newSyncer returns a syncer for |c|. If Config.SyncInterval is set, it starts syncing in the background after the
+// first interval.
+func newSyncer(c *Connector) *syncer {
+	s := &syncer{c: c, statuses: make(map[string]SyncStatus)}
+	if c.cfg.SyncInterval <= 0 {
+		return s
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	s.stop, s.done = stop, make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(c.cfg.SyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sync(ctx)
+			}
+		}
+	}()
+	return s
+}
+
+// close stops syncing in the background, waiting for a sync in progress to finish. A nil *syncer does nothing.
+func (s *syncer) close() {
+	if s == nil || s.stop == nil {
+		return
+	}
+	s.stop()
+	<-s.done
+}
+
+// Sync syncs the databases that have the remote Config.SyncRemote with it right away, like the background sync
+// enabled by Config.SyncInterval does, and returns their SyncStatus. The errors of each database are reported in its
+// SyncStatus; the error returned is for failing to sync at all. It waits for a background sync in progress to finish.
+func (c *Connector) Sync(ctx context.Context) ([]SyncStatus, error) {
+	if c.syncer == nil {
+		return nil, ErrProxied
+	}
+	if err := c.syncer.sync(ctx); err != nil {
+		return nil, err
+	}
+	return c.syncer.snapshot(), nil
+}
+
+// SyncStatus returns the status of the last sync of each database with its remote, in the background or by Sync,
+// sorted by database name. It is empty until the first sync.
+func (c *Connector) SyncStatus() []SyncStatus {
+	if c.syncer == nil {
+		return nil
+	}
+	return c.syncer.snapshot()
+}
+
+// snapshot returns the statuses of the last sync of each database, sorted by database name.
+func (s *syncer) snapshot() []SyncStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]SyncStatus, 0, len(s.statuses))
+	for _, status := range s.statuses {
+		status.Conflicts = append([]string(nil), status.Conflicts...)
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Database < statuses[j].Database
+	})
+	return statuses
+}
+
+// sync syncs each database that has the remote, recording its status and calling Config.OnSync with it.
+func (s *syncer) sync(ctx context.Context) error {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	c := s.c
+	eng, gmsCtx, err := c.newSession()
+	if err != nil {
+		return err
+	}
+	defer eng.release()
+
+	remote := c.cfg.SyncRemote
+	if remote == "" {
+		remote = defaultSyncRemote
+	}
+	bindings, err := argsToBindings([]driver.Value{remote})
+	if err != nil {
+		return err
+	}
+
+	sess := dsess.DSessFromSess(gmsCtx.Session)
+	for _, db := range eng.se.GetUnderlyingEngine().Analyzer.Catalog.AllDatabases(gmsCtx) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, ok := sess.GetDbData(gmsCtx, db.Name()); !ok {
+			// not a dolt database, like information_schema
+			continue
+		}
+		gmsCtx.SetCurrentDatabase(db.Name())
+		rows, err := queryRows(gmsCtx, eng.se, "SELECT name FROM dolt_remotes WHERE name = :v1", bindings)
+		if err != nil {
+			return translateError(err)
+		} else if len(rows) == 0 {
+			continue
+		}
+
+		s.mu.Lock()
+		status := s.statuses[db.Name()]
+		s.mu.Unlock()
+		status.Database, status.Remote = db.Name(), remote
+		status.Pulled, status.Conflicts, status.Pending = false, nil, 0
+		status.Err = syncDatabase(gmsCtx, eng, c.cfg, &status)
+		status.Time = c.cfg.now()
+		if status.Err == nil {
+			status.LastSuccess = status.Time
+		}
+		s.mu.Lock()
+		s.statuses[db.Name()] = status
+		s.mu.Unlock()
+		if c.cfg.OnSync != nil {
+			c.cfg.OnSync(status)
+		}
+	}
+	return nil
+}
+
+// syncDatabase syncs the current database of |gmsCtx| with |status.Remote|, filling in |status|.
+func syncDatabase(gmsCtx *gms.Context, eng *sharedEngine, cfg Config, status *SyncStatus) error {
+	var err error
+	if status.Branch, err = queryString(gmsCtx, eng.se, "SELECT active_branch()", nil); err != nil {
+		return translateError(err)
+	}
+	status.Upstream = cfg.SyncUpstream
+	if status.Upstream == "" {
+		status.Upstream = status.Branch
+	}
+	ref := status.Remote + "/" + status.Upstream
+
+	err = func() error {
+		if cfg.SyncMode.pulls() {
+			if err := pullDatabase(gmsCtx, eng, cfg, ref, status); err != nil {
+				return err
+			}
+		}
+
+		if cfg.SyncMode.pushes() {
+			// A device branch is pushed to a branch of its own first, which never conflicts, so that its commits reach
+			// the remote even when the upstream branch moved on
+			refspecs := []string{status.Branch}
+			if status.Upstream != status.Branch {
+				refspecs = append(refspecs, status.Branch+":"+status.Upstream)
+			}
+			for _, refspec := range refspecs {
+				bindings, err := argsToBindings([]driver.Value{status.Remote, refspec})
+				if err != nil {
+					return err
+				}
+				if _, err := queryRows(gmsCtx, eng.se, "CALL dolt_push(:v1, :v2)", bindings); err != nil {
+					return fmt.Errorf("pushing %s to %s: %w", refspec, status.Remote, translateError(err))
+				}
+			}
+		}
+		return nil
+	}()
+
+	// The pending commits are counted even if the sync failed, e.g. while the remote can't be reached
+	pending, pendingErr := pendingCommits(gmsCtx, eng, ref)
+	if pendingErr == nil {
+		status.Pending = pending
+	} else if err == nil {
+		err = translateError(pendingErr)
+	}
+	return err
+}
+
+// pendingCommits returns the number of commits of the current branch that the remote branch |ref| doesn't have, as
+// of the last fetch or push. All the commits of the branch are pending if the remote doesn't have the branch.
+func pendingCommits(gmsCtx *gms.Context, eng *sharedEngine, ref string) (int64, error) {
+	bindings, err := argsToBindings([]driver.Value{"remotes/" + ref})
+	if err != nil {
+		return 0, err
+	}
+	rows, err := queryRows(gmsCtx, eng.se, "SELECT hash FROM dolt_remote_branches WHERE name = :v1", bindings)
+	if err != nil {
+		return 0, err
+	} else if len(rows) == 0 {
+		count, err := queryString(gmsCtx, eng.se, "SELECT count(*) FROM dolt_log", nil)
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(count, 10, 64)
+	}
+
+	if bindings, err = argsToBindings([]driver.Value{ref}); err != nil {
+		return 0, err
+	}
+	rows, err = queryRows(gmsCtx, eng.se, "CALL dolt_count_commits('--from', 'HEAD', '--to', :v1)", bindings)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(fmt.Sprint(rows[0][0]), 10, 64)
+}
+
+// pullDatabase fetches |status.Remote| and merges its branch |ref| into the current branch, in a transaction,
+// resolving conflicts with Config.ResolveSyncConflict or Config.SyncConflicts.
+func pullDatabase(gmsCtx *gms.Context, eng *sharedEngine, cfg Config, ref string, status *SyncStatus) (err error) {
+	bindings, err := argsToBindings([]driver.Value{status.Remote})
+	if err != nil {
+		return err
+	}
+	if _, err := queryRows(gmsCtx, eng.se, "CALL dolt_fetch(:v1)", bindings); err != nil {
+		return fmt.Errorf("fetching %s: %w", status.Remote, translateError(err))
+	}
+
+	if bindings, err = argsToBindings([]driver.Value{"remotes/" + ref}); err != nil {
+		return err
+	}
+	if rows, err := queryRows(gmsCtx, eng.se, "SELECT hash FROM dolt_remote_branches WHERE name = :v1", bindings); err != nil {
+		return translateError(err)
+	} else if len(rows) == 0 {
+		// The remote doesn't have the branch yet, so there's nothing to pull
+		return nil
+	}
+
+	if _, err := queryRows(gmsCtx, eng.se, "START TRANSACTION", nil); err != nil {
+		return translateError(err)
+	}
+	defer func() {
+		if err != nil {
+			queryRows(gmsCtx, eng.se, "ROLLBACK", nil)
+		}
+	}()
+
+	before, err := queryString(gmsCtx, eng.se, "SELECT hashof('HEAD')", nil)
+	if err != nil {
+		return translateError(err)
+	}
+	if bindings, err = argsToBindings([]driver.Value{ref}); err != nil {
+		return err
+	}
+	rows, err := queryRows(gmsCtx, eng.se, "CALL dolt_merge(:v1)", bindings)
+	if err != nil {
+		return fmt.Errorf("merging %s: %w", ref, translateError(err))
+	}
+
+	if len(rows) > 0 && fmt.Sprint(rows[0][2]) != "0" {
+		if err := resolveSyncConflicts(gmsCtx, eng, cfg, ref, status); err != nil {
+			return err
+		}
+		if bindings, err = argsToBindings([]driver.Value{"Merge " + ref}); err != nil {
+			return err
+		}
+		if _, err := queryRows(gmsCtx, eng.se, "CALL dolt_commit('-Am', :v1)", bindings); err != nil {
+			return fmt.Errorf("committing the merge of %s: %w", ref, translateError(err))
+		}
+	}
+
+	if _, err := queryRows(gmsCtx, eng.se, "COMMIT", nil); err != nil {
+		return translateError(err)
+	}
+	after, err := queryString(gmsCtx, eng.se, "SELECT hashof('HEAD')", nil)
+	if err != nil {
+		return translateError(err)
+	}
+	status.Pulled = after != before
+	return nil
+}
+
+// resolveSyncConflicts resolves the conflicts of merging |ref| into the current branch, choosing the policy of each
+// table with Config.ResolveSyncConflict, or else Config.SyncConflicts. Nothing is resolved if any table's policy is
+// SyncConflictsFail.
+func resolveSyncConflicts(gmsCtx *gms.Context, eng *sharedEngine, cfg Config, ref string, status *SyncStatus) error {
+	rows, err := queryRows(gmsCtx, eng.se, "SELECT `table`, num_conflicts FROM dolt_conflicts ORDER BY `table`", nil)
+	if err != nil {
+		return translateError(err)
+	}
+
+	policies := make([]SyncConflicts, len(rows))
+	var failed []string
+	for i, row := range rows {
+		conflict := SyncConflict{
+			Database: status.Database,
+			Branch:   status.Branch,
+			Remote:   status.Remote,
+			Upstream: status.Upstream,
+			Table:    fmt.Sprint(row[0]),
+		}
+		if conflict.Rows, err = strconv.ParseInt(fmt.Sprint(row[1]), 10, 64); err != nil {
+			return err
+		}
+		status.Conflicts = append(status.Conflicts, conflict.Table)
+
+		policies[i] = cfg.SyncConflicts
+		if cfg.ResolveSyncConflict != nil {
+			policies[i] = cfg.ResolveSyncConflict(conflict)
+		}
+		switch policies[i] = SyncConflicts(strings.ToLower(string(policies[i]))); policies[i] {
+		case SyncConflictsOurs, SyncConflictsTheirs:
+		default:
+			failed = append(failed, conflict.Table)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: merging %s into %s: %s", ErrSyncConflicts, ref, status.Branch, strings.Join(failed, ", "))
+	}
+
+	for i, row := range rows {
+		bindings, err := argsToBindings([]driver.Value{"--" + string(policies[i]), fmt.Sprint(row[0])})
+		if err != nil {
+			return err
+		}
+		if _, err := queryRows(gmsCtx, eng.se, "CALL dolt_conflicts_resolve(:v1, :v2)", bindings); err != nil {
+			return fmt.Errorf("resolving the conflicts of merging %s: %w", ref, translateError(err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	tempDir := func() string {
+		dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		return dir
+	}
+	open := func(cfg Config) (*Connector, *sql.DB) {
+		cfg.CommitName = "Billy Batson"
+		cfg.CommitEmail = "shazam@gmail.com"
+		connector, err := NewConnector(cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { connector.Close() })
+		db := sql.OpenDB(connectorOnly{connector})
+		t.Cleanup(func() { db.Close() })
+		return connector, db
+	}
+	exec := func(db *sql.DB, queries ...string) {
+		for _, query := range queries {
+			_, err := db.ExecContext(ctx, query)
+			require.NoError(t, err, query)
+		}
+	}
+	name := func(db *sql.DB, id int) string {
+		var name string
+		require.NoError(t, db.QueryRowContext(ctx, "select name from items where id = ?", id).Scan(&name))
+		return name
+	}
+
+	// The central database is a file remote, which another process pushes to and pulls from
+	remote := "file://" + filepath.ToSlash(filepath.Join(tempDir(), "inventory"))
+	_, central := open(Config{Directory: tempDir()})
+	exec(central,
+		"create database inventory",
+		"use inventory",
+		"create table items (id int primary key, name varchar(20))",
+		"insert into items values (1, 'bolt')",
+		"call dolt_commit('-Am', 'first item')",
+		"call dolt_remote('add', 'origin', '"+remote+"')",
+		"call dolt_push('origin', 'main')",
+	)
+	push := func(query string) {
+		exec(central, "call dolt_pull('origin', 'main')", query, "call dolt_commit('-Am', 'central change')", "call dolt_push('origin', 'main')")
+	}
+
+	edgeDir := tempDir()
+	_, clone := open(Config{Directory: edgeDir})
+	exec(clone, "call dolt_clone('"+remote+"', 'inventory')")
+
+	// Syncing both ways pulls the central commits and pushes the edge's
+	edge, db := open(Config{Directory: edgeDir, Database: "inventory", SyncMode: SyncBoth, SyncConflicts: SyncConflictsTheirs})
+	push("insert into items values (2, 'nut')")
+	exec(db, "insert into items values (3, 'washer')", "call dolt_commit('-Am', 'edge change')")
+	statuses, err := edge.Sync(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	require.NoError(t, statuses[0].Err)
+	require.Equal(t, "inventory", statuses[0].Database)
+	require.Equal(t, "main", statuses[0].Branch)
+	require.Equal(t, "origin", statuses[0].Remote)
+	require.True(t, statuses[0].Pulled)
+	require.Equal(t, statuses[0].Time, statuses[0].LastSuccess)
+	require.Equal(t, statuses, edge.SyncStatus())
+	require.Equal(t, "nut", name(db, 2))
+
+	exec(central, "call dolt_pull('origin', 'main')")
+	require.Equal(t, "washer", name(central, 3))
+
+	// Syncing again changes nothing
+	statuses, err = edge.Sync(ctx)
+	require.NoError(t, err)
+	require.NoError(t, statuses[0].Err)
+	require.False(t, statuses[0].Pulled)
+
+	// Conflicts are resolved with the policy
+	push("update items set name = 'central bolt' where id = 1")
+	exec(db, "update items set name = 'edge bolt' where id = 1", "call dolt_commit('-Am', 'edge change')")
+	statuses, err = edge.Sync(ctx)
+	require.NoError(t, err)
+	require.NoError(t, statuses[0].Err)
+	require.Equal(t, []string{"items"}, statuses[0].Conflicts)
+	require.Equal(t, "central bolt", name(db, 1))
+	require.NoError(t, edge.Close())
+
+	// or fail the sync, leaving the database as it was
+	edge, db = open(Config{Directory: edgeDir, Database: "inventory"})
+	push("update items set name = 'central nut' where id = 2")
+	exec(db, "update items set name = 'edge nut' where id = 2", "call dolt_commit('-Am', 'edge change')")
+	statuses, err = edge.Sync(ctx)
+	require.NoError(t, err)
+	require.ErrorIs(t, statuses[0].Err, ErrSyncConflicts)
+	require.Equal(t, []string{"items"}, statuses[0].Conflicts)
+	require.True(t, statuses[0].LastSuccess.IsZero())
+	require.Equal(t, "edge nut", name(db, 2))
+	var conflicts int
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from dolt_conflicts").Scan(&conflicts))
+	require.Zero(t, conflicts)
+	require.NoError(t, edge.Close())
+
+	// Databases sync in the background
+	synced := make(chan SyncStatus, 10)
+	edge, db = open(Config{
+		Directory:     edgeDir,
+		Database:      "inventory",
+		SyncInterval:  10 * time.Millisecond,
+		SyncConflicts: SyncConflictsOurs,
+		OnSync:        func(status SyncStatus) { synced <- status },
+	})
+	status := <-synced
+	require.NoError(t, status.Err)
+	require.True(t, status.Pulled)
+	require.Equal(t, "edge nut", name(db, 2))
+	require.NoError(t, edge.Close())
+
+	_, err = NewConnector(Config{Directory: edgeDir, CommitName: "Billy", CommitEmail: "b@b.com", SyncMode: "sideways"})
+	require.ErrorContains(t, err, "unknown sync mode 'sideways'")
+}
+
+func TestSyncOfflineQueue(t *testing.T) {
+	ctx := context.Background()
+	tempDir := func() string {
+		dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+		return dir
+	}
+	open := func(cfg Config) (*Connector, *sql.DB) {
+		cfg.CommitName = "Billy Batson"
+		cfg.CommitEmail = "shazam@gmail.com"
+		connector, err := NewConnector(cfg)
+		require.NoError(t, err)
+		t.Cleanup(func() { connector.Close() })
+		db := sql.OpenDB(connectorOnly{connector})
+		t.Cleanup(func() { db.Close() })
+		return connector, db
+	}
+	exec := func(db *sql.DB, queries ...string) {
+		for _, query := range queries {
+			_, err := db.ExecContext(ctx, query)
+			require.NoError(t, err, query)
+		}
+	}
+
+	remoteDir := filepath.Join(tempDir(), "inventory")
+	remote := "file://" + filepath.ToSlash(remoteDir)
+	_, central := open(Config{Directory: tempDir()})
+	exec(central,
+		"create database hub",
+		"use hub",
+		"create table items (id int primary key, name varchar(20))",
+		"insert into items values (1, 'bolt')",
+		"call dolt_commit('-Am', 'first item')",
+		"call dolt_remote('add', 'origin', '"+remote+"')",
+		"call dolt_push('origin', 'main')",
+	)
+
+	// The device commits to a branch of its own, synced with main. The default branch of a database is a global
+	// setting, so the central database has another name.
+	edgeDir := tempDir()
+	_, clone := open(Config{Directory: edgeDir})
+	exec(clone, "call dolt_clone('"+remote+"', 'inventory')", "use inventory", "call dolt_branch('device-1')")
+	t.Cleanup(func() { gms.SystemVariables.SetGlobal(dsess.DefaultBranchKey("inventory"), "") })
+	var resolved []SyncConflict
+	edge, db := open(Config{
+		Directory:    edgeDir,
+		Database:     "inventory",
+		Databases:    map[string]DatabaseConfig{"inventory": {Branch: "device-1"}},
+		SyncMode:     SyncBoth,
+		SyncUpstream: "main",
+		ResolveSyncConflict: func(conflict SyncConflict) SyncConflicts {
+			resolved = append(resolved, conflict)
+			return SyncConflictsTheirs
+		},
+	})
+
+	// While the remote can't be reached, commits queue up
+	require.NoError(t, os.Rename(remoteDir, remoteDir+".offline"))
+	exec(db,
+		"insert into items values (2, 'nut')", "call dolt_commit('-Am', 'offline nut')",
+		"update items set name = 'device bolt' where id = 1", "call dolt_commit('-Am', 'offline bolt')",
+	)
+	statuses, err := edge.Sync(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	require.Error(t, statuses[0].Err)
+	require.Equal(t, "device-1", statuses[0].Branch)
+	require.Equal(t, "main", statuses[0].Upstream)
+	require.Equal(t, int64(2), statuses[0].Pending)
+	require.True(t, statuses[0].LastSuccess.IsZero())
+
+	// Once it can, they are pushed and merged, with conflicts resolved by the policy
+	require.NoError(t, os.Rename(remoteDir+".offline", remoteDir))
+	exec(central, "update items set name = 'central bolt' where id = 1", "call dolt_commit('-Am', 'central bolt')",
+		"call dolt_push('origin', 'main')")
+	statuses, err = edge.Sync(ctx)
+	require.NoError(t, err)
+	require.NoError(t, statuses[0].Err)
+	require.Zero(t, statuses[0].Pending)
+	require.True(t, statuses[0].Pulled)
+	require.Equal(t, statuses[0].Time, statuses[0].LastSuccess)
+	require.Equal(t, []string{"items"}, statuses[0].Conflicts)
+	require.Equal(t, []SyncConflict{{
+		Database: "inventory",
+		Branch:   "device-1",
+		Remote:   "origin",
+		Upstream: "main",
+		Table:    "items",
+		Rows:     1,
+	}}, resolved)
+
+	exec(central, "call dolt_pull('origin', 'main')")
+	var names []string
+	rows, err := central.QueryContext(ctx, "select name from items order by id")
+	require.NoError(t, err)
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"central bolt", "nut"}, names)
+
+	// The device branch was pushed as well
+	var branches int
+	require.NoError(t, central.QueryRowContext(ctx, "select count(*) from dolt_remote_branches where name = 'remotes/origin/device-1'").Scan(&branches))
+	require.Equal(t, 1, branches)
+}
@@ -0,0 +1,130 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	gms "github.com/dolthub/go-mysql-server/sql"
+)
+
+// TableStats describes the size of a table, read from Dolt's storage metadata rather than by scanning the table.
+type TableStats struct {
+	Database string
+	Table    string
+	// Rows is the number of rows in the table
+	Rows uint64
+	// DataLength is the estimated size of the table's rows in bytes, computed from the row count and the average
+	// length of the schema's columns, as reported by information_schema.TABLES
+	DataLength uint64
+	// Indexes are the statistics of the table's secondary indexes
+	Indexes []IndexStats
+}
+
+// IndexStats describes the size of a secondary index.
+type IndexStats struct {
+	Name string
+	// Rows is the number of entries in the index
+	Rows uint64
+	// DataLength is the estimated size of the index in bytes, computed like TableStats.DataLength from the indexed
+	// columns
+	DataLength uint64
+}
+
+// TableStats returns the size statistics of |table| in |database|, as seen by the working set of a new session. The
+// row counts are exact, and obtaining them doesn't scan the table, so it is suitable for capacity dashboards polling
+// large tables.
+func (c *Connector) TableStats(ctx context.Context, database, table string) (TableStats, error) {
+	if err := ctx.Err(); err != nil {
+		return TableStats{}, err
+	}
+
+	eng, gmsCtx, err := c.newDatabaseSession(ctx, database)
+	if err != nil {
+		return TableStats{}, err
+	}
+	defer eng.release()
+
+	gmsCtx.SetCurrentDatabase(database)
+	tx, err := gmsCtx.Session.(gms.TransactionSession).StartTransaction(gmsCtx, gms.ReadOnly)
+	if err != nil {
+		return TableStats{}, translateError(err)
+	}
+	gmsCtx.SetTransaction(tx)
+	defer gmsCtx.Session.(gms.TransactionSession).Rollback(gmsCtx, tx)
+
+	tbl, _, err := eng.se.GetUnderlyingEngine().Analyzer.Catalog.Table(gmsCtx, database, table)
+	if err != nil {
+		return TableStats{}, translateError(err)
+	}
+
+	statsTable, ok := tbl.(gms.StatisticsTable)
+	if !ok {
+		return TableStats{}, fmt.Errorf("table '%s' doesn't have statistics", table)
+	}
+
+	stats := TableStats{Database: database, Table: tbl.Name()}
+	if stats.Rows, _, err = statsTable.RowCount(gmsCtx); err != nil {
+		return TableStats{}, translateError(err)
+	}
+	if stats.DataLength, err = statsTable.DataLength(gmsCtx); err != nil {
+		return TableStats{}, translateError(err)
+	}
+
+	roots, ok := dsess.DSessFromSess(gmsCtx.Session).GetRoots(gmsCtx, database)
+	if !ok {
+		return TableStats{}, translateError(gms.ErrDatabaseNotFound.New(database))
+	}
+	dt, ok, err := roots.Working.GetTable(gmsCtx, doltdb.TableName{Name: tbl.Name()})
+	if err != nil {
+		return TableStats{}, err
+	} else if !ok {
+		return TableStats{}, translateError(gms.ErrTableNotFound.New(table))
+	}
+	sch, err := dt.GetSchema(gmsCtx)
+	if err != nil {
+		return TableStats{}, err
+	}
+
+	for _, idx := range sch.Indexes().AllIndexes() {
+		data, err := dt.GetIndexRowData(gmsCtx, idx.Name())
+		if err != nil {
+			return TableStats{}, err
+		}
+		rows, err := data.Count()
+		if err != nil {
+			return TableStats{}, err
+		}
+
+		stats.Indexes = append(stats.Indexes, IndexStats{
+			Name:       idx.Name(),
+			Rows:       rows,
+			DataLength: rows * schema.SchemaAvgLength(indexColumns(tbl.Schema(), idx)),
+		})
+	}
+
+	return stats, nil
+}
+
+// indexColumns returns the columns of |sch| stored in |idx|: its own columns followed by the primary key.
+func indexColumns(sch gms.Schema, idx schema.Index) gms.Schema {
+	var cols gms.Schema
+	for _, name := range idx.ColumnNames() {
+		for _, col := range sch {
+			if strings.EqualFold(col.Name, name) {
+				cols = append(cols, col)
+				break
+			}
+		}
+	}
+	for _, col := range sch {
+		if col.PrimaryKey {
+			cols = append(cols, col)
+		}
+	}
+
+	return cols
+}
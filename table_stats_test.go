@@ -0,0 +1,34 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableStats(t *testing.T) {
+	connector, db, cleanUpFunc := initializeTestConnector(t)
+	defer cleanUpFunc()
+
+	ctx := context.Background()
+	_, err := db.ExecContext(ctx, "create table t (id int primary key, name varchar(10), key name_idx (name))")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "insert into t values (1, 'one'), (2, 'two'), (3, 'three')")
+	require.NoError(t, err)
+
+	stats, err := connector.TableStats(ctx, "testdb", "T")
+	require.NoError(t, err)
+	require.Equal(t, "testdb", stats.Database)
+	require.Equal(t, "t", stats.Table)
+	require.Equal(t, uint64(3), stats.Rows)
+	require.NotZero(t, stats.DataLength)
+	require.Len(t, stats.Indexes, 1)
+	require.Equal(t, "name_idx", stats.Indexes[0].Name)
+	require.Equal(t, uint64(3), stats.Indexes[0].Rows)
+	require.NotZero(t, stats.Indexes[0].DataLength)
+	require.Less(t, stats.Indexes[0].DataLength, stats.DataLength+1)
+
+	_, err = connector.TableStats(ctx, "testdb", "missing")
+	require.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package embedded
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/dolthub/dolt/go/store/util/tempfiles"
+)
+
+// tempDir is the engine's temp directory set by a connector with Config.TempDir while it's open. A nil *tempDir sets
+// nothing.
+type tempDir struct {
+	provider  tempfiles.TempFileProvider
+	closeOnce sync.Once
+}
+
+// tempDirs is the state of the engine's temp file provider, which is shared by the whole process. The open connectors
+// with Config.TempDir are kept in the order they were opened, the last of which sets the provider, and the provider
+// the engine had before them is restored once they're all closed.
+var tempDirs struct {
+	mu       sync.Mutex
+	open     []*tempDir
+	original tempfiles.TempFileProvider
+}
+
+// checkTempDir creates |dir| if it doesn't exist and checks that the engine can write its scratch files to it.
+func checkTempDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating temp directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, "dolt-driver-check-*")
+	if err != nil {
+		return fmt.Errorf("temp directory '%s' isn't writable: %w", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+// useTempDir makes the engine write its scratch files to |dir|, checked by checkTempDir, and returns the tempDir
+// restoring the previous temp directory once the connector is closed. It returns nil if |dir| is empty.
+func useTempDir(dir string) *tempDir {
+	if dir == "" {
+		return nil
+	}
+
+	t := &tempDir{provider: tempfiles.NewTempFileProviderAt(dir)}
+	tempDirs.mu.Lock()
+	defer tempDirs.mu.Unlock()
+	if len(tempDirs.open) == 0 {
+		tempDirs.original = tempfiles.MovableTempFileProvider
+	}
+	tempDirs.open = append(tempDirs.open, t)
+	tempfiles.MovableTempFileProvider = t.provider
+	return t
+}
+
+// close sets the engine's temp directory back to that of the last connector opened with Config.TempDir that is still
+// open, or to the one the engine had before them. A nil *tempDir does nothing.
+func (t *tempDir) close() {
+	if t == nil {
+		return
+	}
+	t.closeOnce.Do(func() {
+		tempDirs.mu.Lock()
+		defer tempDirs.mu.Unlock()
+		for i, open := range tempDirs.open {
+			if open == t {
+				tempDirs.open = append(tempDirs.open[:i], tempDirs.open[i+1:]...)
+				break
+			}
+		}
+		if n := len(tempDirs.open); n > 0 {
+			tempfiles.MovableTempFileProvider = tempDirs.open[n-1].provider
+		} else {
+			tempfiles.MovableTempFileProvider = tempDirs.original
+			tempDirs.original = nil
+		}
+	})
+}
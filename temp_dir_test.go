@@ -0,0 +1,59 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dolthub/dolt/go/store/util/tempfiles"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTempDir(t *testing.T) {
+	previous := tempfiles.MovableTempFileProvider
+
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	scratch := filepath.Join(dir, "scratch", "dolt")
+
+	ctx := context.Background()
+	connector, err := NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+		TempDir:     scratch,
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	require.Equal(t, scratch, tempfiles.MovableTempFileProvider.GetTempDir())
+
+	// Building an index sorts the table's rows with scratch files
+	for _, query := range []string{
+		"create database testdb",
+		"use testdb",
+		"create table t (id int primary key, v int)",
+		"insert into t values (1, 30), (2, 20), (3, 10)",
+		"create index v on t (v)",
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err, query)
+	}
+	var id int
+	require.NoError(t, db.QueryRowContext(ctx, "select id from t where v = 20").Scan(&id))
+	require.Equal(t, 2, id)
+
+	// A temp directory that can't be created fails the open
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	_, err = NewConnector(Config{Directory: dir, CommitName: "Billy Batson", CommitEmail: "shazam@gmail.com", TempDir: file})
+	require.ErrorContains(t, err, "creating temp directory")
+	require.Equal(t, scratch, tempfiles.MovableTempFileProvider.GetTempDir())
+
+	// The temp directory from before the connector is restored once it's closed
+	require.NoError(t, db.Close())
+	require.Same(t, previous, tempfiles.MovableTempFileProvider)
+}
@@ -0,0 +1,127 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// tenantMigrationsTable is the table of each tenant database created by EnsureTenantDB that records the migrations
+// applied to it.
+const tenantMigrationsTable = "tenant_migrations"
+
+// connectorOnly hides the Close method of a connector from sql.OpenDB, whose DB would otherwise close the connector
+// when it is closed.
+type connectorOnly struct {
+	driver.Connector
+}
+
+// EnsureTenantDB creates the database |name| if it doesn't exist, applies the migrations of |schema| it hasn't applied
+// yet, and commits, so that a service with a database per tenant can provision a tenant's database, or bring it up to
+// date, with one call. The migrations are the .sql files at the root of |schema|, such as 0001_create_orders.sql,
+// applied in the order of their names. Each migration is a script of statements, executed like ExecScript does, in a
+// transaction that records it in the tenant_migrations table of the database, so a migration that fails is rolled
+// back and is applied again by the next call. Migrations aren't applied again once recorded, so they must not be
+// renamed or edited afterwards. The changes are committed to the database's current branch, and nothing is committed
+// if the database was up to date. Calls are serialized within the connector, but not with other processes.
+func (c *Connector) EnsureTenantDB(ctx context.Context, name string, schema fs.FS) error {
+	migrations, err := fs.Glob(schema, "*.sql")
+	if err != nil {
+		return err
+	}
+
+	c.tenantMu.Lock()
+	defer c.tenantMu.Unlock()
+
+	db := sql.OpenDB(connectorOnly{c})
+	defer db.Close()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, query := range []string{
+		"create database if not exists " + quoteIdentifier(name),
+		"use " + quoteIdentifier(name),
+		"create table if not exists " + tenantMigrationsTable + " (name varchar(255) primary key)",
+	} {
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("creating tenant database '%s': %w", name, err)
+		}
+	}
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("reading the migrations of tenant database '%s': %w", name, err)
+	}
+	var newlyApplied []string
+	for _, migration := range migrations {
+		if applied[migration] {
+			continue
+		}
+		if err := applyMigration(ctx, conn, schema, migration); err != nil {
+			return fmt.Errorf("applying migration %s to tenant database '%s': %w", migration, name, err)
+		}
+		newlyApplied = append(newlyApplied, migration)
+	}
+
+	message := "Create tenant database " + name
+	if len(newlyApplied) > 0 {
+		message = fmt.Sprintf("Apply migrations to tenant database %s: %s", name, strings.Join(newlyApplied, ", "))
+	}
+	if _, err := conn.ExecContext(ctx, "call dolt_commit('-Am', ?, '--skip-empty')", message); err != nil {
+		return fmt.Errorf("committing tenant database '%s': %w", name, err)
+	}
+	return nil
+}
+
+// appliedMigrations returns the names of the migrations recorded in the tenant_migrations table of the current
+// database of |conn|.
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[string]bool, error) {
+	rows, err := conn.QueryContext(ctx, "select name from "+tenantMigrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		applied[name] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration executes the script of |migration| in |schema| on |conn| and records it, in a transaction.
+func applyMigration(ctx context.Context, conn *sql.Conn, schema fs.FS, migration string) (err error) {
+	f, err := schema.Open(migration)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := conn.ExecContext(ctx, "start transaction"); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			conn.ExecContext(ctx, "rollback")
+		}
+	}()
+
+	if err := ExecScript(ctx, conn, f, ScriptOptions{}); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "insert into "+tenantMigrationsTable+" values (?)", migration); err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, "commit")
+	return err
+}
@@ -0,0 +1,79 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureTenantDB(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	connector, err := NewConnector(Config{
+		Directory:   dir,
+		CommitName:  "Billy Batson",
+		CommitEmail: "shazam@gmail.com",
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	schema := fstest.MapFS{
+		"0001_orders.sql": {Data: []byte("create table orders (id int primary key, total int);\ncreate index total on orders (total);")},
+		"0002_seed.sql":   {Data: []byte("insert into orders values (1, 10);")},
+		"README.md":       {Data: []byte("not a migration")},
+	}
+	require.NoError(t, connector.EnsureTenantDB(ctx, "tenant-1", schema))
+
+	commits := func() int {
+		var count int
+		require.NoError(t, db.QueryRowContext(ctx, "select count(*) from `tenant-1`.dolt_log").Scan(&count))
+		return count
+	}
+	var total int
+	require.NoError(t, db.QueryRowContext(ctx, "select total from `tenant-1`.orders where id = 1").Scan(&total))
+	require.Equal(t, 10, total)
+	require.Equal(t, 2, commits())
+	var message string
+	require.NoError(t, db.QueryRowContext(ctx, "select message from `tenant-1`.dolt_log limit 1").Scan(&message))
+	require.Equal(t, "Apply migrations to tenant database tenant-1: 0001_orders.sql, 0002_seed.sql", message)
+
+	// Calling it again changes nothing
+	require.NoError(t, connector.EnsureTenantDB(ctx, "tenant-1", schema))
+	require.Equal(t, 2, commits())
+
+	// New migrations are applied, and a failing one is rolled back and reported
+	schema["0003_customers.sql"] = &fstest.MapFile{Data: []byte("create table customers (id int primary key);")}
+	schema["0004_broken.sql"] = &fstest.MapFile{Data: []byte("create table broken (id int primary key);\ninsert into nosuchtable values (1);")}
+	err = connector.EnsureTenantDB(ctx, "tenant-1", schema)
+	require.ErrorContains(t, err, "applying migration 0004_broken.sql to tenant database 'tenant-1'")
+	var stmtErr *StatementError
+	require.ErrorAs(t, err, &stmtErr)
+	require.Equal(t, 2, stmtErr.Index)
+
+	var tables []string
+	rows, err := db.QueryContext(ctx, "show tables from `tenant-1`")
+	require.NoError(t, err)
+	for rows.Next() {
+		var table string
+		require.NoError(t, rows.Scan(&table))
+		tables = append(tables, table)
+	}
+	require.NoError(t, rows.Err())
+	require.Contains(t, tables, "customers")
+	require.NotContains(t, tables, "broken")
+
+	delete(schema, "0004_broken.sql")
+	require.NoError(t, connector.EnsureTenantDB(ctx, "tenant-1", schema))
+	require.Equal(t, 3, commits())
+	var applied int
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from `tenant-1`.tenant_migrations").Scan(&applied))
+	require.Equal(t, 3, applied)
+}
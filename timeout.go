@@ -0,0 +1,69 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/go-sql-driver/mysql"
+)
+
+// maxExecutionTimeVar is the session variable limiting the execution time of SELECT statements, in milliseconds.
+const maxExecutionTimeVar = "max_execution_time"
+
+// ErrQueryTimeout is the error returned when a SELECT statement runs for longer than the session's max_execution_time,
+// like MySQL's ER_QUERY_TIMEOUT. Match it with errors.Is, which compares MySQL error numbers.
+var ErrQueryTimeout = &mysql.MySQLError{
+	Number:  3024,
+	Message: "Query execution was interrupted, maximum statement execution time exceeded",
+}
+
+// maxExecutionTime returns the session's max_execution_time if it applies to |query|, which is the case for SELECT
+// statements, or 0 if the query's execution time isn't limited.
+func (stmt *doltStmt) maxExecutionTime(query string) time.Duration {
+	limit, err := stmt.gmsCtx.GetSessionVariable(stmt.gmsCtx, maxExecutionTimeVar)
+	if err != nil {
+		return 0
+	}
+	ms, ok := limit.(int64)
+	if !ok || ms <= 0 || !isSelect(query) {
+		return 0
+	}
+
+	return time.Duration(ms) * time.Millisecond
+}
+
+// isSelect returns whether |query| is a SELECT statement, to which max_execution_time applies.
+func isSelect(query string) bool {
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return false
+	}
+
+	switch stmt.(type) {
+	case *sqlparser.Select, *sqlparser.SetOp, *sqlparser.ParenSelect:
+		return isReadOnlyStatement(stmt)
+	default:
+		return false
+	}
+}
+
+// interruptError returns ErrQueryTimeout in place of |err| if it was caused by |gmsCtx| reaching its
+// max_execution_time deadline, and ErrQueryInterrupted if the query was killed. It must be called before the statement's
+// own cancel function, which also cancels |gmsCtx|.
+func interruptError(gmsCtx *gms.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch ctxErr := gmsCtx.Err(); {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		return ErrQueryTimeout
+	case errors.Is(ctxErr, context.Canceled):
+		return ErrQueryInterrupted
+	default:
+		return err
+	}
+}
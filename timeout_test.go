@@ -0,0 +1,46 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxExecutionTime(t *testing.T) {
+	dir, err := os.MkdirTemp("", "dolthub-driver-tests-db*")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	connector, err := NewConnector(Config{
+		Directory:        dir,
+		CommitName:       "Billy Batson",
+		CommitEmail:      "shazam@gmail.com",
+		MaxExecutionTime: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	const runaway = "with recursive c(n) as (select 1 union all select n + 1 from c where n < 1000) select count(*) from c a, c b, c d"
+	start := time.Now()
+	var count int
+	err = conn.QueryRowContext(ctx, runaway).Scan(&count)
+	require.ErrorIs(t, err, ErrQueryTimeout)
+	require.Less(t, time.Since(start), 10*time.Second)
+
+	// The connection can still be used, and the limit changed for its session
+	require.NoError(t, conn.QueryRowContext(ctx, "select 1").Scan(&count))
+	_, err = conn.ExecContext(ctx, "set max_execution_time = 0")
+	require.NoError(t, err)
+	require.NoError(t, conn.QueryRowContext(ctx, "with recursive c(n) as (select 1 union all select n + 1 from c where n < 1000) select count(*) from c").Scan(&count))
+	require.Equal(t, 1000, count)
+}
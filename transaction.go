@@ -1,7 +1,9 @@
 package embedded
 
 import (
+	"context"
 	"database/sql/driver"
+
 	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
 	gms "github.com/dolthub/go-mysql-server/sql"
 )
@@ -9,18 +11,37 @@ import (
 var _ driver.Tx = (*doltTx)(nil)
 
 type doltTx struct {
-	gmsCtx *gms.Context
-	se     *engine.SqlEngine
+	gmsCtx   *gms.Context
+	se       *engine.SqlEngine
+	shadow   *shadowConn
+	recorder *connRecorder
+	// head is the commit hash the transaction was begun with RequireHead, or empty
+	head string
 }
 
-// Commit finishes the transaction.
+// Commit finishes the transaction. A transaction begun with RequireHead is rolled back instead if the HEAD of its
+// branch moved, and Commit returns a *HeadMovedError. DOLT_COMMIT commits the transaction it runs in, so there is
+// nothing left to check if the transaction's last statement was one.
 func (tx *doltTx) Commit() error {
+	if tx.gmsCtx.GetTransaction() != nil {
+		if err := checkHead(tx.gmsCtx, tx.head); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return rollbackErr
+			}
+			return err
+		}
+	}
+
 	_, _, _, err := tx.se.Query(tx.gmsCtx, "COMMIT;")
+	tx.shadow.mirror(context.Background(), "COMMIT", nil, 0, err)
+	tx.recorder.recordExec("COMMIT", nil, 0, err)
 	return translateError(err)
 }
 
 // Rollback cancels the transaction.
 func (tx *doltTx) Rollback() error {
 	_, _, _, err := tx.se.Query(tx.gmsCtx, "ROLLBACK;")
+	tx.shadow.mirror(context.Background(), "ROLLBACK", nil, 0, err)
+	tx.recorder.recordExec("ROLLBACK", nil, 0, err)
 	return translateError(err)
 }
@@ -0,0 +1,122 @@
+package embedded
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// Warning is a note, warning or error left by a statement, as listed by SHOW WARNINGS.
+type Warning struct {
+	// Level is "Note", "Warning" or "Error"
+	Level string
+	// Code is the MySQL error number of the warning
+	Code int
+	// Message describes the warning
+	Message string
+}
+
+// Warner is implemented by the driver.Rows and driver.Result returned by the driver's statements, for code using the
+// driver directly, e.g. through sql.Conn.Raw. Warnings returns the warnings the statement left, in the order they
+// occurred. The warnings of a query are complete once its rows have been read.
+type Warner interface {
+	Warnings() []Warning
+}
+
+var _ Warner = (*doltRows)(nil)
+var _ Warner = (*doltMultiRows)(nil)
+var _ Warner = (*doltResult)(nil)
+
+// GetWarnings returns the warnings left by the last statement executed on |conn|, which must be a connection opened
+// with the dolt driver, like SHOW WARNINGS does.
+func GetWarnings(conn *sql.Conn) ([]Warning, error) {
+	var warnings []Warning
+	err := conn.Raw(func(driverConn any) error {
+		doltConn, ok := driverConn.(*DoltConn)
+		if !ok {
+			return fmt.Errorf("not a dolt connection: %T", driverConn)
+		}
+
+		warnings = sessionWarnings(doltConn.gmsCtx)
+		return nil
+	})
+
+	return warnings, err
+}
+
+// sessionWarnings returns the warnings of the session of |gmsCtx|, in the order they occurred.
+func sessionWarnings(gmsCtx *gms.Context) []Warning {
+	sessionWarnings := gmsCtx.Session.Warnings()
+	if len(sessionWarnings) == 0 {
+		return nil
+	}
+
+	// The session lists its warnings from the most recent one
+	warnings := make([]Warning, len(sessionWarnings))
+	for i, w := range sessionWarnings {
+		warnings[len(warnings)-1-i] = Warning{Level: w.Level, Code: w.Code, Message: w.Message}
+	}
+	return warnings
+}
+
+// clearWarnings clears the warnings of the previous statement before |query| executes, so that SHOW WARNINGS lists the
+// warnings of a single statement like in MySQL, unless |query| is SHOW WARNINGS or SHOW ERRORS, which list them.
+func clearWarnings(gmsCtx *gms.Context, query string) {
+	if isShowWarnings(query) {
+		return
+	}
+
+	// The session only clears the warnings it already counted in a previous call, which the engine makes once per
+	// statement, so they would otherwise be listed by the SHOW WARNINGS of the statement after next.
+	gmsCtx.ClearWarnings()
+	gmsCtx.ClearWarnings()
+}
+
+// isShowWarnings returns whether |query| is a SHOW WARNINGS or SHOW ERRORS statement, or their SHOW COUNT(*) forms.
+func isShowWarnings(query string) bool {
+	query = strings.TrimSpace(query)
+	if len(query) < 4 || !strings.EqualFold(query[:4], "show") {
+		return false
+	}
+
+	stmt, err := sqlparser.Parse(query)
+	if err != nil {
+		return false
+	}
+	show, ok := stmt.(*sqlparser.Show)
+	return ok && (strings.EqualFold(show.Type, "warnings") || strings.EqualFold(show.Type, "errors"))
+}
+
+// Warnings returns the warnings of the statement.
+func (rows *doltRows) Warnings() []Warning {
+	if rows.intercepted != nil || rows.gmsCtx == nil {
+		return nil
+	} else if rows.warnings != nil {
+		return *rows.warnings
+	}
+	return sessionWarnings(rows.gmsCtx)
+}
+
+// saveWarnings keeps the statement's warnings once its rows have been read, before the next statement clears them.
+func (rows *doltRows) saveWarnings() {
+	if rows.warnings == nil && rows.gmsCtx != nil {
+		warnings := sessionWarnings(rows.gmsCtx)
+		rows.warnings = &warnings
+	}
+}
+
+// Warnings returns the warnings of the statement of the current result set.
+func (d *doltMultiRows) Warnings() []Warning {
+	if d.currentRowSet >= len(d.rowSets) {
+		return nil
+	}
+	return d.rowSets[d.currentRowSet].Warnings()
+}
+
+// Warnings returns the warnings of the statement.
+func (result *doltResult) Warnings() []Warning {
+	return result.warnings
+}
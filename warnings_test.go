@@ -0,0 +1,61 @@
+package embedded
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarnings(t *testing.T) {
+	conn, cleanupFunc := initializeTestDatabaseConnection(t, false)
+	defer cleanupFunc()
+
+	ctx := context.Background()
+	_, err := conn.ExecContext(ctx, "create table t (v tinyint, s varchar(2))")
+	require.NoError(t, err)
+
+	truncation := []Warning{
+		{Level: "Note", Code: 1105, Message: "1000 out of range for tinyint"},
+		{Level: "Note", Code: 1105, Message: "string 'abc' is too large for column 'varchar(2)'"},
+	}
+	_, err = conn.ExecContext(ctx, "insert ignore into t values (1000, 'abc')")
+	require.NoError(t, err)
+	warnings, err := GetWarnings(conn)
+	require.NoError(t, err)
+	require.ElementsMatch(t, truncation, warnings)
+
+	// SHOW WARNINGS lists the warnings of the previous statement, and doesn't clear them
+	rows := [][]any{{"Note", 1105, truncation[1].Message}, {"Note", 1105, truncation[0].Message}}
+	requireResults(t, conn, "show warnings", rows)
+	requireResults(t, conn, "show warnings", rows)
+
+	// Each statement clears the warnings of the previous one
+	requireResults(t, conn, "select 1/0", [][]any{{nil}})
+	requireResults(t, conn, "show warnings", [][]any{{"Warning", 1365, "Division by 0"}})
+	requireResults(t, conn, "select 1", [][]any{{1}})
+	warnings, err = GetWarnings(conn)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	// The driver's rows and results report the warnings of their statement
+	require.NoError(t, conn.Raw(func(driverConn any) error {
+		doltConn := driverConn.(*DoltConn)
+
+		stmt, err := doltConn.Prepare("insert ignore into t values (1000, 'abc')")
+		require.NoError(t, err)
+		result, err := stmt.(driver.StmtExecContext).ExecContext(ctx, nil)
+		require.NoError(t, err)
+		require.ElementsMatch(t, truncation, result.(Warner).Warnings())
+
+		stmt, err = doltConn.Prepare("select 1/0")
+		require.NoError(t, err)
+		rows, err := stmt.(driver.StmtQueryContext).QueryContext(ctx, nil)
+		require.NoError(t, err)
+		require.NoError(t, rows.Next(make([]driver.Value, 1)))
+		require.NoError(t, rows.Close())
+		require.Equal(t, []Warning{{Level: "Warning", Code: 1365, Message: "Division by 0"}}, rows.(Warner).Warnings())
+		return nil
+	}))
+}
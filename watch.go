@@ -0,0 +1,132 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	gms "github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// defaultWatchInterval is the interval Watch polls at when Config.WatchInterval isn't set.
+const defaultWatchInterval = time.Second
+
+// Change is a notification sent by Watch when the data it watches changes.
+type Change struct {
+	// Database is the watched database
+	Database string
+	// Table is the watched table, or empty if the whole database is watched
+	Table string
+	// PreviousHash and Hash are the hashes of the watched table or database's working set before and after the change
+	PreviousHash string
+	Hash         string
+	// Err is set if watching failed, for instance because the table was dropped. It is the last change sent.
+	Err error
+}
+
+// Watch returns a channel that receives a Change every time the working set of |table| in |database| changes, or the
+// working set of the whole database if |table| is empty. Changes committed by any connection are noticed by polling
+// the working set hash every Config.WatchInterval, so several changes made within one interval are reported as one. The channel is closed when |ctx| is done, or after a Change reporting an error.
+func (c *Connector) Watch(ctx context.Context, database, table string) (<-chan Change, error) {
+	eng, gmsCtx, err := c.newDatabaseSession(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	gmsCtx.SetCurrentDatabase(database)
+
+	query, bindings := "SELECT dolt_hashof_db()", map[string]sqlparser.Expr(nil)
+	if table != "" {
+		query, bindings = "SELECT dolt_hashof_table(:v1)", map[string]sqlparser.Expr{
+			"v1": sqlparser.NewStrVal([]byte(table)),
+		}
+	}
+
+	hash, err := queryString(gmsCtx, eng.se, query, bindings)
+	if err != nil {
+		eng.release()
+		return nil, translateError(err)
+	}
+
+	interval := c.cfg.WatchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	changes := make(chan Change)
+	go func() {
+		defer eng.release()
+		defer close(changes)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			change := Change{Database: database, Table: table, PreviousHash: hash}
+			change.Hash, change.Err = queryString(gmsCtx, eng.se, query, bindings)
+			if change.Err == nil && change.Hash == hash {
+				continue
+			}
+			change.Err = translateError(change.Err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case changes <- change:
+			}
+			if change.Err != nil {
+				return
+			}
+			hash = change.Hash
+		}
+	}()
+
+	return changes, nil
+}
+
+// queryString runs |query| in the session of |gmsCtx| and returns the first column of its first row as a string.
+func queryString(gmsCtx *gms.Context, se *engine.SqlEngine, query string, bindings map[string]sqlparser.Expr) (string, error) {
+	_, itr, _, err := se.GetUnderlyingEngine().QueryWithBindings(gmsCtx, query, nil, bindings, nil)
+	if err != nil {
+		return "", err
+	}
+
+	row, err := itr.Next(gmsCtx)
+	if err == io.EOF {
+		err = fmt.Errorf("query returned no rows: %s", query)
+	}
+	if err != nil {
+		itr.Close(gmsCtx)
+		return "", err
+	}
+
+	return fmt.Sprint(row[0]), itr.Close(gmsCtx)
+}
+
+// queryRows runs |query| in the session of |gmsCtx| and returns all its rows.
+func queryRows(gmsCtx *gms.Context, se *engine.SqlEngine, query string, bindings map[string]sqlparser.Expr) ([]gms.Row, error) {
+	_, itr, _, err := se.GetUnderlyingEngine().QueryWithBindings(gmsCtx, query, nil, bindings, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []gms.Row
+	for {
+		row, err := itr.Next(gmsCtx)
+		if err == io.EOF {
+			return rows, itr.Close(gmsCtx)
+		} else if err != nil {
+			itr.Close(gmsCtx)
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,54 @@
+package embedded
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	connector, db, cleanUpFunc := initializeTestConnector(t)
+	defer cleanUpFunc()
+	connector.cfg.WatchInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := db.ExecContext(ctx, "create table t1 (id int primary key)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "create table t2 (id int primary key)")
+	require.NoError(t, err)
+
+	tableChanges, err := connector.Watch(ctx, "testdb", "t1")
+	require.NoError(t, err)
+	dbChanges, err := connector.Watch(ctx, "testdb", "")
+	require.NoError(t, err)
+	_, err = connector.Watch(ctx, "testdb", "missing")
+	require.Error(t, err)
+
+	_, err = db.ExecContext(ctx, "insert into t2 values (1)")
+	require.NoError(t, err)
+	change := <-dbChanges
+	require.NoError(t, change.Err)
+	require.Equal(t, "testdb", change.Database)
+	require.NotEqual(t, change.PreviousHash, change.Hash)
+
+	_, err = db.ExecContext(ctx, "insert into t1 values (1)")
+	require.NoError(t, err)
+	change = <-tableChanges
+	require.NoError(t, change.Err)
+	require.Equal(t, "t1", change.Table)
+	require.NotEqual(t, change.PreviousHash, change.Hash)
+
+	_, err = db.ExecContext(ctx, "drop table t1")
+	require.NoError(t, err)
+	change = <-tableChanges
+	require.Error(t, change.Err)
+	_, ok := <-tableChanges
+	require.False(t, ok)
+
+	cancel()
+	for range dbChanges {
+	}
+}